@@ -0,0 +1,20 @@
+// birdweather.go BirdWeather maintenance command code
+package birdweather
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// Command creates the birdweather parent command
+func Command(settings *conf.Settings) *cobra.Command {
+	bwCmd := &cobra.Command{
+		Use:   "birdweather",
+		Short: "BirdWeather integration commands",
+	}
+
+	// Add subcommands here
+	bwCmd.AddCommand(ResyncCommand(settings))
+
+	return bwCmd
+}
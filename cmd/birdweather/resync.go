@@ -0,0 +1,200 @@
+package birdweather
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/birdweather"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// ResyncCommand creates the birdweather resync subcommand
+func ResyncCommand(settings *conf.Settings) *cobra.Command {
+	var from, to string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "resync",
+		Short: "Submit historical detections to BirdWeather",
+		Long:  "Submit detections of a date range whose audio clips still exist to BirdWeather. Detections below the BirdWeather threshold and detections already submitted by this node are skipped.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, end, err := parseDateRange(from, to)
+			if err != nil {
+				return err
+			}
+
+			bw := settings.Realtime.Birdweather
+			if bw.ID == "" {
+				return fmt.Errorf("BirdWeather station ID is not configured")
+			}
+
+			client, err := birdweather.New(settings)
+			if err != nil {
+				return fmt.Errorf("failed to create BirdWeather client: %w", err)
+			}
+
+			store := datastore.New(settings)
+			if store == nil {
+				return fmt.Errorf("no database output is enabled in configuration")
+			}
+			if maintainer, ok := store.(datastore.Maintainer); ok {
+				err = maintainer.Connect()
+			} else {
+				err = store.Open()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+			defer store.Close()
+
+			notes, err := store.GetNotesBetween(start, end)
+			if err != nil {
+				return fmt.Errorf("failed to read detections: %w", err)
+			}
+
+			var submitted, skipped, failed int
+			for i := range notes {
+				note := &notes[i]
+
+				if note.Confidence < bw.Threshold || client.Submitted(note) {
+					skipped++
+					continue
+				}
+
+				clipPath := resolveClipPath(settings, note.ClipName)
+				if clipPath == "" {
+					skipped++
+					continue
+				}
+
+				if dryRun {
+					fmt.Printf("Would submit %s %s %s (%.2f)\n", note.Date, note.Time, note.CommonName, note.Confidence)
+					submitted++
+					continue
+				}
+
+				wavData, err := readClipAsWAV(settings, clipPath)
+				if err != nil {
+					fmt.Printf("Skipping %s %s %s: %v\n", note.Date, note.Time, note.CommonName, err)
+					failed++
+					continue
+				}
+
+				if err := client.SubmitWAV(note, wavData); err != nil {
+					fmt.Printf("Failed to submit %s %s %s: %v\n", note.Date, note.Time, note.CommonName, err)
+					failed++
+					continue
+				}
+				fmt.Printf("Submitted %s %s %s (%.2f)\n", note.Date, note.Time, note.CommonName, note.Confidence)
+				submitted++
+			}
+
+			verb := "Submitted"
+			if dryRun {
+				verb = "Would submit"
+			}
+			fmt.Printf("%s %d detections, skipped %d, failed %d\n", verb, submitted, skipped, failed)
+			if failed > 0 {
+				return fmt.Errorf("%d detections could not be submitted", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "First day to submit (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "Last day to submit (YYYY-MM-DD, default same as --from)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List detections that would be submitted without submitting them")
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
+
+// parseDateRange converts the inclusive local date range to the time range it covers.
+func parseDateRange(from, to string) (start, end time.Time, err error) {
+	start, err = time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return start, end, fmt.Errorf("invalid --from date %q, expected YYYY-MM-DD", from)
+	}
+	end = start
+	if to != "" {
+		end, err = time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid --to date %q, expected YYYY-MM-DD", to)
+		}
+	}
+	if end.Before(start) {
+		return start, end, fmt.Errorf("--to date is before --from date")
+	}
+	// The last day is included
+	return start, end.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// resolveClipPath returns the path of an existing audio clip, or an empty string if the clip
+// no longer exists. Clip names are relative to the audio export path, older clips may be stored
+// with a path relative to the working directory or an absolute path.
+func resolveClipPath(settings *conf.Settings, clipName string) string {
+	if clipName == "" {
+		return ""
+	}
+
+	candidates := []string{clipName}
+	if !filepath.IsAbs(clipName) {
+		candidates = []string{filepath.Join(settings.Realtime.Audio.Export.Path, clipName), clipName}
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// readClipAsWAV reads an audio clip as WAV. FLAC clips are decoded natively, clips in other
+// formats are converted to 48 kHz mono WAV with FFmpeg.
+func readClipAsWAV(settings *conf.Settings, path string) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		return os.ReadFile(path)
+	case ".flac":
+		samples, err := myaudio.ReadAudioFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode clip: %w", err)
+		}
+		return myaudio.ConvertPCMToWAV(float32ToPCM16(samples), conf.SampleRate, conf.BitDepth, conf.NumChannels)
+	}
+
+	ffmpegPath := settings.Realtime.Audio.FfmpegPath
+	if ffmpegPath == "" {
+		return nil, fmt.Errorf("FFmpeg is required to convert %s clips", filepath.Ext(path))
+	}
+
+	var stdout, stderr bytes.Buffer
+	ffmpeg := exec.Command(ffmpegPath, "-i", path, "-f", "wav", "-ac", "1", "-ar", fmt.Sprint(conf.SampleRate), "-loglevel", "error", "pipe:1")
+	ffmpeg.Stdout = &stdout
+	ffmpeg.Stderr = &stderr
+	if err := ffmpeg.Run(); err != nil {
+		return nil, fmt.Errorf("failed to convert clip to WAV: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// float32ToPCM16 converts samples in the range [-1, 1) to 16-bit little endian PCM.
+func float32ToPCM16(samples []float32) []byte {
+	pcm := make([]byte, 2*len(samples))
+	for i, sample := range samples {
+		v := math.Round(float64(sample) * 32768)
+		v = math.Max(math.MinInt16, math.Min(math.MaxInt16, v))
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(v)))
+	}
+	return pcm
+}
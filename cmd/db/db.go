@@ -0,0 +1,54 @@
+// db.go database maintenance command code
+package db
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// Command creates the db parent command
+func Command(settings *conf.Settings) *cobra.Command {
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Database maintenance commands",
+	}
+
+	// Add subcommands here
+	dbCmd.AddCommand(MigrateCommand(settings))
+	dbCmd.AddCommand(StatusCommand(settings))
+	dbCmd.AddCommand(ExportCommand(settings))
+	dbCmd.AddCommand(ImportCommand(settings))
+
+	return dbCmd
+}
+
+// connect opens the configured datastore without applying migrations. If sqlitePath
+// is set, the SQLite database at that path is used instead of the configured one.
+func connect(settings *conf.Settings, sqlitePath string) (datastore.Maintainer, error) {
+	if sqlitePath != "" {
+		override := *settings
+		override.Output.SQLite.Enabled = true
+		override.Output.SQLite.Path = sqlitePath
+		override.Output.MySQL.Enabled = false
+		settings = &override
+	}
+
+	ds := datastore.New(settings)
+	if ds == nil {
+		return nil, fmt.Errorf("no database output is enabled in configuration")
+	}
+
+	store, ok := ds.(datastore.Maintainer)
+	if !ok {
+		return nil, fmt.Errorf("configured datastore does not support maintenance operations")
+	}
+
+	if err := store.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return store, nil
+}
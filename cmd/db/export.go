@@ -0,0 +1,57 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// ExportCommand creates the db export subcommand
+func ExportCommand(settings *conf.Settings) *cobra.Command {
+	var output, sqlitePath string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the detection database to a portable archive",
+		Long:  "Export all detections, reviews, comments, locks, weather and image cache rows to a zip archive with one JSON Lines file per table and a manifest.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := connect(settings, sqlitePath)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			if output == "" {
+				output = fmt.Sprintf("birdnet-export-%s.zip", time.Now().Format("20060102_150405"))
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create archive file: %w", err)
+			}
+
+			manifest, err := store.Export(f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(output)
+				return fmt.Errorf("export failed: %w", err)
+			}
+
+			for _, t := range manifest.Tables {
+				fmt.Printf("%-16s %d rows\n", t.Name, t.Rows)
+			}
+			fmt.Printf("Database exported to %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Archive file to write (default birdnet-export-<timestamp>.zip)")
+	cmd.Flags().StringVar(&sqlitePath, "sqlite", "", "Export from this SQLite database instead of the configured database")
+
+	return cmd
+}
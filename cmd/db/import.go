@@ -0,0 +1,59 @@
+package db
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// ImportCommand creates the db import subcommand
+func ImportCommand(settings *conf.Settings) *cobra.Command {
+	var sqlitePath string
+
+	cmd := &cobra.Command{
+		Use:   "import <archive>",
+		Short: "Import a database archive created by db export",
+		Long:  "Import an archive created by db export into the configured database. Row IDs are remapped and rows that already exist are skipped, so an archive can be imported more than once.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open archive: %w", err)
+			}
+			defer f.Close()
+
+			info, err := f.Stat()
+			if err != nil {
+				return fmt.Errorf("failed to read archive: %w", err)
+			}
+
+			store, err := connect(settings, sqlitePath)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			// Make sure the target schema is current before writing to it
+			if err := store.Migrate(); err != nil {
+				return err
+			}
+
+			stats, importErr := store.Import(f, info.Size())
+			for _, s := range stats {
+				fmt.Printf("%-16s %d imported, %d skipped\n", s.Table, s.Imported, s.Skipped)
+			}
+			if importErr != nil {
+				return fmt.Errorf("import failed: %w", importErr)
+			}
+
+			fmt.Printf("Database imported from %s\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&sqlitePath, "sqlite", "", "Import into this SQLite database instead of the configured database")
+
+	return cmd
+}
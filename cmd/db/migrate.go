@@ -0,0 +1,51 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// MigrateCommand creates the db migrate subcommand
+func MigrateCommand(settings *conf.Settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending database migrations",
+		Long:  "Back up the database and apply all pending schema and data migrations.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := connect(settings, "")
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			before, err := store.MigrationStatus()
+			if err != nil {
+				return err
+			}
+
+			if err := store.Migrate(); err != nil {
+				return err
+			}
+
+			after, err := store.MigrationStatus()
+			if err != nil {
+				return err
+			}
+
+			applied := 0
+			for i := range after {
+				if after[i].Applied && !before[i].Applied {
+					fmt.Printf("Applied migration %d: %s\n", after[i].Version, after[i].Name)
+					applied++
+				}
+			}
+			if applied == 0 {
+				fmt.Println("Database schema is up to date")
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// StatusCommand creates the db status subcommand
+func StatusCommand(settings *conf.Settings) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the database schema version and pending migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := connect(settings, "")
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			status, err := store.MigrationStatus()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+			current, pending := 0, 0
+			for _, s := range status {
+				if s.Applied {
+					current = s.Version
+					fmt.Fprintf(w, "%d\t%s\tapplied\t%s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+				} else {
+					pending++
+					fmt.Fprintf(w, "%d\t%s\tpending\t-\n", s.Version, s.Name)
+				}
+			}
+			w.Flush()
+
+			fmt.Printf("\nSchema version: %d, pending migrations: %d\n", current, pending)
+			return nil
+		},
+	}
+}
@@ -2,6 +2,7 @@ package rangefilter
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -45,8 +46,39 @@ func PrintCommand(settings *conf.Settings) *cobra.Command {
 				}
 			}
 
+			format, _ := cmd.Flags().GetString("format")
+			year, _ := cmd.Flags().GetBool("year")
+			if format != "text" && format != "json" && format != "csv" {
+				fmt.Printf("Invalid output format: %s. Valid formats are text, json and csv.\n", format)
+				return
+			}
+
 			// Run the filter process
-			bn.RunFilterProcess(dateStr, week)
+			if format == "text" && !year {
+				bn.RunFilterProcess(dateStr, week)
+				return
+			}
+
+			var weeks []birdnet.WeekScores
+			if year {
+				weeks, err = bn.GetYearlySpeciesScores()
+			} else {
+				var date time.Time
+				if dateStr != "" {
+					date, _ = time.Parse("2006-01-02", dateStr)
+				}
+				var ws birdnet.WeekScores
+				ws, err = bn.GetWeekSpeciesScores(date, week)
+				weeks = []birdnet.WeekScores{ws}
+			}
+			if err != nil {
+				fmt.Printf("Error during species prediction: %s\n", err)
+				return
+			}
+
+			if err := birdnet.WriteSpeciesScores(os.Stdout, format, weeks); err != nil {
+				fmt.Printf("Error writing species scores: %s\n", err)
+			}
 		},
 	}
 
@@ -57,6 +89,8 @@ func PrintCommand(settings *conf.Settings) *cobra.Command {
 	printCmd.Flags().StringVar(&settings.BirdNET.RangeFilter.Model, "model", settings.BirdNET.RangeFilter.Model, "Model for range filter")
 	printCmd.Flags().String("date", "", "Date for the range filter process in ISO 8601 format (YYYY-MM-DD)")
 	printCmd.Flags().Int("week", 0, "Week number for the range filter process, values 1 to 48")
+	printCmd.Flags().String("format", "text", "Output format: text, json or csv")
+	printCmd.Flags().Bool("year", false, "Print the species of every week of the year")
 
 	return printCmd
 }
@@ -8,7 +8,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/tphakala/birdnet-go/internal/analysis"
 	"github.com/tphakala/birdnet-go/internal/conf"
-	"github.com/tphakala/birdnet-go/internal/httpcontroller/handlers"
+	"github.com/tphakala/birdnet-go/internal/notification"
 )
 
 // RealtimeCommand creates a new command for real-time audio analysis.
@@ -18,7 +18,7 @@ func Command(settings *conf.Settings) *cobra.Command {
 		Short: "Analyze audio in realtime mode",
 		Long:  "Start analyzing incoming audio data in real-time looking for bird calls.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			notificationChan := make(chan handlers.Notification, 10)
+			notificationChan := make(chan notification.Notification, 10)
 			return analysis.RealtimeAnalysis(settings, notificationChan)
 		},
 	}
@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/birdweather"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/hub"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
 	"github.com/tphakala/birdnet-go/internal/mqtt"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
@@ -31,16 +33,20 @@ type LogAction struct {
 	Settings     *conf.Settings
 	Note         datastore.Note
 	EventTracker *EventTracker
+	retry        bool       // true when the action is retried from the event queue
 	mu           sync.Mutex // Protect concurrent access to Note
 }
 
 type DatabaseAction struct {
-	Settings     *conf.Settings
-	Ds           datastore.Interface
-	Note         datastore.Note
-	Results      []datastore.Results
-	EventTracker *EventTracker
-	mu           sync.Mutex // Protect concurrent access to Note and Results
+	Settings      *conf.Settings
+	Ds            datastore.Interface
+	Note          datastore.Note
+	Results       []datastore.Results
+	EventTracker  *EventTracker
+	DetectionChan chan datastore.Note // receives the saved note for the live detection stream, may be nil
+	pcmData       []byte              // audio clip, read from the capture buffer on the first attempt
+	retry         bool                // true when the action is retried from the event queue
+	mu            sync.Mutex          // Protect concurrent access to Note and Results
 }
 
 type SaveAudioAction struct {
@@ -57,6 +63,7 @@ type BirdWeatherAction struct {
 	pcmData      []byte
 	BwClient     *birdweather.BwClient
 	EventTracker *EventTracker
+	retry        bool       // true when the action is retried from the event queue
 	mu           sync.Mutex // Protect concurrent access to Note and pcmData
 }
 
@@ -66,9 +73,21 @@ type MqttAction struct {
 	BirdImageCache *imageprovider.BirdImageCache
 	MqttClient     mqtt.Client
 	EventTracker   *EventTracker
+	retry          bool       // true when the action is retried from the event queue
 	mu             sync.Mutex // Protect concurrent access to Note
 }
 
+type HubAction struct {
+	Settings     *conf.Settings
+	Note         datastore.Note
+	Results      []datastore.Results
+	pcmData      []byte // audio clip, read from the capture buffer on the first attempt
+	HubClient    *hub.Client
+	EventTracker *EventTracker
+	retry        bool       // true when the action is retried from the event queue
+	mu           sync.Mutex // Protect concurrent access to Note, Results and pcmData
+}
+
 type UpdateRangeFilterAction struct {
 	Bn       *birdnet.BirdNET
 	Settings *conf.Settings
@@ -83,7 +102,7 @@ func (a *LogAction) Execute(data interface{}) error {
 	species := strings.ToLower(a.Note.CommonName)
 
 	// Check if the event should be handled for this species
-	if !a.EventTracker.TrackEvent(species, LogToFile) {
+	if !trackEvent(a.EventTracker, a.retry, species, LogToFile) {
 		return nil
 	}
 
@@ -105,30 +124,44 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 	species := strings.ToLower(a.Note.CommonName)
 
 	// Check event frequency
-	if !a.EventTracker.TrackEvent(species, DatabaseSave) {
+	if !trackEvent(a.EventTracker, a.retry, species, DatabaseSave) {
 		return nil
 	}
 
-	// Save note to database
-	if err := a.Ds.Save(&a.Note, a.Results); err != nil {
-		log.Printf("Failed to save note and results to database: %v", err)
-		return err
+	// Read the audio clip before the note is saved. The capture buffer holds only recent audio,
+	// so a clip that is no longer available after a restart or a long retry backoff cannot be
+	// recovered, and the note is saved without it. The clip is kept with the action so that a
+	// retried export does not depend on the capture buffer.
+	if a.Settings.Realtime.Audio.Export.Enabled && a.Note.ClipName != "" && a.pcmData == nil {
+		pcmData, err := myaudio.ReadSegmentFromCaptureBuffer(a.Note.Source, a.Note.BeginTime, a.Note.EndTime)
+		if err != nil {
+			log.Printf("Audio clip of %s is no longer available, saving detection without it: %v", a.Note.CommonName, err)
+			if a.Note.ID != 0 {
+				if err := a.Ds.DeleteNoteClipPath(strconv.FormatUint(uint64(a.Note.ID), 10)); err != nil {
+					return err
+				}
+			}
+			a.Note.ClipName = ""
+		} else {
+			a.pcmData = pcmData
+		}
 	}
 
-	// Save audio clip to file if enabled
-	if a.Settings.Realtime.Audio.Export.Enabled {
-		// export audio clip from capture buffer
-		pcmData, err := myaudio.ReadSegmentFromCaptureBuffer(a.Note.Source, a.Note.BeginTime, 15)
-		if err != nil {
-			log.Printf("Failed to read audio segment from buffer: %v", err)
+	// Save note to database, a retried note may already be saved if only the clip export failed
+	if a.Note.ID == 0 {
+		if err := a.Ds.Save(&a.Note, a.Results); err != nil {
+			log.Printf("Failed to save note and results to database: %v", err)
 			return err
 		}
+	}
 
+	// Save audio clip to file if enabled
+	if a.pcmData != nil {
 		// Create a SaveAudioAction and execute it
 		saveAudioAction := &SaveAudioAction{
 			Settings: a.Settings,
 			ClipName: a.Note.ClipName,
-			pcmData:  pcmData,
+			pcmData:  a.pcmData,
 		}
 
 		if err := saveAudioAction.Execute(nil); err != nil {
@@ -138,7 +171,16 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 
 		if a.Settings.Debug {
 			log.Printf("Saved audio clip to %s\n", a.Note.ClipName)
-			log.Printf("detection time %v, begin time %v, end time %v\n", a.Note.Time, a.Note.BeginTime, time.Now())
+			log.Printf("detection time %v, begin time %v, end time %v\n", a.Note.Time, a.Note.BeginTime, a.Note.EndTime)
+		}
+	}
+
+	// Publish the saved detection once its ID and audio clip are available
+	if a.DetectionChan != nil {
+		select {
+		case a.DetectionChan <- a.Note:
+		default:
+			log.Printf("Detection stream is not keeping up, dropped detection of %s", a.Note.CommonName)
 		}
 	}
 
@@ -159,16 +201,9 @@ func (a *SaveAudioAction) Execute(data interface{}) error {
 		return err
 	}
 
-	if a.Settings.Realtime.Audio.Export.Type == "wav" {
-		if err := myaudio.SavePCMDataToWAV(outputPath, a.pcmData); err != nil {
-			log.Printf("error saving audio clip to WAV: %s\n", err)
-			return err
-		}
-	} else {
-		if err := myaudio.ExportAudioWithFFmpeg(a.pcmData, outputPath, &a.Settings.Realtime.Audio); err != nil {
-			log.Printf("error exporting audio clip with FFmpeg: %s\n", err)
-			return err
-		}
+	if err := myaudio.ExportAudio(a.pcmData, outputPath, &a.Settings.Realtime.Audio); err != nil {
+		log.Printf("error exporting audio clip to %s: %s\n", a.Settings.Realtime.Audio.Export.Type, err)
+		return err
 	}
 
 	if a.Settings.Debug {
@@ -195,7 +230,7 @@ func (a *BirdWeatherAction) Execute(data interface{}) error {
 	species := strings.ToLower(a.Note.CommonName)
 
 	// Check event frequency
-	if !a.EventTracker.TrackEvent(species, BirdWeatherSubmit) {
+	if !trackEvent(a.EventTracker, a.retry, species, BirdWeatherSubmit) {
 		return nil
 	}
 
@@ -234,32 +269,36 @@ func (a *MqttAction) Execute(data interface{}) error {
 	species := strings.ToLower(a.Note.CommonName)
 
 	// Check event frequency
-	if !a.EventTracker.TrackEvent(species, MQTTPublish) {
+	if !trackEvent(a.EventTracker, a.retry, species, MQTTPublish) {
 		return nil
 	}
 
-	// First, check if the MQTT client is connected
+	// First, check if the MQTT client is connected, the event queue retries the publish later
 	if !a.MqttClient.IsConnected() {
-		log.Println("MQTT client is not connected, skipping publish")
-		return nil
+		return fmt.Errorf("MQTT client is not connected")
 	}
 
 	// Validate MQTT settings
-	if a.Settings.Realtime.MQTT.Topic == "" {
+	topic := a.topic()
+	if topic == "" {
 		return fmt.Errorf("MQTT topic is not specified")
 	}
 
-	// Get bird image of detected bird
-	birdImage, err := a.BirdImageCache.Get(a.Note.ScientificName)
-	if err != nil {
-		birdImage = imageprovider.BirdImage{}
-	}
-
-	// Wrap note with bird image
-	noteWithBirdImage := NoteWithBirdImage{Note: a.Note, BirdImage: birdImage}
+	// Create a JSON representation of the note, with the bird image unless a light payload is used
+	var noteJson []byte
+	var err error
+	if a.Settings.Realtime.MQTT.PayloadMode == conf.MQTTPayloadLight {
+		noteJson, err = json.Marshal(a.Note)
+	} else {
+		// Get bird image of detected bird
+		birdImage, imageErr := a.BirdImageCache.Get(a.Note.ScientificName)
+		if imageErr != nil {
+			birdImage = imageprovider.BirdImage{}
+		}
 
-	// Create a JSON representation of the note
-	noteJson, err := json.Marshal(noteWithBirdImage)
+		// Wrap note with bird image
+		noteJson, err = json.Marshal(NoteWithBirdImage{Note: a.Note, BirdImage: birdImage})
+	}
 	if err != nil {
 		log.Printf("error marshalling note to JSON: %s\n", err)
 		return err
@@ -270,7 +309,11 @@ func (a *MqttAction) Execute(data interface{}) error {
 	defer cancel()
 
 	// Publish the note to the MQTT broker
-	err = a.MqttClient.Publish(ctx, a.Settings.Realtime.MQTT.Topic, string(noteJson))
+	if a.Settings.Realtime.MQTT.Retain {
+		err = a.MqttClient.PublishRetained(ctx, topic, string(noteJson))
+	} else {
+		err = a.MqttClient.Publish(ctx, topic, string(noteJson))
+	}
 	if err != nil {
 		return fmt.Errorf("failed to publish to MQTT: %w", err)
 	}
@@ -278,26 +321,115 @@ func (a *MqttAction) Execute(data interface{}) error {
 	return nil
 }
 
-// Execute updates the range filter species list, this is run every day
-func (a *UpdateRangeFilterAction) Execute(data interface{}) error {
+// topic returns the MQTT topic of the note, using the topic of the species if it overrides the
+// configured topic.
+func (a *MqttAction) topic() string {
+	template := a.Settings.Realtime.MQTT.Topic
+	if config, exists := a.Settings.GetSpeciesConfig(strings.ToLower(a.Note.CommonName)); exists && config.MQTTTopic != "" {
+		template = config.MQTTTopic
+	}
+	if template == "" {
+		return ""
+	}
+	return mqtt.ExpandTopic(template, &a.Note)
+}
+
+// Execute pushes the note, its results and audio clip to the hub
+func (a *HubAction) Execute(data interface{}) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	today := time.Now().Truncate(24 * time.Hour)
-	if today.After(a.Settings.BirdNET.RangeFilter.LastUpdated) {
-		// Update location based species list
-		speciesScores, err := a.Bn.GetProbableSpecies(today, 0.0)
+	species := strings.ToLower(a.Note.CommonName)
+
+	// Check event frequency
+	if !trackEvent(a.EventTracker, a.retry, species, HubPush) {
+		return nil
+	}
+
+	if a.HubClient == nil {
+		return fmt.Errorf("hub client is not initialized")
+	}
+
+	// Send the same clip as saved locally, spanning the whole detection. It is read from the
+	// capture buffer on the first attempt and kept with the action for retries.
+	if a.pcmData == nil {
+		pcmData, err := myaudio.ReadSegmentFromCaptureBuffer(a.Note.Source, a.Note.BeginTime, a.Note.EndTime)
 		if err != nil {
-			return err
+			log.Printf("Audio clip of %s is no longer available, pushing detection to hub without it: %v", a.Note.CommonName, err)
+		} else {
+			a.pcmData = pcmData
 		}
+	}
 
-		// Convert the speciesScores slice to a slice of species labels
-		var includedSpecies []string
-		for _, speciesScore := range speciesScores {
-			includedSpecies = append(includedSpecies, speciesScore.Label)
+	sub := &hub.Submission{Note: a.Note, Results: a.Results}
+	if len(a.pcmData) > 0 {
+		wavData, err := myaudio.ConvertPCMToWAV(a.pcmData, conf.SampleRate, conf.BitDepth, conf.NumChannels)
+		if err != nil {
+			return fmt.Errorf("failed to encode clip for hub: %w", err)
 		}
+		sub.Clip = wavData
+	}
+
+	if err := a.HubClient.Push(sub); err != nil {
+		log.Printf("error pushing detection to hub: %s\n", err)
+		return err
+	} else if a.Settings.Debug {
+		log.Printf("Pushed detection of %s to hub\n", a.Note.CommonName)
+	}
+	return nil
+}
 
-		a.Settings.UpdateIncludedSpecies(includedSpecies)
+// QueueKind returns the event queue kind of the action
+func (a *LogAction) QueueKind() string { return queueKindLog }
+
+// QueuePayload serializes the action for the event queue
+func (a *LogAction) QueuePayload() ([]byte, error) {
+	return json.Marshal(queuedAction{Note: a.Note})
+}
+
+// QueueKind returns the event queue kind of the action
+func (a *DatabaseAction) QueueKind() string { return queueKindDatabase }
+
+// QueuePayload serializes the action for the event queue. The note ID is kept so that a
+// retry does not save the note twice, and the audio clip so that it is not lost with the
+// capture buffer.
+func (a *DatabaseAction) QueuePayload() ([]byte, error) {
+	return json.Marshal(queuedAction{Note: a.Note, Results: a.Results, PCMData: a.pcmData})
+}
+
+// QueueKind returns the event queue kind of the action
+func (a *BirdWeatherAction) QueueKind() string { return queueKindBirdWeather }
+
+// QueuePayload serializes the action for the event queue
+func (a *BirdWeatherAction) QueuePayload() ([]byte, error) {
+	return json.Marshal(queuedAction{Note: a.Note, PCMData: a.pcmData})
+}
+
+// QueueKind returns the event queue kind of the action
+func (a *MqttAction) QueueKind() string { return queueKindMQTT }
+
+// QueuePayload serializes the action for the event queue
+func (a *MqttAction) QueuePayload() ([]byte, error) {
+	return json.Marshal(queuedAction{Note: a.Note})
+}
+
+// QueueKind returns the event queue kind of the action
+func (a *HubAction) QueueKind() string { return queueKindHub }
+
+// QueuePayload serializes the action for the event queue
+func (a *HubAction) QueuePayload() ([]byte, error) {
+	return json.Marshal(queuedAction{Note: a.Note, Results: a.Results, PCMData: a.pcmData})
+}
+
+// Execute updates the range filter species list, this is run every day
+func (a *UpdateRangeFilterAction) Execute(data interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	today := time.Now().Truncate(24 * time.Hour)
+	if today.After(a.Settings.BirdNET.RangeFilter.LastUpdated) {
+		// Update location based species list and its history
+		return birdnet.BuildRangeFilter(a.Bn)
 	}
 	return nil
 }
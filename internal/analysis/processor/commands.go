@@ -0,0 +1,330 @@
+// commands.go handles remote control commands received over MQTT
+package processor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/mqtt"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// Remote commands accepted on the MQTT command topic
+const (
+	CommandRebuildRangeFilter = "rebuild_range_filter"
+	CommandReloadBirdNET      = "reload_birdnet"
+	CommandReconfigureSources = "reconfigure_sources"
+	CommandSetThreshold       = "set_threshold"
+	CommandPauseSource        = "pause_source"
+	CommandResumeSource       = "resume_source"
+	CommandStatus             = "status"
+)
+
+// controlSignalTimeout limits how long a command waits for the control monitor to accept a signal
+const controlSignalTimeout = 5 * time.Second
+
+// commandMaxAge is how far the timestamp of a command may differ from the clock of the node.
+// Command IDs are remembered for twice as long, so a replayed command is either stale or seen.
+const commandMaxAge = 5 * time.Minute
+
+// SignedCommand is the message received on the MQTT command topic. The command is signed with
+// HMAC-SHA256 using the shared secret from realtime.mqtt.commands.token as the key, over the
+// exact bytes of the command object as sent.
+type SignedCommand struct {
+	Command   json.RawMessage `json:"command"`
+	Signature string          `json:"signature"` // hex encoded HMAC-SHA256 of command
+}
+
+// RemoteCommand is a command received on the MQTT command topic.
+type RemoteCommand struct {
+	ID        string `json:"id"`             // unique ID, copied to the reply and never accepted twice
+	Timestamp int64  `json:"timestamp"`      // Unix time the command was sent at
+	Node      string `json:"node,omitempty"` // node the command is meant for, empty for all nodes
+	Command   string `json:"command"`
+
+	// Parameters of set_threshold, pause_source and resume_source
+	Source    string   `json:"source,omitempty"`    // audio source name
+	Species   string   `json:"species,omitempty"`   // common name of the species
+	Threshold *float64 `json:"threshold,omitempty"` // new confidence threshold
+}
+
+// RemoteReply is published on the MQTT reply topic in response to a command.
+type RemoteReply struct {
+	ID      string      `json:"id,omitempty"`
+	Node    string      `json:"node"`
+	Command string      `json:"command"`
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NodeStatus is the data of the reply to a status command.
+type NodeStatus struct {
+	Node          string         `json:"node"`
+	Version       string         `json:"version"`
+	Uptime        string         `json:"uptime"`
+	Threshold     float64        `json:"threshold"`
+	Sensitivity   float64        `json:"sensitivity"`
+	Sources       []SourceStatus `json:"sources"`
+	MQTTConnected bool           `json:"mqttConnected"`
+	QueuedActions int64          `json:"queuedActions"`
+	DeadLetters   int64          `json:"deadLetters"`
+}
+
+// SourceStatus describes an analysis source in a status reply.
+type SourceStatus struct {
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Paused    bool    `json:"paused"`
+}
+
+// commandTopics returns the command and reply topics.
+func commandTopics(settings *conf.Settings) (commandTopic, replyTopic string) {
+	base := mqtt.BaseTopic(settings.Realtime.MQTT.Topic)
+	commandTopic = settings.Realtime.MQTT.Commands.Topic
+	if commandTopic == "" {
+		commandTopic = base + "/command"
+	}
+	replyTopic = settings.Realtime.MQTT.Commands.ReplyTopic
+	if replyTopic == "" {
+		replyTopic = base + "/reply"
+	}
+	return commandTopic, replyTopic
+}
+
+// startCommandListener subscribes to the MQTT command topic.
+func (p *Processor) startCommandListener() {
+	commandTopic, _ := commandTopics(p.Settings)
+	if err := p.MqttClient.Subscribe(commandTopic, p.handleCommandMessage); err != nil {
+		log.Printf("failed to subscribe to MQTT command topic %s: %s", commandTopic, err)
+	}
+}
+
+// handleCommandMessage authenticates and executes a command, and publishes the reply.
+func (p *Processor) handleCommandMessage(topic string, payload []byte) {
+	cmd, err := p.authenticateCommand(payload, time.Now())
+	if err != nil {
+		// Unauthenticated commands get no reply so the topic cannot be probed
+		log.Printf("Rejected MQTT command: %v\n", err)
+		return
+	}
+
+	// Commands addressed to other nodes of a fleet are ignored silently
+	if cmd.Node != "" && cmd.Node != p.Settings.Main.Name {
+		return
+	}
+
+	reply := p.executeCommand(cmd)
+	reply.ID = cmd.ID
+	reply.Node = p.Settings.Main.Name
+	reply.Command = cmd.Command
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("Failed to encode MQTT command reply: %v\n", err)
+		return
+	}
+
+	_, replyTopic := commandTopics(p.Settings)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := p.MqttClient.Publish(ctx, replyTopic, string(data)); err != nil {
+		log.Printf("Failed to publish MQTT command reply: %v\n", err)
+	}
+}
+
+// authenticateCommand verifies the signature of a command and rejects commands that are stale
+// or were seen before.
+func (p *Processor) authenticateCommand(payload []byte, now time.Time) (*RemoteCommand, error) {
+	var signed SignedCommand
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	token := p.Settings.Realtime.MQTT.Commands.Token
+	signature, err := hex.DecodeString(signed.Signature)
+	if token == "" || err != nil || !hmac.Equal(signature, SignCommand(signed.Command, token)) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	var cmd RemoteCommand
+	if err := json.Unmarshal(signed.Command, &cmd); err != nil {
+		return nil, fmt.Errorf("invalid command: %w", err)
+	}
+	if cmd.ID == "" {
+		return nil, fmt.Errorf("command %q has no ID", cmd.Command)
+	}
+	if age := now.Sub(time.Unix(cmd.Timestamp, 0)); age > commandMaxAge || age < -commandMaxAge {
+		return nil, fmt.Errorf("command %s is stale, sent at %s", cmd.ID, time.Unix(cmd.Timestamp, 0).Format(time.RFC3339))
+	}
+
+	p.commandsMutex.Lock()
+	defer p.commandsMutex.Unlock()
+	for id, seen := range p.seenCommands {
+		if now.Sub(seen) > 2*commandMaxAge {
+			delete(p.seenCommands, id)
+		}
+	}
+	if _, seen := p.seenCommands[cmd.ID]; seen {
+		return nil, fmt.Errorf("command %s was already received", cmd.ID)
+	}
+	if p.seenCommands == nil {
+		p.seenCommands = make(map[string]time.Time)
+	}
+	p.seenCommands[cmd.ID] = now
+	return &cmd, nil
+}
+
+// SignCommand returns the HMAC-SHA256 signature of an encoded command.
+func SignCommand(command []byte, token string) []byte {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write(command)
+	return mac.Sum(nil)
+}
+
+// executeCommand executes an authenticated command.
+func (p *Processor) executeCommand(cmd *RemoteCommand) RemoteReply {
+	var err error
+	var message string
+	var data interface{}
+
+	switch cmd.Command {
+	case CommandRebuildRangeFilter, CommandReloadBirdNET:
+		err = p.sendControlSignal(cmd.Command)
+		message = "accepted"
+	case CommandReconfigureSources, "reconfigure_rtsp_sources":
+		err = p.sendControlSignal("reconfigure_rtsp_sources")
+		message = "accepted"
+	case CommandSetThreshold:
+		message, err = p.setThreshold(cmd)
+	case CommandPauseSource, CommandResumeSource:
+		message, err = p.setSourcePaused(cmd.Source, cmd.Command == CommandPauseSource)
+	case CommandStatus:
+		data = p.nodeStatus()
+	default:
+		err = fmt.Errorf("unknown command %q", cmd.Command)
+	}
+
+	if err != nil {
+		log.Printf("MQTT command %s failed: %v\n", cmd.Command, err)
+		return RemoteReply{Success: false, Message: err.Error()}
+	}
+	log.Printf("Executed MQTT command %s\n", cmd.Command)
+	return RemoteReply{Success: true, Message: message, Data: data}
+}
+
+// sendControlSignal hands a control signal to the realtime control monitor.
+func (p *Processor) sendControlSignal(signal string) error {
+	if p.controlChan == nil {
+		return fmt.Errorf("control signals are not available")
+	}
+	select {
+	case p.controlChan <- signal:
+		return nil
+	case <-time.After(controlSignalTimeout):
+		return fmt.Errorf("node is busy, try again later")
+	}
+}
+
+// setThreshold changes the confidence threshold of a species, an audio source or the global
+// threshold, and saves the settings. Commands run on the MQTT client goroutine while detections
+// are processed, so the settings are changed through the locked setters of conf.
+func (p *Processor) setThreshold(cmd *RemoteCommand) (string, error) {
+	if cmd.Threshold == nil || *cmd.Threshold < 0 || *cmd.Threshold > 1 {
+		return "", fmt.Errorf("threshold must be between 0 and 1")
+	}
+	threshold := *cmd.Threshold
+
+	var message string
+	switch {
+	case cmd.Species != "" && cmd.Source != "":
+		return "", fmt.Errorf("threshold can be set for a species or a source, not both")
+	case cmd.Species != "":
+		p.Settings.SetSpeciesThreshold(cmd.Species, threshold)
+		message = fmt.Sprintf("threshold of %s set to %.2f", cmd.Species, threshold)
+	case cmd.Source != "":
+		if !p.Settings.SetSourceThreshold(cmd.Source, threshold) {
+			return "", fmt.Errorf("unknown audio source %q, only named sources have thresholds", cmd.Source)
+		}
+		message = fmt.Sprintf("threshold of source %s set to %.2f", cmd.Source, threshold)
+	default:
+		p.Settings.SetThreshold(threshold)
+		message = fmt.Sprintf("global threshold set to %.2f", threshold)
+	}
+
+	if err := conf.SaveSettings(); err != nil {
+		return "", fmt.Errorf("threshold changed but settings could not be saved: %w", err)
+	}
+	return message, nil
+}
+
+// setSourcePaused pauses or resumes analysis of an audio source, or of all channels of a
+// multichannel source.
+func (p *Processor) setSourcePaused(name string, paused bool) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("source is required")
+	}
+
+	var analysisSources []string
+	if source, _, found := p.Settings.FindAnalysisSource(name); found {
+		analysisSources = []string{name}
+		if source.Name == name {
+			analysisSources = source.AnalysisSources()
+		}
+	} else {
+		return "", fmt.Errorf("unknown audio source %q", name)
+	}
+
+	for _, analysisSource := range analysisSources {
+		if paused {
+			myaudio.PauseSource(analysisSource)
+		} else {
+			myaudio.ResumeSource(analysisSource)
+		}
+	}
+
+	if paused {
+		return fmt.Sprintf("source %s paused", name), nil
+	}
+	return fmt.Sprintf("source %s resumed", name), nil
+}
+
+// nodeStatus returns the status of the node.
+func (p *Processor) nodeStatus() NodeStatus {
+	status := NodeStatus{
+		Node:          p.Settings.Main.Name,
+		Version:       p.Settings.Version,
+		Uptime:        time.Since(p.startTime).Round(time.Second).String(),
+		Threshold:     p.Settings.GetThreshold(),
+		Sensitivity:   p.Settings.BirdNET.Sensitivity,
+		MQTTConnected: p.MqttClient != nil && p.MqttClient.IsConnected(),
+	}
+
+	for _, source := range p.Settings.EnabledAudioSources() {
+		for _, name := range source.AnalysisSources() {
+			status.Sources = append(status.Sources, SourceStatus{
+				Name:      name,
+				Type:      source.Type,
+				Threshold: source.Threshold,
+				Paused:    myaudio.IsSourcePaused(name),
+			})
+		}
+	}
+
+	if p.EventQueue != nil {
+		pending, deadLetters, err := p.EventQueue.Stats()
+		if err == nil {
+			status.QueuedActions = pending
+			status.DeadLetters = deadLetters
+		}
+	}
+	return status
+}
@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/mqtt"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// replyRecorder is an MQTT client that records published messages.
+type replyRecorder struct {
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func (r *replyRecorder) Connect(ctx context.Context) error { return nil }
+
+func (r *replyRecorder) Publish(ctx context.Context, topic, payload string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages[topic] = append(r.messages[topic], payload)
+	return nil
+}
+
+func (r *replyRecorder) PublishRetained(ctx context.Context, topic, payload string) error {
+	return r.Publish(ctx, topic, payload)
+}
+
+func (r *replyRecorder) Subscribe(topic string, handler mqtt.MessageHandler) error { return nil }
+func (r *replyRecorder) IsConnected() bool                                         { return true }
+func (r *replyRecorder) Disconnect()                                               {}
+func (r *replyRecorder) SetOnConnectHandler(handler func())                        {}
+
+// replies decodes the replies published on the topic.
+func (r *replyRecorder) replies(t *testing.T, topic string) []RemoteReply {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var replies []RemoteReply
+	for _, payload := range r.messages[topic] {
+		var reply RemoteReply
+		if err := json.Unmarshal([]byte(payload), &reply); err != nil {
+			t.Fatalf("invalid reply %s: %v", payload, err)
+		}
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+func newCommandTestProcessor() (*Processor, *replyRecorder) {
+	settings := &conf.Settings{}
+	settings.Main.Name = "node1"
+	settings.Realtime.MQTT.Topic = "birdnet/{source}"
+	settings.Realtime.MQTT.Commands.Enabled = true
+	settings.Realtime.MQTT.Commands.Token = "secret"
+	settings.Realtime.Audio.Sources = []conf.AudioSourceSettings{
+		{Name: "garden", Type: conf.SourceTypeSoundCard, Device: "hw:1", Enabled: true, Channels: 2},
+	}
+
+	recorder := &replyRecorder{messages: make(map[string][]string)}
+	return &Processor{
+		Settings:    settings,
+		MqttClient:  recorder,
+		controlChan: make(chan string, 1),
+		startTime:   time.Now(),
+	}, recorder
+}
+
+// signCommand encodes a command with the given ID and timestamp and signs it with token.
+func signCommand(token, id string, timestamp time.Time, fields string) []byte {
+	command := fmt.Sprintf(`{"id":%q,"timestamp":%d,%s}`, id, timestamp.Unix(), fields)
+	signed, _ := json.Marshal(SignedCommand{
+		Command:   json.RawMessage(command),
+		Signature: hex.EncodeToString(SignCommand([]byte(command), token)),
+	})
+	return signed
+}
+
+// commandCount numbers the commands sent by tests so that their IDs are unique.
+var commandCount int
+
+// command returns a command signed with the test token.
+func command(fields string) []byte {
+	commandCount++
+	return signCommand("secret", fmt.Sprintf("test-%d", commandCount), time.Now(), fields)
+}
+
+func TestCommandAuthentication(t *testing.T) {
+	p, recorder := newCommandTestProcessor()
+	now := time.Now()
+
+	p.handleCommandMessage("birdnet/command", signCommand("wrong", "1", now, `"command":"status"`))
+	p.handleCommandMessage("birdnet/command", signCommand("secret", "2", now, `"node":"node2","command":"status"`))
+	p.handleCommandMessage("birdnet/command", signCommand("secret", "3", now.Add(-10*time.Minute), `"command":"status"`))
+	p.handleCommandMessage("birdnet/command", signCommand("secret", "", now, `"command":"status"`))
+	p.handleCommandMessage("birdnet/command", []byte(`{"command":{"id":"4","command":"status"},"signature":"00"}`))
+	p.handleCommandMessage("birdnet/command", []byte(`not json`))
+
+	// A command whose signed bytes were changed after signing is rejected
+	tampered := bytes.Replace(signCommand("secret", "5", now, `"command":"status"`), []byte(`status`), []byte(`statuS`), 1)
+	p.handleCommandMessage("birdnet/command", tampered)
+	if replies := recorder.replies(t, "birdnet/reply"); len(replies) != 0 {
+		t.Fatalf("expected no replies to rejected commands, got %+v", replies)
+	}
+
+	status := signCommand("secret", "42", now, `"node":"node1","command":"status"`)
+	p.handleCommandMessage("birdnet/command", status)
+	p.handleCommandMessage("birdnet/command", status) // replayed
+	replies := recorder.replies(t, "birdnet/reply")
+	if len(replies) != 1 {
+		t.Fatalf("expected 1 reply, got %d", len(replies))
+	}
+	if !replies[0].Success || replies[0].ID != "42" || replies[0].Node != "node1" || replies[0].Command != CommandStatus {
+		t.Errorf("unexpected status reply %+v", replies[0])
+	}
+}
+
+func TestCommandControlSignalAndPause(t *testing.T) {
+	p, recorder := newCommandTestProcessor()
+	defer myaudio.ResumeSource("garden_ch1")
+	defer myaudio.ResumeSource("garden_ch2")
+
+	p.handleCommandMessage("birdnet/command", command(`"command":"reconfigure_sources"`))
+	select {
+	case signal := <-p.controlChan:
+		if signal != "reconfigure_rtsp_sources" {
+			t.Errorf("unexpected control signal %q", signal)
+		}
+	default:
+		t.Error("expected control signal to be sent")
+	}
+
+	p.handleCommandMessage("birdnet/command", command(`"command":"pause_source","source":"garden"`))
+	if !myaudio.IsSourcePaused("garden_ch1") || !myaudio.IsSourcePaused("garden_ch2") {
+		t.Error("expected all channels of the source to be paused")
+	}
+	p.handleCommandMessage("birdnet/command", command(`"command":"resume_source","source":"garden_ch2"`))
+	if !myaudio.IsSourcePaused("garden_ch1") || myaudio.IsSourcePaused("garden_ch2") {
+		t.Error("expected only the resumed channel to be analyzed")
+	}
+
+	p.handleCommandMessage("birdnet/command", command(`"command":"pause_source","source":"pond"`))
+	p.handleCommandMessage("birdnet/command", command(`"command":"set_threshold","threshold":1.5`))
+	p.handleCommandMessage("birdnet/command", command(`"command":"explode"`))
+
+	replies := recorder.replies(t, "birdnet/reply")
+	if len(replies) != 6 {
+		t.Fatalf("expected 6 replies, got %d", len(replies))
+	}
+	for i, reply := range replies {
+		if wantSuccess := i < 3; reply.Success != wantSuccess {
+			t.Errorf("reply %d to %s: success = %v, want %v (%s)", i, reply.Command, reply.Success, wantSuccess, reply.Message)
+		}
+	}
+}
+
+func TestThresholdChangesDuringDetection(t *testing.T) {
+	// Thresholds are changed by commands on the MQTT client goroutine while detections are
+	// processed, which must not race on the species configuration map
+	p, _ := newCommandTestProcessor()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			p.Settings.SetSpeciesThreshold("Great Tit", 0.5)
+			p.Settings.SetSpeciesThreshold("Eurasian Blue Tit", 0.6)
+			p.Settings.SetSourceThreshold("garden", 0.7)
+			p.Settings.SetThreshold(0.8)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		p.getBaseConfidenceThreshold("great tit", "garden")
+		p.getBaseConfidenceThreshold("common blackbird", "garden_ch1")
+	}
+	<-done
+
+	if threshold := p.getBaseConfidenceThreshold("great tit", "garden"); threshold != 0.5 {
+		t.Errorf("species threshold = %v, want 0.5", threshold)
+	}
+	if threshold := p.getBaseConfidenceThreshold("common blackbird", "garden_ch1"); threshold != 0.7 {
+		t.Errorf("source threshold = %v, want 0.7", threshold)
+	}
+	if threshold := p.getBaseConfidenceThreshold("common blackbird", "other"); threshold != 0.8 {
+		t.Errorf("global threshold = %v, want 0.8", threshold)
+	}
+}
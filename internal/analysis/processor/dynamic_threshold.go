@@ -2,11 +2,26 @@ package processor
 
 import (
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/datastore"
 )
 
+// dynamicThresholdSaveInterval limits how often changed dynamic thresholds are saved, the
+// timer of a threshold is extended on every detection of its species
+const dynamicThresholdSaveInterval = time.Minute
+
+// markThresholdChanged records that the dynamic threshold of a species has unsaved changes.
+// The caller must hold thresholdsMutex.
+func (p *Processor) markThresholdChanged(species string) {
+	if p.thresholdsChanged == nil {
+		p.thresholdsChanged = make(map[string]bool)
+	}
+	p.thresholdsChanged[species] = true
+}
+
 // addSpeciesToDynamicThresholds adds a species to the dynamic thresholds map if it doesn't already exist.
 func (p *Processor) addSpeciesToDynamicThresholds(speciesLowercase string, baseThreshold float32) {
 	// Lock the mutex to ensure thread-safe access to the DynamicThresholds map
@@ -28,6 +43,7 @@ func (p *Processor) addSpeciesToDynamicThresholds(speciesLowercase string, baseT
 			HighConfCount: 0,
 			ValidHours:    p.Settings.Realtime.DynamicThreshold.ValidHours,
 		}
+		p.markThresholdChanged(speciesLowercase)
 	}
 }
 
@@ -50,23 +66,20 @@ func (p *Processor) getAdjustedConfidenceThreshold(speciesLowercase string, resu
 		dt.HighConfCount++
 		dt.Timer = time.Now().Add(time.Duration(dt.ValidHours) * time.Hour)
 
-		// Adjust the dynamic threshold based on the number of high-confidence detections
-		switch dt.HighConfCount {
-		case 1:
-			dt.Level = 1
-			dt.CurrentValue = float64(baseThreshold * 0.75)
-		case 2:
-			dt.Level = 2
-			dt.CurrentValue = float64(baseThreshold * 0.5)
-		case 3:
-			dt.Level = 3
-			dt.CurrentValue = float64(baseThreshold * 0.25)
+		// Step to the highest level of the schedule reached by the high-confidence detections
+		for i, level := range p.Settings.Realtime.DynamicThreshold.Levels {
+			if dt.HighConfCount >= level.Count {
+				dt.Level = i + 1
+				dt.CurrentValue = float64(baseThreshold) * level.Multiplier
+			}
 		}
-	} else if time.Now().After(dt.Timer) {
+		p.markThresholdChanged(speciesLowercase)
+	} else if time.Now().After(dt.Timer) && (dt.Level > 0 || dt.HighConfCount > 0) {
 		// Reset the dynamic threshold if the timer has expired
 		dt.Level = 0
 		dt.CurrentValue = float64(baseThreshold)
 		dt.HighConfCount = 0
+		p.markThresholdChanged(speciesLowercase)
 	}
 
 	// Ensure the dynamic threshold doesn't fall below the minimum threshold
@@ -78,18 +91,19 @@ func (p *Processor) getAdjustedConfidenceThreshold(speciesLowercase string, resu
 }
 
 // updateDynamicThreshold updates the dynamic threshold for a given species if enabled.
-func (p *Processor) updateDynamicThreshold(commonName string, confidence float64) {
+func (p *Processor) updateDynamicThreshold(commonName, source string, confidence float64) {
 	if p.Settings.Realtime.DynamicThreshold.Enabled {
 		// Lock the mutex to ensure thread-safe access to the DynamicThresholds map
 		p.thresholdsMutex.Lock()
 		defer p.thresholdsMutex.Unlock()
 
 		// Check if the species already has a dynamic threshold
-		if dt, exists := p.DynamicThresholds[commonName]; exists && confidence > float64(p.getBaseConfidenceThreshold(commonName)) {
+		if dt, exists := p.DynamicThresholds[commonName]; exists && confidence > float64(p.getBaseConfidenceThreshold(commonName, source)) {
 			// Update the timer to extend the threshold's validity
 			dt.Timer = time.Now().Add(time.Duration(dt.ValidHours) * time.Hour)
 			// Since we're modifying a struct in the map, we need to reassign it
 			p.DynamicThresholds[commonName] = dt
+			p.markThresholdChanged(commonName)
 		}
 	}
 }
@@ -116,6 +130,123 @@ func (p *Processor) cleanUpDynamicThresholds() {
 			}
 			// Remove the stale threshold from the map
 			delete(p.DynamicThresholds, species)
+			p.markThresholdChanged(species)
+		}
+	}
+}
+
+// loadDynamicThresholds restores the dynamic thresholds saved in the datastore, skipping
+// thresholds that went stale while the processor was not running.
+func (p *Processor) loadDynamicThresholds() {
+	saved, err := p.Ds.GetDynamicThresholds()
+	if err != nil {
+		log.Printf("Failed to load dynamic thresholds: %v", err)
+		return
+	}
+
+	validHours := p.Settings.Realtime.DynamicThreshold.ValidHours
+	staleDuration := time.Duration(validHours) * time.Hour
+	now := time.Now()
+
+	p.thresholdsMutex.Lock()
+	defer p.thresholdsMutex.Unlock()
+
+	for _, t := range saved {
+		if now.Sub(t.ExpiresAt) > staleDuration {
+			p.markThresholdChanged(t.Species)
+			continue
+		}
+		p.DynamicThresholds[t.Species] = &DynamicThreshold{
+			Level:         t.Level,
+			CurrentValue:  t.CurrentValue,
+			Timer:         t.ExpiresAt,
+			HighConfCount: t.HighConfCount,
+			ValidHours:    validHours,
+		}
+	}
+	if p.Settings.Realtime.DynamicThreshold.Debug {
+		log.Printf("Restored %d dynamic thresholds", len(p.DynamicThresholds))
+	}
+}
+
+// saveDynamicThresholds saves changed dynamic thresholds to the datastore and deletes removed
+// ones. Unless forced, changes are saved at most once per dynamicThresholdSaveInterval.
+func (p *Processor) saveDynamicThresholds(force bool) {
+	if p.Ds == nil {
+		return
+	}
+
+	p.thresholdsMutex.Lock()
+	if len(p.thresholdsChanged) == 0 || (!force && time.Since(p.thresholdsSaved) < dynamicThresholdSaveInterval) {
+		p.thresholdsMutex.Unlock()
+		return
+	}
+	var changed []datastore.DynamicThreshold
+	var removed []string
+	for species := range p.thresholdsChanged {
+		if dt, exists := p.DynamicThresholds[species]; exists {
+			changed = append(changed, dt.record(species))
+		} else {
+			removed = append(removed, species)
+		}
+	}
+	p.thresholdsChanged = nil
+	p.thresholdsSaved = time.Now()
+	p.thresholdsMutex.Unlock()
+
+	if err := p.Ds.SaveDynamicThresholds(changed); err != nil {
+		log.Printf("Failed to save dynamic thresholds: %v", err)
+	}
+	for _, species := range removed {
+		if err := p.Ds.DeleteDynamicThreshold(species); err != nil {
+			log.Printf("Failed to delete dynamic threshold: %v", err)
 		}
 	}
 }
+
+// record returns the datastore representation of the dynamic threshold of a species.
+func (dt *DynamicThreshold) record(species string) datastore.DynamicThreshold {
+	return datastore.DynamicThreshold{
+		Species:       species,
+		Level:         dt.Level,
+		CurrentValue:  dt.CurrentValue,
+		HighConfCount: dt.HighConfCount,
+		ExpiresAt:     dt.Timer,
+	}
+}
+
+// DynamicThresholdStates returns the current dynamic threshold of each tracked species, sorted
+// by species.
+func (p *Processor) DynamicThresholdStates() []datastore.DynamicThreshold {
+	p.thresholdsMutex.RLock()
+	defer p.thresholdsMutex.RUnlock()
+
+	states := make([]datastore.DynamicThreshold, 0, len(p.DynamicThresholds))
+	for species, dt := range p.DynamicThresholds {
+		states = append(states, dt.record(species))
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Species < states[j].Species })
+	return states
+}
+
+// ResetDynamicThreshold reverts a species to its base threshold by discarding its dynamic
+// threshold, or all species if species is empty. It reports whether a threshold was reset.
+func (p *Processor) ResetDynamicThreshold(species string) bool {
+	species = strings.ToLower(species)
+
+	p.thresholdsMutex.Lock()
+	reset := false
+	for s := range p.DynamicThresholds {
+		if species == "" || s == species {
+			delete(p.DynamicThresholds, s)
+			p.markThresholdChanged(s)
+			reset = true
+		}
+	}
+	p.thresholdsMutex.Unlock()
+
+	if reset {
+		p.saveDynamicThresholds(true)
+	}
+	return reset
+}
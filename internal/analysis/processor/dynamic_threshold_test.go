@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestDynamicThresholdSchedule(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.DynamicThreshold = conf.DynamicThresholdSettings{
+		Enabled:    true,
+		Trigger:    0.9,
+		Min:        0.2,
+		ValidHours: 24,
+		Levels: []conf.DynamicThresholdLevel{
+			{Count: 2, Multiplier: 0.8},
+			{Count: 4, Multiplier: 0.2},
+		},
+	}
+	p := &Processor{Settings: settings, DynamicThresholds: make(map[string]*DynamicThreshold)}
+	p.addSpeciesToDynamicThresholds("great tit", 0.8)
+
+	// Levels are reached at the configured counts, the minimum threshold limits the last one
+	high := datastore.Results{Species: "Parus major_Great Tit", Confidence: 0.95}
+	want := []float64{0.8, 0.64, 0.64, 0.2}
+	for i, w := range want {
+		got := p.getAdjustedConfidenceThreshold("great tit", high, 0.8)
+		if math.Abs(float64(got)-w) > 1e-6 {
+			t.Errorf("detection %d: threshold %.2f, want %.2f", i+1, got, w)
+		}
+	}
+
+	states := p.DynamicThresholdStates()
+	if len(states) != 1 || states[0].Species != "great tit" || states[0].Level != 2 || states[0].HighConfCount != 4 {
+		t.Errorf("unexpected dynamic threshold state: %+v", states)
+	}
+	if !p.thresholdsChanged["great tit"] {
+		t.Error("expected changed threshold to be marked for saving")
+	}
+
+	if p.ResetDynamicThreshold("Blackbird") {
+		t.Error("expected reset of an untracked species to report false")
+	}
+	if !p.ResetDynamicThreshold("Great Tit") || len(p.DynamicThresholdStates()) != 0 {
+		t.Error("expected dynamic threshold to be reset")
+	}
+}
@@ -15,6 +15,7 @@ const (
 	SendNotification                   // Represents a send notification event
 	BirdWeatherSubmit                  // Represents a bird weather submit event
 	MQTTPublish                        // Represents an MQTT publish event
+	HubPush                            // Represents a push of a detection to the hub
 )
 
 // EventBehaviorFunc defines the signature for functions that determine the behavior of an event.
@@ -67,8 +68,15 @@ func StandardEventBehavior(lastEventTime time.Time, timeout time.Duration) bool
 
 // EventTracker manages event handling for different species across multiple event types.
 type EventTracker struct {
-	Handlers map[EventType]*EventHandler // Map of event types to their respective handlers
-	Mutex    sync.Mutex                  // Mutex to ensure thread-safe access
+	Handlers       map[EventType]*EventHandler // Map of event types to their respective handlers
+	TargetHandlers map[TargetKey]*EventHandler // Handlers of event types delivered to several targets
+	Mutex          sync.Mutex                  // Mutex to ensure thread-safe access
+}
+
+// TargetKey identifies the handler of an event type for a single target, such as a notification service.
+type TargetKey struct {
+	EventType EventType
+	Target    string
 }
 
 // Add this new struct to hold configuration
@@ -89,7 +97,9 @@ func NewEventTracker(interval time.Duration) *EventTracker {
 			SendNotification:  NewEventHandler(interval, StandardEventBehavior),
 			BirdWeatherSubmit: NewEventHandler(interval, StandardEventBehavior),
 			MQTTPublish:       NewEventHandler(interval, StandardEventBehavior),
+			HubPush:           NewEventHandler(interval, StandardEventBehavior),
 		},
+		TargetHandlers: make(map[TargetKey]*EventHandler),
 	}
 }
 
@@ -115,3 +125,26 @@ func (et *EventTracker) ResetEvent(species string, eventType EventType) {
 		handler.ResetEvent(species)
 	}
 }
+
+// TrackTargetEvent checks if an event for a given species, event type and target should be processed.
+// Each target is rate limited independently with its own timeout, a zero timeout uses the timeout
+// of the event type.
+func (et *EventTracker) TrackTargetEvent(species string, eventType EventType, target string, timeout time.Duration) bool {
+	et.Mutex.Lock()
+	defer et.Mutex.Unlock()
+
+	key := TargetKey{EventType: eventType, Target: target}
+	handler, exists := et.TargetHandlers[key]
+	if !exists {
+		base, ok := et.Handlers[eventType]
+		if !ok {
+			return false
+		}
+		if timeout == 0 {
+			timeout = base.Timeout
+		}
+		handler = NewEventHandler(timeout, base.BehaviorFunc)
+		et.TargetHandlers[key] = handler
+	}
+	return handler.ShouldHandleEvent(species)
+}
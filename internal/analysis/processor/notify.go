@@ -0,0 +1,171 @@
+// notify.go
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// Notification conditions usable in SendNotification species actions.
+const (
+	ConditionAlways      = "always"      // every detection
+	ConditionFirstEver   = "firstever"   // species has never been detected before
+	ConditionFirstOfYear = "firstofyear" // species has not been detected earlier this calendar year
+)
+
+// NotificationAction sends a detection to a single notification target when one of its conditions is met.
+type NotificationAction struct {
+	Settings     *conf.Settings
+	Ds           datastore.Interface
+	Note         datastore.Note
+	Target       *notification.Target
+	Conditions   []string
+	EventTracker *EventTracker
+	retry        bool       // true when the action is retried from the event queue
+	mu           sync.Mutex // Protect concurrent access to Note
+}
+
+// Execute checks the notification conditions and rate limit and sends the notification
+func (a *NotificationAction) Execute(data interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	species := strings.ToLower(a.Note.CommonName)
+
+	condition, err := a.matchCondition()
+	if err != nil {
+		return fmt.Errorf("error evaluating notification conditions for %s: %w", species, err)
+	}
+	if condition == "" {
+		return nil
+	}
+
+	// Check event frequency for this target
+	if !a.retry && !a.EventTracker.TrackTargetEvent(species, SendNotification, a.Target.Name(), a.Target.Interval) {
+		return nil
+	}
+
+	msg, err := a.Target.Render(&a.Note, condition)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := a.Target.Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send notification to %s: %w", a.Target.Name(), err)
+	}
+
+	if a.Settings.Realtime.Notifications.Debug {
+		log.Printf("Sent %s notification of %s to %s\n", condition, a.Note.CommonName, a.Target.Name())
+	}
+	return nil
+}
+
+// QueueKind returns the event queue kind of the action
+func (a *NotificationAction) QueueKind() string { return queueKindNotification }
+
+// QueuePayload serializes the action for the event queue
+func (a *NotificationAction) QueuePayload() ([]byte, error) {
+	return json.Marshal(queuedAction{Note: a.Note, Target: a.Target.Name(), Conditions: a.Conditions})
+}
+
+// matchCondition returns the first condition met by the note, or an empty string if none is met.
+func (a *NotificationAction) matchCondition() (string, error) {
+	if len(a.Conditions) == 0 {
+		return ConditionAlways, nil
+	}
+
+	for _, condition := range a.Conditions {
+		condition = strings.ToLower(strings.TrimSpace(condition))
+
+		var start time.Time
+		switch condition {
+		case ConditionAlways:
+			return condition, nil
+		case datastore.EventFirstOfSeason, datastore.EventReturning:
+			// classified by the species tracker
+			if a.Note.DetectionEvent == condition {
+				return condition, nil
+			}
+			continue
+		case ConditionFirstEver:
+			// zero start counts all earlier detections
+		case ConditionFirstOfYear:
+			local := a.Note.Timestamp.Local()
+			start = time.Date(local.Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+		default:
+			log.Printf("Unknown notification condition %q for %s\n", condition, a.Note.CommonName)
+			continue
+		}
+
+		if a.Ds == nil {
+			return "", fmt.Errorf("condition %s requires a database", condition)
+		}
+		count, err := a.Ds.CountSpeciesDetectionsBetween(a.Note.ScientificName, start, a.Note.Timestamp)
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return condition, nil
+		}
+	}
+	return "", nil
+}
+
+// getNotificationActions returns a notification action for every target referenced by the
+// SendNotification actions of the species and of the all species configuration.
+func (p *Processor) getNotificationActions(detection *Detections, speciesName string) []Action {
+	if !p.Settings.Realtime.Notifications.Enabled || len(p.NotificationTargets) == 0 {
+		return nil
+	}
+
+	var actions []Action
+	for _, key := range []string{speciesName, conf.AllSpeciesConfigKey} {
+		speciesConfig, exists := p.Settings.GetSpeciesConfig(key)
+		if !exists {
+			continue
+		}
+
+		for _, actionConfig := range speciesConfig.Actions {
+			if actionConfig.Type != "SendNotification" {
+				continue
+			}
+
+			targetNames := actionConfig.Targets
+			if len(targetNames) == 0 {
+				for name := range p.NotificationTargets {
+					targetNames = append(targetNames, name)
+				}
+			}
+
+			for _, name := range targetNames {
+				target, ok := p.NotificationTargets[name]
+				if !ok {
+					log.Printf("Unknown notification target %q in actions of %s\n", name, key)
+					continue
+				}
+				actions = append(actions, &NotificationAction{
+					Settings:     p.Settings,
+					Ds:           p.Ds,
+					Note:         detection.Note,
+					Target:       target,
+					Conditions:   actionConfig.Conditions,
+					EventTracker: p.EventTracker,
+				})
+			}
+		}
+	}
+
+	return actions
+}
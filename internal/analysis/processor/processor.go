@@ -16,10 +16,13 @@ import (
 	"github.com/tphakala/birdnet-go/internal/birdweather"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/hub"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
 	"github.com/tphakala/birdnet-go/internal/mqtt"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/notification"
 	"github.com/tphakala/birdnet-go/internal/observation"
+	"github.com/tphakala/birdnet-go/internal/suncalc"
 	"github.com/tphakala/birdnet-go/internal/telemetry"
 )
 
@@ -29,20 +32,32 @@ type Processor struct {
 	Ds                  datastore.Interface
 	Bn                  *birdnet.BirdNET
 	BwClient            *birdweather.BwClient
+	HubClient           *hub.Client // pushes detections to the hub, nil if disabled
 	MqttClient          mqtt.Client
+	HomeAssistant       *mqtt.HomeAssistant // Home Assistant discovery and sensors, nil if disabled
+	NotificationTargets map[string]*notification.Target
 	BirdImageCache      *imageprovider.BirdImageCache
 	EventTracker        *EventTracker
-	LastDogDetection    map[string]time.Time // keep track of dog barks per audio source
-	LastHumanDetection  map[string]time.Time // keep track of human vocal per audio source
+	SpeciesTracker      *SpeciesTracker                // classifies new and returning species, nil if disabled
+	NotificationChan    chan notification.Notification // web UI notifications, may be nil
+	DetectionChan       chan datastore.Note            // saved detections for the live detection stream, may be nil
 	Metrics             *telemetry.Metrics
+	EventQueue          *queue.EventQueue // persistent queue of pending actions, nil if disabled
+	SunCalc             *suncalc.SunCalc  // sun event times of the station for the schedule and time rules
+	reportedDrops       uint64            // dropped results already reported to telemetry
 	DynamicThresholds   map[string]*DynamicThreshold
-	thresholdsMutex     sync.RWMutex // Mutex to protect access to DynamicThresholds
-	pendingDetections   map[string]PendingDetection
-	pendingMutex        sync.Mutex // Mutex to protect access to pendingDetections
-	lastDogDetectionLog map[string]time.Time
-	dogDetectionMutex   sync.Mutex
-	detectionMutex      sync.RWMutex // Mutex to protect LastDogDetection and LastHumanDetection maps
-	controlChan         chan string
+	thresholdsMutex     sync.RWMutex    // Mutex to protect access to DynamicThresholds
+	thresholdsChanged   map[string]bool // species whose dynamic threshold has unsaved changes
+	thresholdsSaved     time.Time       // last time dynamic thresholds were saved
+	pendingDetections   map[pendingKey]PendingDetection
+	pendingMutex        sync.Mutex                       // Mutex to protect access to pendingDetections
+	suppressors         []*suppressor                    // enabled suppressor rules, including the privacy and dog bark filters
+	detectionMutex      sync.RWMutex                     // Mutex to protect the suppressors
+	controlChan         chan string                      // control signals for the realtime control monitor, may be nil
+	rejectedChan        chan datastore.RejectedDetection // rejected detections waiting to be saved, nil if the log is disabled
+	seenCommands        map[string]time.Time             // IDs of accepted remote commands and when they were received
+	commandsMutex       sync.Mutex                       // Mutex to protect seenCommands
+	startTime           time.Time
 }
 
 // DynamicThreshold represents the dynamic threshold configuration for a species.
@@ -58,6 +73,7 @@ type Detections struct {
 	pcmData3s []byte              // 3s PCM data containing the detection
 	Note      datastore.Note      // Note containing highest match
 	Results   []datastore.Results // Full BirdNET prediction results
+	result    datastore.Results   // Prediction result of the detected species
 }
 
 // PendingDetection struct represents a single detection held in memory,
@@ -66,10 +82,21 @@ type PendingDetection struct {
 	Detection     Detections // The detection data
 	Confidence    float64    // Confidence level of the detection
 	Source        string     // Audio source of the detection, RTSP URL or audio card name
-	FirstDetected time.Time  // Time the detection was first detected
-	LastUpdated   time.Time  // Last time this detection was updated
+	FirstDetected time.Time  // Start of the first audio chunk the species was detected in
+	LastUpdated   time.Time  // End of the latest audio chunk the species was detected in
 	FlushDeadline time.Time  // Deadline by which the detection must be processed
 	Count         int        // Number of times this detection has been updated
+
+	// Results of the species in each prediction window it was detected in
+	Windows []datastore.Results
+}
+
+// pendingKey identifies a pending detection. Each audio source, and each channel of a split
+// multichannel source, is analyzed independently, so detections of the same species on
+// different sources are held separately.
+type pendingKey struct {
+	Source  string // analysis source name
+	Species string // lower case common name
 }
 
 // mutex is used to synchronize access to the PendingDetections map,
@@ -77,24 +104,56 @@ type PendingDetection struct {
 var mutex sync.Mutex
 
 // func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, audioBuffers map[string]*myaudio.AudioBuffer, metrics *telemetry.Metrics) *Processor {
-func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, metrics *telemetry.Metrics, birdImageCache *imageprovider.BirdImageCache) *Processor {
+func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, metrics *telemetry.Metrics, birdImageCache *imageprovider.BirdImageCache, notificationChan chan notification.Notification, detectionChan chan datastore.Note, controlChan chan string) *Processor {
 	p := &Processor{
-		Settings:            settings,
-		Ds:                  ds,
-		Bn:                  bn,
-		BirdImageCache:      birdImageCache,
-		EventTracker:        NewEventTracker(time.Duration(settings.Realtime.Interval) * time.Second),
-		Metrics:             metrics,
-		LastDogDetection:    make(map[string]time.Time),
-		LastHumanDetection:  make(map[string]time.Time),
-		DynamicThresholds:   make(map[string]*DynamicThreshold),
-		pendingDetections:   make(map[string]PendingDetection),
-		lastDogDetectionLog: make(map[string]time.Time),
+		Settings:          settings,
+		Ds:                ds,
+		Bn:                bn,
+		BirdImageCache:    birdImageCache,
+		EventTracker:      NewEventTracker(time.Duration(settings.Realtime.Interval) * time.Second),
+		Metrics:           metrics,
+		DynamicThresholds: make(map[string]*DynamicThreshold),
+		pendingDetections: make(map[pendingKey]PendingDetection),
+		suppressors:       newSuppressors(settings),
+		SunCalc:           suncalc.NewSunCalc(settings.BirdNET.Latitude, settings.BirdNET.Longitude),
+		NotificationChan:  notificationChan,
+		DetectionChan:     detectionChan,
+		controlChan:       controlChan,
+		startTime:         time.Now(),
+	}
+
+	// Track new and returning species if a database is available
+	if settings.Realtime.SpeciesTracking.Enabled && ds != nil {
+		p.SpeciesTracker = NewSpeciesTracker(ds, settings.Realtime.SpeciesTracking.ReturningDays)
+	}
+
+	// Restore dynamic thresholds from before the last restart
+	if settings.Realtime.DynamicThreshold.Enabled && ds != nil {
+		p.loadDynamicThresholds()
+	}
+
+	// Keep a log of rejected detections for auditing the filters
+	if settings.Realtime.Rejected.Enabled && ds != nil {
+		p.startRejectedDetectionLog()
 	}
 
 	// Start the detection processor
 	p.startDetectionProcessor()
 
+	// Open the persistent event queue before the workers start delivering from it
+	if settings.Realtime.EventQueue.Enabled {
+		eq := settings.Realtime.EventQueue
+		var err error
+		p.EventQueue, err = queue.OpenEventQueue(eq.Path, queue.RetryPolicy{
+			MaxAttempts:   eq.MaxAttempts,
+			RetryDelay:    time.Duration(eq.RetryDelay) * time.Second,
+			MaxRetryDelay: time.Duration(eq.MaxRetryDelay) * time.Second,
+		})
+		if err != nil {
+			log.Printf("failed to open event queue, pending actions will not survive restarts: %s", err)
+		}
+	}
+
 	// Start the worker pool for action processing
 	p.startWorkerPool(10)
 
@@ -107,6 +166,21 @@ func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, m
 		p.BwClient, err = birdweather.New(settings)
 		if err != nil {
 			log.Printf("failed to create Birdweather client: %s", err)
+		} else {
+			// Submit detections buffered while Birdweather was unreachable
+			p.BwClient.StartBufferReplay()
+		}
+	}
+
+	// Initialize hub client if this node pushes its detections to a hub
+	if settings.Realtime.Hub.Client.Enabled {
+		var err error
+		p.HubClient, err = hub.NewClient(settings)
+		if err != nil {
+			log.Printf("failed to create hub client: %s", err)
+		} else {
+			// Push detections spooled while the hub was unreachable
+			p.HubClient.StartSpoolReplay()
 		}
 	}
 
@@ -119,6 +193,17 @@ func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, m
 			// Log an error if client creation fails
 			log.Printf("failed to create MQTT client: %s", err)
 		} else {
+			// Publish Home Assistant discovery configs and sensor states once connected
+			if settings.Realtime.MQTT.HomeAssistant.Enabled {
+				p.HomeAssistant = mqtt.NewHomeAssistant(p.MqttClient, settings)
+				p.seedHomeAssistant()
+			}
+
+			// Accept remote control commands, the subscription is made once connected
+			if settings.Realtime.MQTT.Commands.Enabled {
+				p.startCommandListener()
+			}
+
 			// Create a context with a 30-second timeout for the connection attempt
 			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel() // Ensure the cancel function is called to release resources
@@ -132,6 +217,15 @@ func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, m
 		}
 	}
 
+	// Initialize notification targets if enabled in settings
+	if settings.Realtime.Notifications.Enabled {
+		var errs []error
+		p.NotificationTargets, errs = notification.NewTargets(&settings.Realtime.Notifications)
+		for _, err := range errs {
+			log.Printf("failed to create notification target: %s", err)
+		}
+	}
+
 	return p
 }
 
@@ -151,44 +245,51 @@ func (p *Processor) startDetectionProcessor() {
 func (p *Processor) processDetections(item *queue.Results) {
 	// Delay before a detection is considered final and is flushed.
 	// TODO: make this configurable
-	const delay = 15 * time.Second
+	const delay = conf.DetectionHoldTime * time.Second
 
 	// processResults() returns a slice of detections, we iterate through each and process them
 	// detections are put into pendingDetections map where they are held until flush deadline is reached
 	// once deadline is reached detections are delivered to workers for actions (save to db etc) processing
 	detectionResults := p.processResults(item)
+	chunkEnd := item.StartTime.Add(conf.CaptureLength * time.Second)
 	for i := 0; i < len(detectionResults); i++ {
 		detection := detectionResults[i]
 		commonName := strings.ToLower(detection.Note.CommonName)
 		confidence := detection.Note.Confidence
+		key := pendingKey{Source: item.Source, Species: commonName}
 
 		// Lock the mutex to ensure thread-safe access to shared resources
 		p.pendingMutex.Lock()
 
-		if existing, exists := p.pendingDetections[commonName]; exists {
+		if existing, exists := p.pendingDetections[key]; exists {
 			// Update the existing detection if it's already in pendingDetections map
 			if confidence > existing.Confidence {
 				existing.Detection = detection
 				existing.Confidence = confidence
-				existing.Source = item.Source
-				existing.LastUpdated = time.Now()
+			}
+			// Extend the span of the detection so that its clip covers the whole vocalisation
+			if chunkEnd.After(existing.LastUpdated) {
+				existing.LastUpdated = chunkEnd
 			}
 			existing.Count++
-			p.pendingDetections[commonName] = existing
+			existing.Windows = append(existing.Windows, detection.result)
+			p.pendingDetections[key] = existing
 		} else {
 			// Create a new pending detection if it doesn't exist
-			p.pendingDetections[commonName] = PendingDetection{
+			p.pendingDetections[key] = PendingDetection{
 				Detection:     detection,
 				Confidence:    confidence,
 				Source:        item.Source,
 				FirstDetected: item.StartTime,
+				LastUpdated:   chunkEnd,
 				FlushDeadline: item.StartTime.Add(delay),
 				Count:         1,
+				Windows:       []datastore.Results{detection.result},
 			}
 		}
 
 		// Update the dynamic threshold for this species if enabled
-		p.updateDynamicThreshold(commonName, confidence)
+		p.updateDynamicThreshold(commonName, item.Source, confidence)
 
 		// Unlock the mutex to allow other goroutines to access shared resources
 		p.pendingMutex.Unlock()
@@ -204,21 +305,32 @@ func (p *Processor) processResults(item *queue.Results) []Detections {
 		p.Metrics.BirdNET.SetProcessTime(float64(item.ElapsedTime.Milliseconds()))
 	}
 
+	// Discard all results outside the daily detection schedule
+	if !p.inSchedule(item.StartTime) {
+		return nil
+	}
+
+	// Results are stored with the start of their prediction window
+	results := make([]datastore.Results, len(item.Results))
+	for i, result := range item.Results {
+		results[i] = result
+		results[i].StartTime = item.StartTime
+	}
+
 	// Process each result in item.Results
-	for _, result := range item.Results {
+	for _, result := range results {
 		var confidenceThreshold float32
 		scientificName, commonName, _ := observation.ParseSpeciesString(result.Species)
 
 		// Convert species to lowercase for case-insensitive comparison
 		speciesLowercase := strings.ToLower(commonName)
 
-		// Handle dog and human detection, this sets LastDogDetection and LastHumanDetection which is
-		// later used to discard detection if privacy filter or dog bark filters are enabled in settings.
-		p.handleDogDetection(item, speciesLowercase, result)
-		p.handleHumanDetection(item, speciesLowercase, result)
+		// Record suppressors such as human voices and dog barks, they are later used to discard
+		// detections heard around them
+		p.handleSuppressors(item, result)
 
 		// Determine base confidence threshold
-		baseThreshold := p.getBaseConfidenceThreshold(speciesLowercase)
+		baseThreshold := p.getBaseConfidenceThreshold(speciesLowercase, item.Source)
 
 		// If result is human and detection exceeds base threshold, discard it
 		// due to privacy reasons we do not want human detections to reach actions stage
@@ -227,16 +339,28 @@ func (p *Processor) processResults(item *queue.Results) []Detections {
 			continue
 		}
 
+		// Apply time of day rules, they may change the threshold or exclude the species
+		timedThreshold, excluded := p.applyTimeRules(scientificName, commonName, item.StartTime, baseThreshold)
+		if excluded {
+			if result.Confidence > timedThreshold {
+				p.rejectResult(item, result, datastore.RejectReasonTimeOfDay, timedThreshold)
+			}
+			continue
+		}
+
 		if p.Settings.Realtime.DynamicThreshold.Enabled {
-			// Apply dynamic threshold adjustments
+			// Apply dynamic threshold adjustments, they follow the base threshold so that the
+			// state of a species does not depend on the time of day it was first detected at
 			confidenceThreshold = p.getAdjustedConfidenceThreshold(speciesLowercase, result, baseThreshold)
 		} else {
 			// Use the base threshold if dynamic thresholds are disabled
 			confidenceThreshold = baseThreshold
 		}
+		confidenceThreshold = timeRuleThreshold(confidenceThreshold, baseThreshold, timedThreshold)
 
 		// Skip processing if confidence is too low
 		if result.Confidence <= confidenceThreshold {
+			p.rejectResult(item, result, datastore.RejectReasonThreshold, confidenceThreshold)
 			continue
 		}
 
@@ -245,6 +369,7 @@ func (p *Processor) processResults(item *queue.Results) []Detections {
 			if p.Settings.Debug {
 				log.Printf("Species not on included list: %s\n", result.Species)
 			}
+			p.rejectResult(item, result, datastore.RejectReasonRangeFilter, confidenceThreshold)
 			continue
 		}
 
@@ -256,9 +381,9 @@ func (p *Processor) processResults(item *queue.Results) []Detections {
 		// Create file name for audio clip
 		clipName := p.generateClipName(scientificName, result.Confidence)
 
-		// set begin and end time for note
-		// TODO: adjust end time based on detection pending delay
-		beginTime, endTime := item.StartTime, item.StartTime.Add(15*time.Second)
+		// set begin and end time for note to the analyzed chunk, they are extended to the full
+		// span of the detection when it is approved
+		beginTime, endTime := item.StartTime, item.StartTime.Add(conf.CaptureLength*time.Second)
 
 		note := observation.New(p.Settings, beginTime, endTime, result.Species, float64(result.Confidence), item.Source, clipName, item.ElapsedTime)
 
@@ -266,50 +391,32 @@ func (p *Processor) processResults(item *queue.Results) []Detections {
 		detections = append(detections, Detections{
 			pcmData3s: item.PCMdata,
 			Note:      note,
-			Results:   item.Results,
+			Results:   results,
+			result:    result,
 		})
 	}
 
 	return detections
 }
 
-// handleDogDetection handles the detection of dog barks and updates the last detection timestamp.
-func (p *Processor) handleDogDetection(item *queue.Results, speciesLowercase string, result datastore.Results) {
-	if p.Settings.Realtime.DogBarkFilter.Enabled && strings.Contains(speciesLowercase, "dog") &&
-		result.Confidence > p.Settings.Realtime.DogBarkFilter.Confidence {
-		log.Printf("Dog detected with confidence %.3f/%.3f from source %s", result.Confidence, p.Settings.Realtime.DogBarkFilter.Confidence, item.Source)
-		p.detectionMutex.Lock()
-		p.LastDogDetection[item.Source] = item.StartTime
-		p.detectionMutex.Unlock()
-	}
-}
-
-// handleHumanDetection handles the detection of human vocalizations and updates the last detection timestamp.
-func (p *Processor) handleHumanDetection(item *queue.Results, speciesLowercase string, result datastore.Results) {
-	// only check this if privacy filter is enabled
-	if p.Settings.Realtime.PrivacyFilter.Enabled && strings.Contains(speciesLowercase, "human ") &&
-		result.Confidence > p.Settings.Realtime.PrivacyFilter.Confidence {
-		log.Printf("Human detected with confidence %.3f/%.3f from source %s", result.Confidence, p.Settings.Realtime.PrivacyFilter.Confidence, item.Source)
-		// put human detection timestamp into LastHumanDetection map. This is used to discard
-		// bird detections if a human vocalization is detected after the first detection
-		p.detectionMutex.Lock()
-		p.LastHumanDetection[item.Source] = item.StartTime
-		p.detectionMutex.Unlock()
-	}
-}
-
-// getBaseConfidenceThreshold retrieves the confidence threshold for a species, using custom or global thresholds.
-func (p *Processor) getBaseConfidenceThreshold(speciesLowercase string) float32 {
-	// Check if species has a custom threshold in the new structure
-	if config, exists := p.Settings.Realtime.Species.Config[speciesLowercase]; exists {
+// getBaseConfidenceThreshold retrieves the confidence threshold for a species, using custom, audio source or global thresholds.
+func (p *Processor) getBaseConfidenceThreshold(speciesLowercase, source string) float32 {
+	// Check if species has a custom threshold in the new structure, species may be configured
+	// for other settings only
+	if config, exists := p.Settings.GetSpeciesConfig(speciesLowercase); exists && config.Threshold > 0 {
 		if p.Settings.Debug {
 			log.Printf("\nUsing custom confidence threshold of %.2f for %s\n", config.Threshold, speciesLowercase)
 		}
 		return float32(config.Threshold)
 	}
 
+	// Use the threshold of the audio source if it overrides the global threshold
+	if sourceSettings, exists := p.Settings.FindAudioSource(source); exists && sourceSettings.Threshold > 0 {
+		return float32(sourceSettings.Threshold)
+	}
+
 	// Fall back to global threshold
-	return float32(p.Settings.BirdNET.Threshold)
+	return float32(p.Settings.GetThreshold())
 }
 
 // generateClipName generates a clip name for the given scientific name and confidence.
@@ -341,40 +448,46 @@ func (p *Processor) generateClipName(scientificName string, confidence float32)
 	return clipName
 }
 
-// shouldDiscardDetection checks if a detection should be discarded based on various criteria
+// shouldDiscardDetection checks if a detection should be discarded based on various criteria,
+// the reason is datastore.RejectReasonMatches or the name of a suppressor rule
 func (p *Processor) shouldDiscardDetection(item *PendingDetection, minDetections int) (shouldDiscard bool, reason string) {
 	// Check minimum detection count
 	if item.Count < minDetections {
-		return true, fmt.Sprintf("false positive, matched %d/%d times", item.Count, minDetections)
+		return true, datastore.RejectReasonMatches
 	}
 
-	// Check privacy filter
-	if p.Settings.Realtime.PrivacyFilter.Enabled {
-		p.detectionMutex.RLock()
-		lastHumanDetection, exists := p.LastHumanDetection[item.Source]
-		p.detectionMutex.RUnlock()
-		if exists && lastHumanDetection.After(item.FirstDetected) {
-			return true, "privacy filter"
-		}
+	// Check suppressors, the privacy and dog bark filters among them
+	if rule := p.checkSuppressors(item); rule != "" {
+		return true, rule
 	}
 
-	// Check dog bark filter
-	if p.Settings.Realtime.DogBarkFilter.Enabled {
-		if p.Settings.Realtime.DogBarkFilter.Debug {
-			p.detectionMutex.RLock()
-			log.Printf("Last dog detection: %s\n", p.LastDogDetection)
-			p.detectionMutex.RUnlock()
-		}
-		p.detectionMutex.RLock()
-		lastDogDetection := p.LastDogDetection[item.Source]
-		p.detectionMutex.RUnlock()
-		if p.CheckDogBarkFilter(item.Detection.Note.CommonName, lastDogDetection) ||
-			p.CheckDogBarkFilter(item.Detection.Note.ScientificName, lastDogDetection) {
-			return true, "recent dog bark"
-		}
+	return false, ""
+}
+
+// clipRange returns the begin and end time of the audio clip of a detection heard from first
+// to last. The clip is extended by the configured pre-roll and post-roll and cut to the maximum
+// clip length, keeping the start of the vocalisation.
+func clipRange(settings *conf.Settings, first, last time.Time) (begin, end time.Time) {
+	export := &settings.Realtime.Audio.Export
+	begin = first.Add(-time.Duration(export.PreRoll) * time.Second)
+	end = last.Add(time.Duration(export.PostRoll) * time.Second)
+	if maxLength := time.Duration(export.MaxLength) * time.Second; maxLength > 0 && end.Sub(begin) > maxLength {
+		end = begin.Add(maxLength)
 	}
+	return begin, end
+}
 
-	return false, ""
+// withWindowResults adds the results of the species in the other prediction windows it was
+// detected in to the full results of the window with the highest confidence, so that all
+// windows of a detection are saved.
+func withWindowResults(results, windows []datastore.Results) []datastore.Results {
+	combined := append([]datastore.Results(nil), results...)
+	for _, window := range windows {
+		if len(results) == 0 || !window.StartTime.Equal(results[0].StartTime) {
+			combined = append(combined, window)
+		}
+	}
+	return combined
 }
 
 // processApprovedDetection handles an approved detection by sending it to the worker queue
@@ -382,10 +495,15 @@ func (p *Processor) processApprovedDetection(item *PendingDetection, species str
 	log.Printf("Approving detection of %s from source %s, matched %d times\n",
 		species, item.Source, item.Count)
 
-	item.Detection.Note.BeginTime = item.FirstDetected
+	item.Detection.Note.BeginTime, item.Detection.Note.EndTime = clipRange(p.Settings, item.FirstDetected, item.LastUpdated)
+	item.Detection.Results = withWindowResults(item.Detection.Results, item.Windows)
+	p.classifyDetection(&item.Detection.Note)
+	if p.HomeAssistant != nil {
+		p.HomeAssistant.RecordDetection(&item.Detection.Note)
+	}
 	actionList := p.getActionsForItem(&item.Detection)
 	for _, action := range actionList {
-		workerQueue <- Task{Type: TaskTypeAction, Detection: item.Detection, Action: action}
+		p.enqueueTask(Task{Type: TaskTypeAction, Detection: item.Detection, Action: action})
 	}
 
 	// Update BirdNET metrics detection counter if enabled
@@ -394,6 +512,59 @@ func (p *Processor) processApprovedDetection(item *PendingDetection, species str
 	}
 }
 
+// seedHomeAssistant initializes the Home Assistant daily counters from today's detections.
+func (p *Processor) seedHomeAssistant() {
+	if p.Ds == nil {
+		return
+	}
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	notes, err := p.Ds.GetNotesBetween(midnight, now)
+	if err != nil {
+		log.Printf("Failed to read today's detections for Home Assistant: %v\n", err)
+		return
+	}
+	p.HomeAssistant.Seed(notes)
+}
+
+// classifyDetection sets the detection event of a note and announces new and returning species in the web UI.
+func (p *Processor) classifyDetection(note *datastore.Note) {
+	if p.SpeciesTracker == nil {
+		return
+	}
+
+	event, previous, err := p.SpeciesTracker.Classify(note)
+	if err != nil {
+		log.Printf("Failed to classify detection of %s: %v\n", note.CommonName, err)
+		return
+	}
+	note.DetectionEvent = event
+
+	var message string
+	switch event {
+	case datastore.EventLifer:
+		message = fmt.Sprintf("New species: %s (%s)", note.CommonName, note.ScientificName)
+	case datastore.EventFirstOfYear:
+		message = fmt.Sprintf("First %s of the year", note.CommonName)
+	case datastore.EventFirstOfSeason:
+		message = fmt.Sprintf("First %s of the season", note.CommonName)
+	case datastore.EventReturning:
+		days := int(note.Timestamp.Sub(previous).Hours() / 24)
+		message = fmt.Sprintf("%s returned after %d days", note.CommonName, days)
+	default:
+		return
+	}
+	log.Println(message)
+
+	if p.NotificationChan != nil {
+		// Never block detection processing on the web UI
+		select {
+		case p.NotificationChan <- notification.Notification{Message: message, Type: "info"}:
+		default:
+		}
+	}
+}
+
 // pendingDetectionsFlusher runs a goroutine that periodically checks the pending detections
 // and flushes them to the worker queue if their deadline has passed.
 func (p *Processor) pendingDetectionsFlusher() {
@@ -410,23 +581,27 @@ func (p *Processor) pendingDetectionsFlusher() {
 			now := time.Now()
 
 			p.pendingMutex.Lock()
-			for species := range p.pendingDetections {
-				item := p.pendingDetections[species]
+			for key := range p.pendingDetections {
+				item := p.pendingDetections[key]
 				if now.After(item.FlushDeadline) {
 					if shouldDiscard, reason := p.shouldDiscardDetection(&item, minDetections); shouldDiscard {
-						log.Printf("Discarding detection of %s from source %s due to %s\n",
-							species, item.Source, reason)
-						delete(p.pendingDetections, species)
+						log.Printf("Discarding detection of %s from source %s due to %s filter, matched %d/%d times\n",
+							key.Species, item.Source, reason, item.Count, minDetections)
+						item.Detection.Note.BeginTime, item.Detection.Note.EndTime = item.FirstDetected, item.LastUpdated
+						p.recordRejection(&item.Detection.Note, reason, item.Count)
+						delete(p.pendingDetections, key)
 						continue
 					}
 
-					p.processApprovedDetection(&item, species)
-					delete(p.pendingDetections, species)
+					p.processApprovedDetection(&item, key.Species)
+					delete(p.pendingDetections, key)
 				}
 			}
 			p.pendingMutex.Unlock()
 
 			p.cleanUpDynamicThresholds()
+			p.saveDynamicThresholds(false)
+			p.updateQueueMetrics()
 		}
 	}()
 }
@@ -445,8 +620,11 @@ func contains(slice []string, item string) bool {
 func (p *Processor) getActionsForItem(detection *Detections) []Action {
 	speciesName := strings.ToLower(detection.Note.CommonName)
 
+	// Notifications are sent in addition to the other actions
+	notificationActions := p.getNotificationActions(detection, speciesName)
+
 	// Check if species has custom configuration
-	if speciesConfig, exists := p.Settings.Realtime.Species.Config[speciesName]; exists {
+	if speciesConfig, exists := p.Settings.GetSpeciesConfig(speciesName); exists {
 		if p.Settings.Debug {
 			log.Println("Species config exists for custom actions")
 		}
@@ -463,20 +641,17 @@ func (p *Processor) getActionsForItem(detection *Detections) []Action {
 						Params:  parseCommandParams(actionConfig.Parameters, detection),
 					})
 				}
-			case "SendNotification":
-				// Add notification action handling
-				// ... implementation ...
 			}
 		}
 
 		// If there are custom actions, return only those
 		if len(actions) > 0 {
-			return actions
+			return append(actions, notificationActions...)
 		}
 	}
 
 	// Fall back to default actions if no custom actions or if custom actions should be combined
-	return p.getDefaultActions(detection)
+	return append(p.getDefaultActions(detection), notificationActions...)
 }
 
 // Helper function to parse command parameters
@@ -506,11 +681,12 @@ func (p *Processor) getDefaultActions(detection *Detections) []Action {
 
 	if p.Settings.Output.SQLite.Enabled || p.Settings.Output.MySQL.Enabled {
 		actions = append(actions, &DatabaseAction{
-			Settings:     p.Settings,
-			EventTracker: p.EventTracker,
-			Note:         detection.Note,
-			Results:      detection.Results,
-			Ds:           p.Ds})
+			Settings:      p.Settings,
+			EventTracker:  p.EventTracker,
+			Note:          detection.Note,
+			Results:       detection.Results,
+			Ds:            p.Ds,
+			DetectionChan: p.DetectionChan})
 	}
 
 	// Add BirdWeatherAction if enabled and client is initialized
@@ -523,6 +699,16 @@ func (p *Processor) getDefaultActions(detection *Detections) []Action {
 			pcmData:      detection.pcmData3s})
 	}
 
+	// Add HubAction if this node pushes its detections to a hub
+	if p.Settings.Realtime.Hub.Client.Enabled && p.HubClient != nil {
+		actions = append(actions, &HubAction{
+			Settings:     p.Settings,
+			EventTracker: p.EventTracker,
+			HubClient:    p.HubClient,
+			Note:         detection.Note,
+			Results:      detection.Results})
+	}
+
 	// Add MQTT action if enabled
 	if p.Settings.Realtime.MQTT.Enabled && p.MqttClient != nil {
 		actions = append(actions, &MqttAction{
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestClipRange(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.Audio.Export.PreRoll = 2
+	settings.Realtime.Audio.Export.PostRoll = 1
+	settings.Realtime.Audio.Export.MaxLength = 20
+
+	first := time.Date(2024, 5, 12, 6, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		last      time.Time
+		wantBegin time.Time
+		wantEnd   time.Time
+	}{
+		{"single chunk", first.Add(3 * time.Second), first.Add(-2 * time.Second), first.Add(4 * time.Second)},
+		{"repeated detections", first.Add(10500 * time.Millisecond), first.Add(-2 * time.Second), first.Add(11500 * time.Millisecond)},
+		{"cut to max length", first.Add(30 * time.Second), first.Add(-2 * time.Second), first.Add(18 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			begin, end := clipRange(settings, first, tt.last)
+			if !begin.Equal(tt.wantBegin) || !end.Equal(tt.wantEnd) {
+				t.Errorf("clipRange() = %v - %v, want %v - %v", begin, end, tt.wantBegin, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestPendingDetectionsPerSource(t *testing.T) {
+	// The same species heard on two channels is held as two detections
+	const label = "Parus major_Great Tit_gretit1"
+	settings := &conf.Settings{}
+	settings.BirdNET.Threshold = 0.5
+	settings.UpdateIncludedSpecies([]string{label})
+	p := &Processor{Settings: settings, pendingDetections: make(map[pendingKey]PendingDetection)}
+
+	start := time.Now()
+	for i, source := range []string{"garden_ch1", "garden_ch2", "garden_ch1"} {
+		p.processDetections(&queue.Results{
+			StartTime: start.Add(time.Duration(i) * time.Second),
+			Results:   []datastore.Results{{Species: label, Confidence: 0.6 + float32(i)/10}},
+			Source:    source,
+		})
+	}
+
+	if len(p.pendingDetections) != 2 {
+		t.Fatalf("expected 2 pending detections, got %d", len(p.pendingDetections))
+	}
+	for source, wantCount := range map[string]int{"garden_ch1": 2, "garden_ch2": 1} {
+		item := p.pendingDetections[pendingKey{Source: source, Species: "great tit"}]
+		if len(item.Windows) != wantCount {
+			t.Errorf("pending detection of %s has %d prediction windows, want %d", source, len(item.Windows), wantCount)
+		}
+	}
+	for source, wantCount := range map[string]int{"garden_ch1": 2, "garden_ch2": 1} {
+		item, exists := p.pendingDetections[pendingKey{Source: source, Species: "great tit"}]
+		if !exists {
+			t.Errorf("no pending detection for %s", source)
+			continue
+		}
+		if item.Source != source || item.Detection.Note.Source != source || item.Count != wantCount {
+			t.Errorf("pending detection of %s has source %s, note source %s and count %d, want count %d",
+				source, item.Source, item.Detection.Note.Source, item.Count, wantCount)
+		}
+	}
+}
+
+func TestWithWindowResults(t *testing.T) {
+	start := time.Date(2024, 5, 12, 6, 15, 0, 0, time.UTC)
+	best := []datastore.Results{
+		{Species: "Parus major_Great Tit_gretit1", Confidence: 0.9, StartTime: start.Add(time.Second)},
+		{Species: "Cyanistes caeruleus_Eurasian Blue Tit_blutit", Confidence: 0.2, StartTime: start.Add(time.Second)},
+	}
+	windows := []datastore.Results{
+		{Species: "Parus major_Great Tit_gretit1", Confidence: 0.7, StartTime: start},
+		{Species: "Parus major_Great Tit_gretit1", Confidence: 0.9, StartTime: start.Add(time.Second)},
+		{Species: "Parus major_Great Tit_gretit1", Confidence: 0.8, StartTime: start.Add(2 * time.Second)},
+	}
+
+	results := withWindowResults(best, windows)
+	if len(results) != 4 || results[2].StartTime != start || results[3].StartTime != start.Add(2*time.Second) {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
@@ -0,0 +1,89 @@
+// rejected.go: log of detections discarded by filters
+package processor
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/observation"
+)
+
+// rejectedQueueSize is the number of rejected detections waiting to be saved, further
+// rejections are dropped until the queue drains.
+const rejectedQueueSize = 100
+
+// startRejectedDetectionLog starts saving rejected detections to the database and deleting
+// those older than the retention period.
+func (p *Processor) startRejectedDetectionLog() {
+	p.rejectedChan = make(chan datastore.RejectedDetection, rejectedQueueSize)
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+
+		p.pruneRejectedDetections()
+		for {
+			select {
+			case rejected := <-p.rejectedChan:
+				if err := p.Ds.SaveRejectedDetection(&rejected); err != nil {
+					log.Printf("Failed to save rejected detection of %s: %v\n", rejected.CommonName, err)
+				}
+			case <-ticker.C:
+				p.pruneRejectedDetections()
+			}
+		}
+	}()
+}
+
+// pruneRejectedDetections deletes rejected detections older than the retention period.
+func (p *Processor) pruneRejectedDetections() {
+	retention := time.Duration(p.Settings.Realtime.Rejected.Retention) * 24 * time.Hour
+	deleted, err := p.Ds.DeleteRejectedDetectionsBefore(time.Now().Add(-retention))
+	if err != nil {
+		log.Printf("Failed to delete expired rejected detections: %v\n", err)
+		return
+	}
+	if deleted > 0 && p.Settings.Debug {
+		log.Printf("Deleted %d expired rejected detections\n", deleted)
+	}
+}
+
+// rejectResult records a prediction result discarded by the confidence threshold or the
+// range filter. Results below the minimum confidence of the log are not recorded for the
+// threshold, nor are human voices for privacy reasons.
+func (p *Processor) rejectResult(item *queue.Results, result datastore.Results, reason string, threshold float32) {
+	if p.rejectedChan == nil || strings.Contains(strings.ToLower(result.Species), "human") {
+		return
+	}
+	if reason == datastore.RejectReasonThreshold && float64(result.Confidence) < p.Settings.Realtime.Rejected.MinConfidence {
+		return
+	}
+
+	beginTime, endTime := item.StartTime, item.StartTime.Add(conf.CaptureLength*time.Second)
+	note := observation.New(p.Settings, beginTime, endTime, result.Species, float64(result.Confidence), item.Source, "", item.ElapsedTime)
+	note.Threshold = math.Round(float64(threshold)*1000) / 1000
+	p.recordRejection(&note, reason, 1)
+}
+
+// recordRejection queues a rejected detection for saving, subject to the sample rate of the
+// log. Detection processing never waits for the database, rejections are dropped when the
+// queue is full.
+func (p *Processor) recordRejection(note *datastore.Note, reason string, matches int) {
+	if p.rejectedChan == nil {
+		return
+	}
+	if rate := p.Settings.Realtime.Rejected.SampleRate; rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	select {
+	case p.rejectedChan <- datastore.NewRejectedDetection(note, reason, matches):
+	default:
+	}
+}
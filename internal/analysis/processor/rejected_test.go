@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestRejectResult(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.Rejected.SampleRate = 1
+	settings.Realtime.Rejected.MinConfidence = 0.3
+	p := &Processor{Settings: settings, rejectedChan: make(chan datastore.RejectedDetection, 10)}
+	item := &queue.Results{StartTime: time.Now(), Source: "malgo"}
+
+	p.rejectResult(item, datastore.Results{Species: "Parus major_Great Tit_gretit1", Confidence: 0.5}, datastore.RejectReasonThreshold, 0.8)
+	p.rejectResult(item, datastore.Results{Species: "Parus major_Great Tit_gretit1", Confidence: 0.2}, datastore.RejectReasonThreshold, 0.8)
+	p.rejectResult(item, datastore.Results{Species: "Pica pica_Eurasian Magpie_eurmag1", Confidence: 0.2}, datastore.RejectReasonRangeFilter, 0.1)
+	p.rejectResult(item, datastore.Results{Species: "Human vocal_Human vocal", Confidence: 0.5}, datastore.RejectReasonThreshold, 0.8)
+
+	// Only the first result clears the minimum confidence, range filter rejections are kept
+	// regardless of it and human voices are never kept
+	if len(p.rejectedChan) != 2 {
+		t.Fatalf("expected 2 rejected detections, got %d", len(p.rejectedChan))
+	}
+	first := <-p.rejectedChan
+	if first.CommonName != "Great Tit" || first.Reason != datastore.RejectReasonThreshold || first.Threshold != 0.8 || first.Matches != 1 {
+		t.Errorf("unexpected rejected detection: %+v", first)
+	}
+	if second := <-p.rejectedChan; second.Reason != datastore.RejectReasonRangeFilter {
+		t.Errorf("expected a range filter rejection, got %+v", second)
+	}
+
+	// A zero sample rate keeps nothing
+	settings.Realtime.Rejected.SampleRate = 0
+	p.recordRejection(&datastore.Note{CommonName: "Great Tit"}, datastore.RejectReasonMatches, 1)
+	if len(p.rejectedChan) != 0 {
+		t.Error("expected no rejected detections with a zero sample rate")
+	}
+}
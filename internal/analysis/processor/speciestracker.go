@@ -0,0 +1,83 @@
+// speciestracker.go
+package processor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// SpeciesTracker classifies detections as new or returning species. The time each species
+// was last detected is looked up from the datastore once and then kept in memory.
+type SpeciesTracker struct {
+	ds            datastore.Interface
+	returningDays int
+	lastSeen      map[string]time.Time // last detection per scientific name, zero if never detected
+	mu            sync.Mutex
+}
+
+// NewSpeciesTracker creates a new SpeciesTracker. Species not detected for returningDays
+// are classified as returning, 0 disables the returning event.
+func NewSpeciesTracker(ds datastore.Interface, returningDays int) *SpeciesTracker {
+	return &SpeciesTracker{
+		ds:            ds,
+		returningDays: returningDays,
+		lastSeen:      make(map[string]time.Time),
+	}
+}
+
+// Classify returns the detection event of the note, or an empty string if the species has been
+// detected recently, together with the time the species was previously detected.
+func (t *SpeciesTracker) Classify(note *datastore.Note) (event string, previous time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	detected := note.Timestamp
+	if detected.IsZero() {
+		detected = time.Now()
+	}
+
+	previous, cached := t.lastSeen[note.ScientificName]
+	if !cached {
+		previous, err = t.ds.GetSpeciesLastSeen(note.ScientificName, detected)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+	}
+	if detected.After(previous) {
+		t.lastSeen[note.ScientificName] = detected
+	}
+
+	return t.classify(previous, detected), previous, nil
+}
+
+// classify determines the event of a detection from the previous detection of the species.
+func (t *SpeciesTracker) classify(previous, detected time.Time) string {
+	local := detected.Local()
+	switch {
+	case previous.IsZero():
+		return datastore.EventLifer
+	case previous.Before(time.Date(local.Year(), time.January, 1, 0, 0, 0, 0, time.Local)):
+		return datastore.EventFirstOfYear
+	case previous.Before(seasonStart(local)):
+		return datastore.EventFirstOfSeason
+	case t.returningDays > 0 && detected.Sub(previous) >= time.Duration(t.returningDays)*24*time.Hour:
+		return datastore.EventReturning
+	}
+	return ""
+}
+
+// seasonStart returns the start of the meteorological season containing t. Seasons start on the
+// first day of March, June, September and December on both hemispheres.
+func seasonStart(t time.Time) time.Time {
+	month := t.Month()
+	year := t.Year()
+	startMonth := month - (month % 3)
+	if startMonth == 0 {
+		// January and February belong to the season starting in December of the previous year
+		startMonth = time.December
+		year--
+	}
+	return time.Date(year, startMonth, 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestSpeciesTrackerClassify(t *testing.T) {
+	tracker := NewSpeciesTracker(nil, 30)
+	detected := time.Date(2024, time.July, 15, 6, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		previous time.Time
+		want     string
+	}{
+		{"never detected", time.Time{}, datastore.EventLifer},
+		{"last detected previous year", time.Date(2023, time.July, 14, 6, 0, 0, 0, time.Local), datastore.EventFirstOfYear},
+		{"last detected previous season", time.Date(2024, time.May, 31, 23, 0, 0, 0, time.Local), datastore.EventFirstOfSeason},
+		{"absent for returning days", time.Date(2024, time.June, 10, 6, 0, 0, 0, time.Local), datastore.EventReturning},
+		{"detected recently", time.Date(2024, time.July, 14, 6, 0, 0, 0, time.Local), ""},
+	}
+
+	for _, tt := range tests {
+		if got := tracker.classify(tt.previous, detected); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSeasonStart(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		want time.Time
+	}{
+		{time.Date(2024, time.February, 10, 0, 0, 0, 0, time.UTC), time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.August, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, time.December, 24, 0, 0, 0, 0, time.UTC), time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := seasonStart(tt.date); !got.Equal(tt.want) {
+			t.Errorf("seasonStart(%s) = %s, want %s", tt.date.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
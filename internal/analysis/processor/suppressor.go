@@ -0,0 +1,160 @@
+// suppressor.go: discards detections heard around suppressing sounds
+package processor
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/observation"
+)
+
+// suppressor tracks when the labels of a suppressor rule were heard on each audio source.
+type suppressor struct {
+	rule  conf.SuppressorRule
+	heard map[string][]suppressorSpan // spans the suppressor was heard in by audio source, oldest first
+}
+
+// suppressorSpan is a period in which a suppressor was heard in consecutive audio chunks,
+// from the start of the first chunk to the start of the last chunk.
+type suppressorSpan struct {
+	start, end time.Time
+}
+
+// newSuppressors returns the enabled suppressor rules of the settings.
+func newSuppressors(settings *conf.Settings) []*suppressor {
+	var suppressors []*suppressor
+	for _, rule := range settings.Realtime.SuppressorRules() {
+		if !rule.Enabled {
+			continue
+		}
+		rule.Lookahead = min(rule.Lookahead, conf.DetectionHoldTime)
+		suppressors = append(suppressors, &suppressor{rule: rule, heard: make(map[string][]suppressorSpan)})
+	}
+	return suppressors
+}
+
+// triggeredBy reports whether a species is one of the labels of the rule.
+func (s *suppressor) triggeredBy(scientificName, commonName string) bool {
+	for _, label := range s.rule.Labels {
+		if matchesLabel(commonName, label) || matchesLabel(scientificName, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLabel reports whether a name is a suppressor label or starts with it as a whole word,
+// ignoring case. The label "human" matches "Human vocal" and "Human whistle" but not
+// "Humaita Antbird".
+func matchesLabel(name, label string) bool {
+	name, label = strings.ToLower(name), strings.ToLower(strings.TrimSpace(label))
+	return label != "" && (name == label || strings.HasPrefix(name, label+" "))
+}
+
+// appliesTo reports whether the rule applies to an audio source, given by its name or by the
+// name of the configured audio source it belongs to.
+func (s *suppressor) appliesTo(settings *conf.Settings, source string) bool {
+	if len(s.rule.Sources) == 0 || slices.Contains(s.rule.Sources, source) {
+		return true
+	}
+	sourceSettings, found := settings.FindAudioSource(source)
+	return found && slices.Contains(s.rule.Sources, sourceSettings.Name)
+}
+
+// suppresses reports whether the rule suppresses a species. The labels of the rule are not
+// suppressed by it.
+func (s *suppressor) suppresses(scientificName, commonName string) bool {
+	if s.triggeredBy(scientificName, commonName) {
+		return false
+	}
+	if len(s.rule.Species) == 0 {
+		return true
+	}
+	for _, species := range s.rule.Species {
+		if strings.EqualFold(species, commonName) || strings.EqualFold(species, scientificName) {
+			return true
+		}
+	}
+	return false
+}
+
+// record records that the suppressor was heard in the audio chunk starting at start. Chunks
+// following each other are merged into one span. It returns true if a new span was started.
+// Spans too old to suppress a pending detection are dropped.
+func (s *suppressor) record(source string, start time.Time) bool {
+	spans := s.heard[source]
+
+	expired := start.Add(-time.Duration(s.rule.Lookback+2*conf.DetectionHoldTime) * time.Second)
+	for len(spans) > 0 && spans[0].end.Before(expired) {
+		spans = spans[1:]
+	}
+
+	newSpan := true
+	if n := len(spans); n > 0 && !start.After(spans[n-1].end.Add(conf.CaptureLength*time.Second)) {
+		if start.After(spans[n-1].end) {
+			spans[n-1].end = start
+		}
+		newSpan = false
+	} else {
+		spans = append(spans, suppressorSpan{start: start, end: start})
+	}
+	s.heard[source] = spans
+	return newSpan
+}
+
+// heardBetween reports whether the suppressor was heard on an audio source between from and to.
+func (s *suppressor) heardBetween(source string, from, to time.Time) bool {
+	for _, span := range s.heard[source] {
+		if !span.start.After(to) && !span.end.Before(from) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSuppressors records the suppressor rules triggered by a prediction result.
+func (p *Processor) handleSuppressors(item *queue.Results, result datastore.Results) {
+	if len(p.suppressors) == 0 {
+		return
+	}
+	scientificName, commonName, _ := observation.ParseSpeciesString(result.Species)
+
+	for _, s := range p.suppressors {
+		if result.Confidence <= s.rule.Confidence || !s.triggeredBy(scientificName, commonName) || !s.appliesTo(p.Settings, item.Source) {
+			continue
+		}
+		p.detectionMutex.Lock()
+		newSpan := s.record(item.Source, item.StartTime)
+		p.detectionMutex.Unlock()
+
+		if newSpan || s.rule.Debug {
+			log.Printf("Suppressor %s triggered by %s with confidence %.3f/%.3f from source %s\n",
+				s.rule.Name, commonName, result.Confidence, s.rule.Confidence, item.Source)
+		}
+	}
+}
+
+// checkSuppressors returns the name of the first suppressor rule heard within its lookback
+// and lookahead of a pending detection that suppresses the species, or an empty string.
+func (p *Processor) checkSuppressors(item *PendingDetection) string {
+	note := &item.Detection.Note
+
+	p.detectionMutex.RLock()
+	defer p.detectionMutex.RUnlock()
+	for _, s := range p.suppressors {
+		if !s.suppresses(note.ScientificName, note.CommonName) {
+			continue
+		}
+		from := item.FirstDetected.Add(-time.Duration(s.rule.Lookback) * time.Second)
+		to := item.LastUpdated.Add(time.Duration(s.rule.Lookahead) * time.Second)
+		if s.heardBetween(item.Source, from, to) {
+			return s.rule.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestSuppressors(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.PrivacyFilter = conf.PrivacyFilterSettings{Enabled: true, Confidence: 0.05}
+	settings.Realtime.DogBarkFilter = conf.DogBarkFilterSettings{Enabled: true, Confidence: 0.1, Remember: 5, Species: []string{"eurasian magpie"}}
+	settings.Realtime.Suppressors = []conf.SuppressorRule{
+		{Name: "engine", Enabled: true, Labels: []string{"engine"}, Confidence: 0.3, Lookback: 30, Sources: []string{"rtsp://yard"}},
+	}
+	p := &Processor{Settings: settings, suppressors: newSuppressors(settings)}
+
+	start := time.Date(2024, 5, 12, 6, 0, 0, 0, time.UTC)
+	hear := func(source, species string, confidence float32, offset int) {
+		item := &queue.Results{Source: source, StartTime: start.Add(time.Duration(offset) * time.Second)}
+		p.handleSuppressors(item, datastore.Results{Species: species, Confidence: confidence})
+	}
+	detection := func(source, scientificName, commonName string, first, last int) *PendingDetection {
+		return &PendingDetection{
+			Detection:     Detections{Note: datastore.Note{ScientificName: scientificName, CommonName: commonName}},
+			Source:        source,
+			FirstDetected: start.Add(time.Duration(first) * time.Second),
+			LastUpdated:   start.Add(time.Duration(last) * time.Second),
+		}
+	}
+
+	hear("malgo", "Dog_Dog_dogdog", 0.5, 0)
+	hear("malgo", "Human vocal_Human vocal_humvoc", 0.5, 120)
+	hear("rtsp://yard", "Engine_Engine_engine", 0.5, 600)
+	hear("rtsp://yard", "Engine_Engine_engine", 0.5, 603)
+	hear("malgo", "Engine_Engine_engine", 0.5, 600) // engine rule does not apply to this source
+
+	tests := []struct {
+		name      string
+		detection *PendingDetection
+		want      string
+	}{
+		{"magpie after dog bark", detection("malgo", "Pica pica", "Eurasian Magpie", 60, 66), conf.SuppressorDogBark},
+		{"magpie after dog bark expired", detection("malgo", "Pica pica", "Eurasian Magpie", 400, 406), ""},
+		{"tit after dog bark not on list", detection("malgo", "Parus major", "Great Tit", 60, 66), ""},
+		{"tit before human voice", detection("malgo", "Parus major", "Great Tit", 110, 116), conf.SuppressorPrivacy},
+		{"tit after human voice", detection("malgo", "Parus major", "Great Tit", 124, 130), ""},
+		{"tit before engine", detection("rtsp://yard", "Parus major", "Great Tit", 580, 586), ""},
+		{"tit after engine", detection("rtsp://yard", "Parus major", "Great Tit", 610, 616), "engine"},
+		{"engine is not suppressed by itself", detection("rtsp://yard", "Engine", "Engine", 600, 606), ""},
+		{"engine on other source", detection("malgo", "Parus major", "Great Tit", 610, 616), ""},
+	}
+	for _, tt := range tests {
+		if got := p.checkSuppressors(tt.detection); got != tt.want {
+			t.Errorf("%s: checkSuppressors() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesLabel(t *testing.T) {
+	tests := []struct {
+		name, label string
+		want        bool
+	}{
+		{"Human vocal", "human", true},
+		{"Humaita Antbird", "human", false},
+		{"Dog", "Dog", true},
+		{"Chordeiles gundlachii", "gun", false},
+		{"Engine", "", false},
+	}
+	for _, tt := range tests {
+		if got := matchesLabel(tt.name, tt.label); got != tt.want {
+			t.Errorf("matchesLabel(%q, %q) = %v, want %v", tt.name, tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestSuppressorSpans(t *testing.T) {
+	s := &suppressor{rule: conf.SuppressorRule{Lookback: 60}, heard: make(map[string][]suppressorSpan)}
+	start := time.Date(2024, 5, 12, 6, 0, 0, 0, time.UTC)
+
+	// Consecutive chunks extend one span, a gap starts another and old spans expire
+	for _, offset := range []int{0, 2, 4, 20, 200} {
+		s.record("malgo", start.Add(time.Duration(offset)*time.Second))
+	}
+	spans := s.heard["malgo"]
+	if len(spans) != 1 || !spans[0].start.Equal(start.Add(200*time.Second)) {
+		t.Fatalf("expected only the latest span to remain, got %+v", spans)
+	}
+
+	s.heard["malgo"] = nil
+	for _, offset := range []int{0, 2, 4, 20} {
+		s.record("malgo", start.Add(time.Duration(offset)*time.Second))
+	}
+	spans = s.heard["malgo"]
+	if len(spans) != 2 || !spans[0].end.Equal(start.Add(4*time.Second)) {
+		t.Errorf("expected spans 0-4 and 20-20, got %+v", spans)
+	}
+}
@@ -0,0 +1,98 @@
+// timerules.go: time of day dependent detection schedule and species thresholds
+package processor
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// inSchedule reports whether detections at t are within the daily detection schedule.
+// Detections are kept when the schedule cannot be evaluated, e.g. when the sun does not set.
+func (p *Processor) inSchedule(t time.Time) bool {
+	schedule := &p.Settings.Realtime.Schedule
+	if !schedule.Enabled || p.SunCalc == nil {
+		return true
+	}
+	start, end, err := conf.ParseSunPeriod(schedule.From, schedule.To)
+	if err != nil {
+		return true
+	}
+	in, err := p.SunCalc.InPeriod(t, start, end)
+	if err != nil {
+		if p.Settings.Debug {
+			log.Printf("Failed to evaluate detection schedule: %v\n", err)
+		}
+		return true
+	}
+	return in
+}
+
+// applyTimeRules returns the confidence threshold of a species at time t and whether its
+// detections are excluded at t. Rules are applied in order, the first rule with a threshold
+// sets the threshold and any rule may exclude the species. Rules that cannot be evaluated at
+// t are ignored.
+func (p *Processor) applyTimeRules(scientificName, commonName string, t time.Time, threshold float32) (adjusted float32, excluded bool) {
+	if p.SunCalc == nil {
+		return threshold, false
+	}
+
+	adjusted = threshold
+	thresholdSet := false
+	for _, rule := range p.Settings.Realtime.Species.TimeRules {
+		if excluded || (thresholdSet && !rule.Exclude) || !timeRuleMatches(&rule, scientificName, commonName) {
+			continue
+		}
+		start, end, err := conf.ParseSunPeriod(rule.From, rule.To)
+		if err != nil {
+			continue
+		}
+		in, err := p.SunCalc.InPeriod(t, start, end)
+		if err != nil {
+			if p.Settings.Debug {
+				log.Printf("Failed to evaluate species time rule from %s to %s: %v\n", rule.From, rule.To, err)
+			}
+			continue
+		}
+		if !in {
+			continue
+		}
+
+		if rule.Exclude {
+			excluded = true
+		}
+		if rule.Threshold > 0 && !thresholdSet {
+			adjusted, thresholdSet = float32(rule.Threshold), true
+		}
+	}
+	return adjusted, excluded
+}
+
+// timeRuleThreshold combines the threshold set by time rules with the threshold that applies
+// otherwise, which dynamic thresholds may have lowered below the base threshold. A rule that
+// raises the threshold sets a floor and a rule that lowers it sets a ceiling, so dynamic
+// thresholds cannot undo a rule.
+func timeRuleThreshold(threshold, base, timed float32) float32 {
+	switch {
+	case timed > base:
+		return max(threshold, timed)
+	case timed < base:
+		return min(threshold, timed)
+	}
+	return threshold
+}
+
+// timeRuleMatches reports whether a species time rule applies to a species.
+func timeRuleMatches(rule *conf.SpeciesTimeRule, scientificName, commonName string) bool {
+	if len(rule.Species) == 0 {
+		return true
+	}
+	for _, species := range rule.Species {
+		if strings.EqualFold(species, commonName) || strings.EqualFold(species, scientificName) {
+			return true
+		}
+	}
+	return false
+}
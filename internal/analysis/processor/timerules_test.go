@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/suncalc"
+)
+
+func TestTimeRules(t *testing.T) {
+	// Helsinki at the March equinox, sunrise is about 06:15 and sunset about 18:25 local time
+	settings := &conf.Settings{}
+	settings.Realtime.Schedule = conf.ScheduleSettings{Enabled: true, From: "civildawn", To: "civildusk"}
+	settings.Realtime.Species.TimeRules = []conf.SpeciesTimeRule{
+		{Species: []string{"Tawny Owl"}, From: "sunrise+1h", To: "sunset-1h", Exclude: true},
+		{Species: []string{"Caprimulgus europaeus"}, From: "sunrise", To: "sunset", Threshold: 0.9},
+		{From: "22:00", To: "04:00", Threshold: 0.5},
+	}
+	p := &Processor{Settings: settings, SunCalc: suncalc.NewSunCalc(60.1699, 24.9384)}
+	eet := time.FixedZone("EET", 2*60*60)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 20, hour, minute, 0, 0, eet)
+	}
+
+	if !p.inSchedule(at(12, 0)) || p.inSchedule(at(2, 0)) {
+		t.Error("expected detections at noon only to be within the dawn to dusk schedule")
+	}
+
+	tests := []struct {
+		name          string
+		scientific    string
+		common        string
+		t             time.Time
+		wantThreshold float32
+		wantExcluded  bool
+	}{
+		{"owl at noon", "Strix aluco", "Tawny Owl", at(12, 0), 0.8, true},
+		{"owl after sunset", "Strix aluco", "Tawny Owl", at(19, 0), 0.8, false},
+		{"nightjar at noon", "Caprimulgus europaeus", "European Nightjar", at(12, 0), 0.9, false},
+		{"nightjar at night", "Caprimulgus europaeus", "European Nightjar", at(23, 0), 0.5, false},
+		{"tit at noon", "Parus major", "Great Tit", at(12, 0), 0.8, false},
+	}
+	for _, tt := range tests {
+		threshold, excluded := p.applyTimeRules(tt.scientific, tt.common, tt.t, 0.8)
+		if threshold != tt.wantThreshold || excluded != tt.wantExcluded {
+			t.Errorf("%s: applyTimeRules() = %v, %v, want %v, %v", tt.name, threshold, excluded, tt.wantThreshold, tt.wantExcluded)
+		}
+	}
+}
+
+func TestTimeRulesWithDynamicThresholds(t *testing.T) {
+	// A nightjar first detected at night gets a dynamic threshold below the base threshold,
+	// which must not undo the higher threshold of its daytime rule
+	const label = "Caprimulgus europaeus_European Nightjar_eurnig1"
+	settings := &conf.Settings{}
+	settings.BirdNET.Threshold = 0.8
+	settings.UpdateIncludedSpecies([]string{label})
+	settings.Realtime.DynamicThreshold = conf.DynamicThresholdSettings{
+		Enabled:    true,
+		Trigger:    0.9,
+		Min:        0.2,
+		ValidHours: 24,
+		Levels:     []conf.DynamicThresholdLevel{{Count: 1, Multiplier: 0.5}},
+	}
+	settings.Realtime.Species.TimeRules = []conf.SpeciesTimeRule{
+		{Species: []string{"European Nightjar"}, From: "sunrise", To: "sunset", Threshold: 0.9},
+		{Species: []string{"European Nightjar"}, From: "01:00", To: "02:00", Threshold: 0.3},
+	}
+	p := &Processor{
+		Settings:          settings,
+		SunCalc:           suncalc.NewSunCalc(60.1699, 24.9384),
+		DynamicThresholds: make(map[string]*DynamicThreshold),
+	}
+	eet := time.FixedZone("EET", 2*60*60)
+	detected := func(hour int, confidence float32) bool {
+		return len(p.processResults(&queue.Results{
+			StartTime: time.Date(2024, 3, 20, hour, 0, 0, 0, eet),
+			Results:   []datastore.Results{{Species: label, Confidence: confidence}},
+			Source:    "malgo",
+		})) > 0
+	}
+
+	if !detected(23, 0.95) || !detected(23, 0.95) {
+		t.Fatal("expected nightjar to be detected at night")
+	}
+	if dt := p.DynamicThresholds["european nightjar"]; dt == nil || math.Abs(dt.CurrentValue-0.4) > 1e-6 {
+		t.Fatalf("expected dynamic threshold of 0.4 based on the untimed threshold, got %+v", dt)
+	}
+
+	tests := []struct {
+		hour       int
+		confidence float32
+		want       bool
+	}{
+		{12, 0.85, false}, // daytime rule is a floor
+		{12, 0.95, true},
+		{23, 0.5, true},  // dynamic threshold applies at night
+		{1, 0.35, true},  // lowering rule is a ceiling
+		{1, 0.25, false}, // minimum of rule and dynamic threshold
+	}
+	for _, tt := range tests {
+		if got := detected(tt.hour, tt.confidence); got != tt.want {
+			t.Errorf("detection at %02d:00 with confidence %.2f: detected = %v, want %v", tt.hour, tt.confidence, got, tt.want)
+		}
+	}
+}
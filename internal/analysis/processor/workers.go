@@ -2,7 +2,13 @@
 package processor
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/datastore"
 )
 
 // TaskType defines types of tasks that can be handled by the worker.
@@ -17,8 +23,39 @@ type Task struct {
 	Type      TaskType
 	Detection Detections
 	Action    Action
+	QueueID   uint // ID of the event queue entry of the action, 0 if the action is not persisted
+}
+
+// QueuedAction is an action that can be stored in the persistent event queue and restored
+// after a restart.
+type QueuedAction interface {
+	Action
+	QueueKind() string             // kind of the action, used to restore it
+	QueuePayload() ([]byte, error) // serialized action
+}
+
+// Kinds of actions stored in the event queue
+const (
+	queueKindLog          = "log"
+	queueKindDatabase     = "database"
+	queueKindBirdWeather  = "birdweather"
+	queueKindMQTT         = "mqtt"
+	queueKindNotification = "notification"
+	queueKindHub          = "hub"
+)
+
+// queuedAction is the stored form of an action in the event queue.
+type queuedAction struct {
+	Note       datastore.Note      `json:"note"`
+	Results    []datastore.Results `json:"results,omitempty"`
+	PCMData    []byte              `json:"pcmData,omitempty"`
+	Target     string              `json:"target,omitempty"`
+	Conditions []string            `json:"conditions,omitempty"`
 }
 
+// eventQueuePollInterval is the interval at which due entries are read from the event queue
+const eventQueuePollInterval = time.Second
+
 // workerQueue is a channel that holds tasks to be processed by worker goroutines.
 var workerQueue chan Task
 
@@ -30,6 +67,11 @@ func (p *Processor) startWorkerPool(numWorkers int) {
 	for i := 0; i < numWorkers; i++ {
 		go p.actionWorker()
 	}
+
+	// Deliver queued actions left from previous runs and retry failed actions
+	if p.EventQueue != nil {
+		go p.eventQueueDispatcher()
+	}
 }
 
 // actionWorker is the goroutine that processes tasks from the workerQueue.
@@ -38,10 +80,234 @@ func (p *Processor) actionWorker() {
 		if task.Type == TaskTypeAction {
 			// Execute the action associated with the task
 			err := task.Action.Execute(task.Detection)
+			if task.QueueID != 0 {
+				p.finishQueuedTask(&task, err)
+				continue
+			}
 			if err != nil {
 				log.Printf("Error executing action: %s\n", err)
-				// Handle errors appropriately (e.g., log, retry)
 			}
 		}
 	}
 }
+
+// enqueueTask hands a task to the workers. Actions that can be persisted are first written to
+// the event queue when it is enabled, so they are delivered even if the process stops.
+func (p *Processor) enqueueTask(task Task) {
+	if p.EventQueue != nil {
+		if action, ok := task.Action.(QueuedAction); ok {
+			id, err := p.persistAction(action)
+			if err == nil {
+				task.QueueID = id
+				select {
+				case workerQueue <- task:
+				default:
+					// Workers are busy, the dispatcher delivers the action from the queue
+					p.EventQueue.Release(id)
+				}
+				return
+			}
+			log.Printf("Failed to store action in event queue, running it without persistence: %v\n", err)
+		}
+	}
+
+	workerQueue <- task
+}
+
+// persistAction writes an action to the event queue.
+func (p *Processor) persistAction(action QueuedAction) (uint, error) {
+	payload, err := action.QueuePayload()
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize %s action: %w", action.QueueKind(), err)
+	}
+	return p.EventQueue.Enqueue(action.QueueKind(), payload)
+}
+
+// finishQueuedTask removes a delivered action from the event queue, or records the failed attempt
+// so that the action is retried or dead lettered.
+func (p *Processor) finishQueuedTask(task *Task, err error) {
+	action, _ := task.Action.(QueuedAction)
+	kind := "unknown"
+	if action != nil {
+		kind = action.QueueKind()
+	}
+
+	if err == nil {
+		if err := p.EventQueue.Complete(task.QueueID); err != nil {
+			log.Printf("Failed to remove delivered %s action from event queue: %v\n", kind, err)
+		}
+		return
+	}
+
+	// Store the state of the action so work already done is not repeated
+	var payload []byte
+	if action != nil {
+		payload, _ = action.QueuePayload()
+	}
+
+	deadLettered, qerr := p.EventQueue.Fail(task.QueueID, err, payload)
+	if qerr != nil {
+		log.Printf("Failed to record failed %s action in event queue: %v\n", kind, qerr)
+		return
+	}
+
+	metricsEnabled := p.Settings.Realtime.Telemetry.Enabled && p.Metrics != nil && p.Metrics.Queue != nil
+	if deadLettered {
+		log.Printf("Error executing %s action, giving up and moving it to the dead letter queue: %s\n", kind, err)
+		if metricsEnabled {
+			p.Metrics.Queue.IncrementDeadLettered(kind)
+		}
+		return
+	}
+
+	log.Printf("Error executing %s action, will retry: %s\n", kind, err)
+	if metricsEnabled {
+		p.Metrics.Queue.IncrementRetries(kind)
+	}
+}
+
+// eventQueueDispatcher periodically hands actions that are due for delivery to the workers.
+func (p *Processor) eventQueueDispatcher() {
+	ticker := time.NewTicker(eventQueuePollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		entries, err := p.EventQueue.Claim(cap(workerQueue) - len(workerQueue))
+		if err != nil {
+			log.Printf("Failed to read event queue: %v\n", err)
+			continue
+		}
+
+		for i := range entries {
+			task, err := p.restoreTask(&entries[i])
+			if err != nil {
+				p.finishQueuedTask(&Task{QueueID: entries[i].ID}, err)
+				continue
+			}
+			workerQueue <- task
+		}
+	}
+}
+
+// restoreTask recreates the task of an event queue entry.
+func (p *Processor) restoreTask(entry *queue.Entry) (Task, error) {
+	var stored queuedAction
+	if err := json.Unmarshal(entry.Payload, &stored); err != nil {
+		return Task{}, fmt.Errorf("failed to decode %s action: %w", entry.Kind, err)
+	}
+
+	// Frequency limits were applied when the action was first attempted
+	retry := entry.Attempts > 0
+
+	var action Action
+	switch entry.Kind {
+	case queueKindLog:
+		action = &LogAction{Settings: p.Settings, EventTracker: p.EventTracker, Note: stored.Note, retry: retry}
+	case queueKindDatabase:
+		if p.Ds == nil {
+			return Task{}, fmt.Errorf("database is not available")
+		}
+		action = &DatabaseAction{
+			Settings:      p.Settings,
+			EventTracker:  p.EventTracker,
+			Note:          stored.Note,
+			Results:       stored.Results,
+			Ds:            p.Ds,
+			DetectionChan: p.DetectionChan,
+			pcmData:       stored.PCMData,
+			retry:         retry,
+		}
+	case queueKindBirdWeather:
+		if p.BwClient == nil {
+			return Task{}, fmt.Errorf("BirdWeather client is not initialized")
+		}
+		action = &BirdWeatherAction{
+			Settings:     p.Settings,
+			EventTracker: p.EventTracker,
+			BwClient:     p.BwClient,
+			Note:         stored.Note,
+			pcmData:      stored.PCMData,
+			retry:        retry,
+		}
+	case queueKindMQTT:
+		if p.MqttClient == nil {
+			return Task{}, fmt.Errorf("MQTT client is not initialized")
+		}
+		action = &MqttAction{
+			Settings:       p.Settings,
+			MqttClient:     p.MqttClient,
+			EventTracker:   p.EventTracker,
+			Note:           stored.Note,
+			BirdImageCache: p.BirdImageCache,
+			retry:          retry,
+		}
+	case queueKindHub:
+		if p.HubClient == nil {
+			return Task{}, fmt.Errorf("hub client is not initialized")
+		}
+		action = &HubAction{
+			Settings:     p.Settings,
+			EventTracker: p.EventTracker,
+			HubClient:    p.HubClient,
+			Note:         stored.Note,
+			Results:      stored.Results,
+			pcmData:      stored.PCMData,
+			retry:        retry,
+		}
+	case queueKindNotification:
+		target, exists := p.NotificationTargets[stored.Target]
+		if !exists {
+			return Task{}, fmt.Errorf("notification target %s is not configured", stored.Target)
+		}
+		action = &NotificationAction{
+			Settings:     p.Settings,
+			Ds:           p.Ds,
+			Note:         stored.Note,
+			Target:       target,
+			Conditions:   stored.Conditions,
+			EventTracker: p.EventTracker,
+			retry:        retry,
+		}
+	default:
+		return Task{}, fmt.Errorf("unknown action kind %q", entry.Kind)
+	}
+
+	return Task{
+		Type:      TaskTypeAction,
+		Detection: Detections{Note: stored.Note, Results: stored.Results, pcmData3s: stored.PCMData},
+		Action:    action,
+		QueueID:   entry.ID,
+	}, nil
+}
+
+// trackEvent applies the event frequency limit of an action. Retried actions already passed the
+// limit on their first attempt and are not limited again.
+func trackEvent(tracker *EventTracker, retry bool, species string, eventType EventType) bool {
+	return retry || tracker.TrackEvent(species, eventType)
+}
+
+// updateQueueMetrics reports queue depths and dropped results to telemetry.
+func (p *Processor) updateQueueMetrics() {
+	if !p.Settings.Realtime.Telemetry.Enabled || p.Metrics == nil || p.Metrics.Queue == nil {
+		return
+	}
+
+	p.Metrics.Queue.SetQueueDepth("results", float64(len(queue.ResultsQueue)))
+	p.Metrics.Queue.SetQueueDepth("actions", float64(len(workerQueue)))
+
+	dropped := queue.DroppedResults()
+	if dropped > p.reportedDrops {
+		p.Metrics.Queue.AddDropped("results", float64(dropped-p.reportedDrops))
+		p.reportedDrops = dropped
+	}
+
+	if p.EventQueue != nil {
+		pending, deadLetters, err := p.EventQueue.Stats()
+		if err != nil {
+			log.Printf("Failed to read event queue statistics: %v\n", err)
+			return
+		}
+		p.Metrics.Queue.SetQueueDepth("persistent", float64(pending))
+		p.Metrics.Queue.SetDeadLetters(float64(deadLetters))
+	}
+}
@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/hub"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+func TestRestoreQueuedAction(t *testing.T) {
+	p := &Processor{Settings: &conf.Settings{}, EventTracker: NewEventTracker(time.Minute)}
+	original := &LogAction{Note: datastore.Note{ID: 7, CommonName: "Eurasian Blackbird", Source: "garden_ch2", Channel: 2}}
+
+	payload, err := original.QueuePayload()
+	if err != nil {
+		t.Fatalf("QueuePayload failed: %v", err)
+	}
+
+	task, err := p.restoreTask(&queue.Entry{ID: 3, Kind: original.QueueKind(), Payload: payload, Attempts: 1})
+	if err != nil {
+		t.Fatalf("restoreTask failed: %v", err)
+	}
+	restored, ok := task.Action.(*LogAction)
+	if !ok {
+		t.Fatalf("Expected *LogAction, got %T", task.Action)
+	}
+	if task.QueueID != 3 || restored.Note.ID != 7 || restored.Note.Channel != 2 || task.Detection.Note.CommonName != "Eurasian Blackbird" {
+		t.Errorf("Unexpected restored task %+v", task)
+	}
+	if !restored.retry {
+		t.Error("Expected action with failed attempts to be restored as retry")
+	}
+
+	// Actions whose dependencies are missing stay in the queue for a later attempt
+	if _, err := p.restoreTask(&queue.Entry{Kind: queueKindDatabase, Payload: payload}); err == nil {
+		t.Error("Expected error restoring database action without a datastore")
+	}
+	if _, err := p.restoreTask(&queue.Entry{Kind: "unknown", Payload: payload}); err == nil {
+		t.Error("Expected error restoring unknown action kind")
+	}
+}
+
+func TestTrackEventRetry(t *testing.T) {
+	tracker := NewEventTracker(time.Hour)
+	if !trackEvent(tracker, false, "robin", DatabaseSave) {
+		t.Fatal("First event must be allowed")
+	}
+	if trackEvent(tracker, false, "robin", DatabaseSave) {
+		t.Error("Second event within interval must be limited")
+	}
+	if !trackEvent(tracker, true, "robin", DatabaseSave) {
+		t.Error("Retries must not be limited")
+	}
+}
+
+func TestDatabaseActionClipLost(t *testing.T) {
+	// A queued action retried after the capture buffer no longer holds its clip saves the note
+	// without the clip instead of failing until it is dead lettered
+	settings := &conf.Settings{}
+	settings.Output.SQLite.Enabled = true
+	settings.Output.SQLite.Path = t.TempDir() + "/test.db"
+	settings.Realtime.Audio.Export.Enabled = true
+	ds := datastore.New(settings)
+	if err := ds.Open(); err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer ds.Close()
+
+	p := &Processor{Settings: settings, Ds: ds, EventTracker: NewEventTracker(time.Minute)}
+	original := &DatabaseAction{Note: datastore.Note{
+		CommonName: "Eurasian Blackbird",
+		Source:     "lost",
+		ClipName:   "clips/blackbird.wav",
+		BeginTime:  time.Now().Add(-time.Hour),
+		EndTime:    time.Now().Add(-time.Hour + 5*time.Second),
+	}}
+	payload, err := original.QueuePayload()
+	if err != nil {
+		t.Fatalf("QueuePayload failed: %v", err)
+	}
+	task, err := p.restoreTask(&queue.Entry{ID: 1, Kind: queueKindDatabase, Payload: payload, Attempts: 1})
+	if err != nil {
+		t.Fatalf("restoreTask failed: %v", err)
+	}
+
+	if err := task.Action.Execute(task.Detection); err != nil {
+		t.Fatalf("Expected note to be saved without the lost clip, got %v", err)
+	}
+	restored := task.Action.(*DatabaseAction)
+	if restored.Note.ID == 0 || restored.Note.ClipName != "" {
+		t.Errorf("Expected saved note without clip, got ID %d and clip %q", restored.Note.ID, restored.Note.ClipName)
+	}
+}
+
+func TestHubActionSendsClip(t *testing.T) {
+	// The hub receives the clip of the detection span rather than the analyzed chunk
+	var received hub.Submission
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("invalid submission: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	settings := &conf.Settings{}
+	settings.Realtime.Hub.Client.URL = server.URL
+	client, err := hub.NewClient(settings)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	const source = "hubclip"
+	if err := myaudio.AllocateCaptureBuffer(10, conf.SampleRate, conf.BitDepth/8, source); err != nil {
+		t.Fatalf("AllocateCaptureBuffer failed: %v", err)
+	}
+	defer myaudio.RemoveCaptureBuffer(source)
+	start := time.Now()
+	if err := myaudio.WriteToCaptureBuffer(source, make([]byte, 2*conf.SampleRate*conf.BitDepth/8)); err != nil {
+		t.Fatalf("WriteToCaptureBuffer failed: %v", err)
+	}
+
+	action := &HubAction{
+		Settings:     settings,
+		EventTracker: NewEventTracker(time.Minute),
+		HubClient:    client,
+		Note:         datastore.Note{CommonName: "Eurasian Blackbird", Source: source, BeginTime: start, EndTime: start.Add(time.Second)},
+	}
+	if err := action.Execute(nil); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// One second of 16 bit mono audio with a 44 byte WAV header, give or take a few samples as
+	// the buffer starts at the time of the first write
+	if want := 44 + conf.SampleRate*conf.BitDepth/8; len(received.Clip) < want-100 || len(received.Clip) > want+100 {
+		t.Errorf("clip of %d bytes, want about %d", len(received.Clip), want)
+	}
+}
@@ -0,0 +1,211 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Entry is a pending action stored in the event queue.
+type Entry struct {
+	ID          uint      `gorm:"primaryKey"`
+	Kind        string    `gorm:"size:64;index"` // Kind of action, used to restore the action from its payload
+	Payload     []byte    // Serialized action
+	Attempts    int       // Number of failed delivery attempts
+	NextAttempt time.Time `gorm:"index"` // Earliest time of the next delivery attempt
+	LastError   string    // Error of the last failed attempt
+	DeadLetter  bool      `gorm:"index"` // True when the action failed too many times and is no longer retried
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// RetryPolicy controls how failed entries are retried.
+type RetryPolicy struct {
+	MaxAttempts   int           // Attempts before an entry is moved to the dead letter queue
+	RetryDelay    time.Duration // Delay before the first retry, doubled after each failed attempt
+	MaxRetryDelay time.Duration // Upper limit of the retry delay
+}
+
+// backoff returns the delay before the next attempt after the given number of failed attempts.
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	delay := p.RetryDelay
+	for i := 1; i < attempts && delay < p.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxRetryDelay {
+		delay = p.MaxRetryDelay
+	}
+	return delay
+}
+
+// EventQueue is a write-ahead queue of pending actions stored in SQLite. Entries are delivered
+// at least once: an entry is only removed after its action completed, so entries that were in
+// progress when the process stopped are delivered again on the next start.
+type EventQueue struct {
+	db       *gorm.DB
+	policy   RetryPolicy
+	mu       sync.Mutex
+	inFlight map[uint]bool // entries handed out for delivery and not yet completed or failed
+}
+
+// OpenEventQueue opens or creates the event queue database at path.
+func OpenEventQueue(path string, policy RetryPolicy) (*EventQueue, error) {
+	if policy.MaxAttempts < 1 {
+		return nil, errors.New("event queue needs at least one delivery attempt")
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create event queue directory: %w", err)
+		}
+	}
+
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event queue database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying *sql.DB: %w", err)
+	}
+	// A single connection serializes writes and keeps in-memory databases shared
+	sqlDB.SetMaxOpenConns(1)
+
+	for _, pragma := range []string{
+		"PRAGMA journal_mode=WAL", // faster writes
+		"PRAGMA synchronous=FULL", // entries must survive power loss
+	} {
+		if _, err := sqlDB.Exec(pragma); err != nil {
+			log.Printf("Warning: Failed to set event queue pragma %s: %v", pragma, err)
+		}
+	}
+
+	if err := db.AutoMigrate(&Entry{}); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to migrate event queue database: %w", err)
+	}
+
+	return &EventQueue{
+		db:       db,
+		policy:   policy,
+		inFlight: make(map[uint]bool),
+	}, nil
+}
+
+// Close closes the queue database.
+func (q *EventQueue) Close() error {
+	sqlDB, err := q.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Enqueue stores a new entry and returns its ID. The entry is handed out to the caller for
+// delivery, which must end with Complete, Fail or Release.
+func (q *EventQueue) Enqueue(kind string, payload []byte) (uint, error) {
+	entry := Entry{Kind: kind, Payload: payload, NextAttempt: time.Now()}
+	if err := q.db.Create(&entry).Error; err != nil {
+		return 0, fmt.Errorf("failed to enqueue %s entry: %w", kind, err)
+	}
+
+	q.mu.Lock()
+	q.inFlight[entry.ID] = true
+	q.mu.Unlock()
+	return entry.ID, nil
+}
+
+// Claim hands out up to limit entries that are due for delivery and not already in progress.
+func (q *EventQueue) Claim(limit int) ([]Entry, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	inFlight := make([]uint, 0, len(q.inFlight))
+	for id := range q.inFlight {
+		inFlight = append(inFlight, id)
+	}
+
+	query := q.db.Where("dead_letter = ? AND next_attempt <= ?", false, time.Now())
+	if len(inFlight) > 0 {
+		query = query.Where("id NOT IN ?", inFlight)
+	}
+
+	var entries []Entry
+	if err := query.Order("id").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to read due entries: %w", err)
+	}
+	for i := range entries {
+		q.inFlight[entries[i].ID] = true
+	}
+	return entries, nil
+}
+
+// Complete removes a delivered entry.
+func (q *EventQueue) Complete(id uint) error {
+	defer q.Release(id)
+	if err := q.db.Delete(&Entry{}, id).Error; err != nil {
+		return fmt.Errorf("failed to remove entry %d: %w", id, err)
+	}
+	return nil
+}
+
+// Fail records a failed delivery attempt. The entry is retried after a backoff delay, or moved
+// to the dead letter queue once it has failed MaxAttempts times, which is reported by
+// deadLettered. The payload replaces the stored payload when it is not nil, so that progress
+// made by a partially successful action is not repeated.
+func (q *EventQueue) Fail(id uint, cause error, payload []byte) (deadLettered bool, err error) {
+	defer q.Release(id)
+
+	var entry Entry
+	if err := q.db.First(&entry, id).Error; err != nil {
+		return false, fmt.Errorf("failed to read entry %d: %w", id, err)
+	}
+
+	entry.Attempts++
+	entry.LastError = cause.Error()
+	if payload != nil {
+		entry.Payload = payload
+	}
+	if entry.Attempts >= q.policy.MaxAttempts {
+		entry.DeadLetter = true
+	} else {
+		entry.NextAttempt = time.Now().Add(q.policy.backoff(entry.Attempts))
+	}
+
+	if err := q.db.Save(&entry).Error; err != nil {
+		return false, fmt.Errorf("failed to update entry %d: %w", id, err)
+	}
+	return entry.DeadLetter, nil
+}
+
+// Release returns an entry handed out for delivery to the queue without recording an attempt.
+func (q *EventQueue) Release(id uint) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inFlight, id)
+}
+
+// Stats returns the number of entries waiting for delivery and the number of dead letters.
+func (q *EventQueue) Stats() (pending, deadLetters int64, err error) {
+	if err := q.db.Model(&Entry{}).Where("dead_letter = ?", false).Count(&pending).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count pending entries: %w", err)
+	}
+	if err := q.db.Model(&Entry{}).Where("dead_letter = ?", true).Count(&deadLetters).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count dead letters: %w", err)
+	}
+	return pending, deadLetters, nil
+}
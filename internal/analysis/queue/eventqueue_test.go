@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestQueue(t *testing.T, path string) *EventQueue {
+	t.Helper()
+	q, err := OpenEventQueue(path, RetryPolicy{MaxAttempts: 2, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to open event queue: %v", err)
+	}
+	return q
+}
+
+func TestEventQueueSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+
+	q := openTestQueue(t, path)
+	if _, err := q.Enqueue("mqtt", []byte("first")); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	// Entries handed out by Enqueue are not claimed again
+	if entries, _ := q.Claim(10); len(entries) != 0 {
+		t.Fatalf("Expected no claimable entries, got %d", len(entries))
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// An entry that was in progress is delivered again after a restart
+	q = openTestQueue(t, path)
+	defer q.Close()
+	entries, err := q.Claim(10)
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Kind != "mqtt" || string(entries[0].Payload) != "first" {
+		t.Fatalf("Unexpected entries after restart: %+v", entries)
+	}
+
+	if err := q.Complete(entries[0].ID); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if pending, _, _ := q.Stats(); pending != 0 {
+		t.Errorf("Expected empty queue after completion, got %d entries", pending)
+	}
+}
+
+func TestEventQueueRetryAndDeadLetter(t *testing.T) {
+	q := openTestQueue(t, filepath.Join(t.TempDir(), "queue.db"))
+	defer q.Close()
+
+	id, err := q.Enqueue("database", []byte("note"))
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	deadLettered, err := q.Fail(id, errors.New("database locked"), []byte("saved note"))
+	if err != nil || deadLettered {
+		t.Fatalf("First failure: dead lettered %v, error %v", deadLettered, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	entries, err := q.Claim(10)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected retry of failed entry, got %d entries, error %v", len(entries), err)
+	}
+	if entries[0].Attempts != 1 || string(entries[0].Payload) != "saved note" || entries[0].LastError != "database locked" {
+		t.Errorf("Unexpected retried entry %+v", entries[0])
+	}
+
+	deadLettered, err = q.Fail(id, errors.New("database locked"), nil)
+	if err != nil || !deadLettered {
+		t.Fatalf("Second failure: dead lettered %v, error %v", deadLettered, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if entries, _ := q.Claim(10); len(entries) != 0 {
+		t.Errorf("Dead letters must not be delivered, got %d entries", len(entries))
+	}
+	if pending, deadLetters, _ := q.Stats(); pending != 0 || deadLetters != 1 {
+		t.Errorf("Expected 0 pending and 1 dead letter, got %d and %d", pending, deadLetters)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, expected := range want {
+		if got := policy.backoff(i + 1); got != expected {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, expected)
+		}
+	}
+}
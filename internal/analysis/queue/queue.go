@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/datastore"
@@ -56,3 +57,16 @@ func Init(mainSize, retrySize int) {
 	ResultsQueue = make(chan Results, mainSize)
 	RetryQueue = make(chan Results, retrySize)
 }
+
+// droppedResults counts results dropped because ResultsQueue was full
+var droppedResults atomic.Uint64
+
+// RecordDroppedResults counts results that could not be added to ResultsQueue.
+func RecordDroppedResults() {
+	droppedResults.Add(1)
+}
+
+// DroppedResults returns the number of results dropped since start.
+func DroppedResults() uint64 {
+	return droppedResults.Load()
+}
@@ -20,8 +20,8 @@ import (
 	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/diskmanager"
 	"github.com/tphakala/birdnet-go/internal/httpcontroller"
-	"github.com/tphakala/birdnet-go/internal/httpcontroller/handlers"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/notification"
 	"github.com/tphakala/birdnet-go/internal/telemetry"
 	"github.com/tphakala/birdnet-go/internal/weather"
 )
@@ -30,7 +30,7 @@ import (
 var audioLevelChan = make(chan myaudio.AudioLevelData, 100)
 
 // RealtimeAnalysis initiates the BirdNET Analyzer in real-time mode and waits for a termination signal.
-func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.Notification) error {
+func RealtimeAnalysis(settings *conf.Settings, notificationChan chan notification.Notification) error {
 	// Initialize BirdNET interpreter
 	if err := initializeBirdNET(settings); err != nil {
 		return err
@@ -61,7 +61,7 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 
 	// Log the start of BirdNET-Go Analyzer in realtime mode and its configurations.
 	fmt.Printf("Starting analyzer in realtime mode. Threshold: %v, overlap: %v, sensitivity: %v, interval: %v\n",
-		settings.BirdNET.Threshold,
+		settings.GetThreshold(),
 		settings.BirdNET.Overlap,
 		settings.BirdNET.Sensitivity,
 		settings.Realtime.Interval)
@@ -79,6 +79,10 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 		defer closeDataStore(dataStore)
 	}
 
+	// Keep the range filter history, starting with the species list built at startup
+	bn.RangeFilterStore = dataStore
+	bn.SaveRangeFilterScores()
+
 	// Initialize the control channel for restart control.
 	controlChan := make(chan string, 1)
 	// Initialize the restart channel for capture restart control.
@@ -90,15 +94,8 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	audioLevelChan = make(chan myaudio.AudioLevelData, 100)
 
 	// Prepare sources list
-	var sources []string
-	if len(settings.Realtime.RTSP.URLs) > 0 || settings.Realtime.Audio.Source != "" {
-		if len(settings.Realtime.RTSP.URLs) > 0 {
-			sources = settings.Realtime.RTSP.URLs
-		}
-		if settings.Realtime.Audio.Source != "" {
-			sources = append(sources, "malgo")
-		}
-
+	sources := audioSourceNames(settings)
+	if len(sources) > 0 {
 		// Initialize buffers for all audio sources
 		if err := initializeBuffers(sources); err != nil {
 			log.Printf("❌ %v", err)
@@ -125,11 +122,15 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 		birdImageCache = nil
 	}
 
+	// Channel for saved detections published on the live detection stream
+	detectionChan := make(chan datastore.Note, 100)
+
 	// Start worker pool for processing detections
-	processor.New(settings, dataStore, bn, metrics, birdImageCache)
+	proc := processor.New(settings, dataStore, bn, metrics, birdImageCache, notificationChan, detectionChan, controlChan)
 
 	// Initialize and start the HTTP server
-	httpServer := httpcontroller.New(settings, dataStore, birdImageCache, audioLevelChan, controlChan)
+	httpServer := httpcontroller.New(settings, dataStore, birdImageCache, audioLevelChan, controlChan, notificationChan, detectionChan)
+	httpServer.Handlers.DynamicThresholds = proc
 	httpServer.Start()
 
 	// Initialize the wait group to wait for all goroutines to finish
@@ -139,7 +140,7 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	bufferManager := NewBufferManager(bn, quitChan, &wg)
 
 	// Start buffer monitors for each audio source only if we have active sources
-	if len(settings.Realtime.RTSP.URLs) > 0 || settings.Realtime.Audio.Source != "" {
+	if len(sources) > 0 {
 		bufferManager.UpdateMonitors(sources)
 	} else {
 		log.Println("⚠️  Starting without active audio sources. You can configure audio devices or RTSP streams through the web interface.")
@@ -352,7 +353,7 @@ func initBirdImageCache(ds datastore.Interface, metrics *telemetry.Metrics) *ima
 }
 
 // startControlMonitor handles various control signals for realtime analysis mode
-func startControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan, restartChan chan struct{}, notificationChan chan handlers.Notification, bufferManager *BufferManager) {
+func startControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan, restartChan chan struct{}, notificationChan chan notification.Notification, bufferManager *BufferManager) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -363,13 +364,13 @@ func startControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan,
 				case "rebuild_range_filter":
 					if err := birdnet.BuildRangeFilter(bn); err != nil {
 						log.Printf("\033[31m❌ Error handling range filter rebuild: %v\033[0m", err)
-						notificationChan <- handlers.Notification{
+						notificationChan <- notification.Notification{
 							Message: fmt.Sprintf("Failed to rebuild range filter: %v", err),
 							Type:    "error",
 						}
 					} else {
 						log.Printf("\033[32m🔄 Range filter rebuilt successfully\033[0m")
-						notificationChan <- handlers.Notification{
+						notificationChan <- notification.Notification{
 							Message: "Range filter rebuilt successfully",
 							Type:    "success",
 						}
@@ -377,52 +378,43 @@ func startControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan,
 				case "reload_birdnet":
 					if err := bn.ReloadModel(); err != nil {
 						log.Printf("\033[31m❌ Error reloading BirdNET model: %v\033[0m", err)
-						notificationChan <- handlers.Notification{
+						notificationChan <- notification.Notification{
 							Message: fmt.Sprintf("Failed to reload BirdNET model: %v", err),
 							Type:    "error",
 						}
 					} else {
 						log.Printf("\033[32m✅ BirdNET model reloaded successfully\033[0m")
-						notificationChan <- handlers.Notification{
+						notificationChan <- notification.Notification{
 							Message: "BirdNET model reloaded successfully",
 							Type:    "success",
 						}
 						// Rebuild range filter after model reload
 						if err := birdnet.BuildRangeFilter(bn); err != nil {
 							log.Printf("\033[31m❌ Error rebuilding range filter after model reload: %v\033[0m", err)
-							notificationChan <- handlers.Notification{
+							notificationChan <- notification.Notification{
 								Message: fmt.Sprintf("Failed to rebuild range filter: %v", err),
 								Type:    "error",
 							}
 						} else {
 							log.Printf("\033[32m✅ Range filter rebuilt successfully\033[0m")
-							notificationChan <- handlers.Notification{
+							notificationChan <- notification.Notification{
 								Message: "Range filter rebuilt successfully",
 								Type:    "success",
 							}
 						}
 					}
 				case "reconfigure_rtsp_sources":
-					log.Printf("\033[32m🔄 Reconfiguring RTSP sources...\033[0m")
+					log.Printf("\033[32m🔄 Reconfiguring audio sources...\033[0m")
 					settings := conf.Setting()
 
-					// Prepare the list of active sources
-					var sources []string
-					if len(settings.Realtime.RTSP.URLs) > 0 {
-						sources = append(sources, settings.Realtime.RTSP.URLs...)
-					}
-					if settings.Realtime.Audio.Source != "" {
-						sources = append(sources, "malgo")
-					}
-
 					// Update the analysis buffer monitors
-					bufferManager.UpdateMonitors(sources)
+					bufferManager.UpdateMonitors(audioSourceNames(settings))
 
-					// Reconfigure RTSP streams
-					myaudio.ReconfigureRTSPStreams(settings, wg, quitChan, restartChan, audioLevelChan)
+					// Reconfigure sound cards and RTSP, HTTP and file streams
+					myaudio.ReconfigureSources(settings, wg, quitChan, restartChan, audioLevelChan)
 
-					log.Printf("\033[32m✅ RTSP sources reconfigured successfully\033[0m")
-					notificationChan <- handlers.Notification{
+					log.Printf("\033[32m✅ Audio sources reconfigured successfully\033[0m")
+					notificationChan <- notification.Notification{
 						Message: "Audio capture reconfigured successfully",
 						Type:    "success",
 					}
@@ -436,6 +428,16 @@ func startControlMonitor(wg *sync.WaitGroup, controlChan chan string, quitChan,
 	}()
 }
 
+// audioSourceNames returns the names of the analysis sources of the enabled audio sources,
+// one per channel for split multichannel sources.
+func audioSourceNames(settings *conf.Settings) []string {
+	var names []string
+	for _, source := range settings.EnabledAudioSources() {
+		names = append(names, source.AnalysisSources()...)
+	}
+	return names
+}
+
 // initializeBuffers handles initialization of all audio-related buffers
 func initializeBuffers(sources []string) error {
 	// Initialize analysis buffers
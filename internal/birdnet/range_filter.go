@@ -4,24 +4,28 @@ package birdnet
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/observation"
 	tflite "github.com/tphakala/go-tflite"
 )
 
 // SpeciesScore holds a species label and its associated score.
 type SpeciesScore struct {
-	Score float64
-	Label string
+	Score  float64
+	Label  string
+	Reason string // why the species is included, one of the conf.RangeFilterReason constants
 }
 
 // ByScore implements sort.Interface for []SpeciesScore based on the Score field.
@@ -44,8 +48,10 @@ func BuildRangeFilter(bn *BirdNET) error {
 
 	// Convert the speciesScores slice to a slice of species labels
 	var includedSpecies []string
+	scores := make([]conf.RangeFilterScore, 0, len(speciesScores))
 	for _, speciesScore := range speciesScores {
 		includedSpecies = append(includedSpecies, speciesScore.Label)
+		scores = append(scores, conf.RangeFilterScore{Label: speciesScore.Label, Score: speciesScore.Score, Reason: speciesScore.Reason})
 	}
 
 	if conf.Setting().BirdNET.RangeFilter.Debug {
@@ -62,11 +68,34 @@ func BuildRangeFilter(bn *BirdNET) error {
 		}
 	}
 
-	conf.Setting().UpdateIncludedSpecies(includedSpecies)
+	conf.Setting().UpdateRangeFilterScores(scores, int(getWeekForFilter(today)))
+	bn.SaveRangeFilterScores()
 
 	return nil
 }
 
+// SaveRangeFilterScores saves the current range filter scores to the range filter history of
+// the datastore, replacing scores saved earlier on the same day. Nothing is saved without a
+// datastore.
+func (bn *BirdNET) SaveRangeFilterScores() {
+	if bn.RangeFilterStore == nil {
+		return
+	}
+
+	scores, week, updated := conf.Setting().GetRangeFilterScores()
+	if updated.IsZero() {
+		return
+	}
+	date := updated.Format("2006-01-02")
+	records := make([]datastore.RangeFilterScore, 0, len(scores))
+	for _, s := range scores {
+		records = append(records, datastore.RangeFilterScore{Date: date, Week: week, Label: s.Label, Score: s.Score, Reason: s.Reason})
+	}
+	if err := bn.RangeFilterStore.SaveRangeFilterScores(date, records); err != nil {
+		log.Printf("❌ [range_filter/rebuild] Warning: Failed to save range filter history: %v\n", err)
+	}
+}
+
 // GetProbableSpecies filters and sorts bird species based on their scores.
 // It also updates the scores for species that have custom actions defined in the speciesConfigCSV.
 func (bn *BirdNET) GetProbableSpecies(date time.Time, week float32) ([]SpeciesScore, error) {
@@ -76,7 +105,7 @@ func (bn *BirdNET) GetProbableSpecies(date time.Time, week float32) ([]SpeciesSc
 		bn.Debug("Latitude and longitude not set, not using location based prediction filter")
 		var speciesScores []SpeciesScore
 		for _, label := range bn.Settings.BirdNET.Labels {
-			speciesScores = append(speciesScores, SpeciesScore{Score: 0.0, Label: label})
+			speciesScores = append(speciesScores, SpeciesScore{Score: 0.0, Label: label, Reason: conf.RangeFilterReasonNoLocation})
 		}
 		return speciesScores, nil
 	}
@@ -100,7 +129,7 @@ func (bn *BirdNET) GetProbableSpecies(date time.Time, week float32) ([]SpeciesSc
 		if filter.Score >= bn.Settings.BirdNET.RangeFilter.Threshold {
 			// Check if species is in exclude list before adding
 			if !isSpeciesExcluded(filter.Label, bn.Settings.Realtime.Species.Exclude) {
-				speciesScores = append(speciesScores, SpeciesScore{Score: float64(filter.Score), Label: filter.Label})
+				speciesScores = append(speciesScores, SpeciesScore{Score: float64(filter.Score), Label: filter.Label, Reason: conf.RangeFilterReasonScore})
 			} else {
 				bn.Debug("Excluding species from range filter: %s", filter.Label)
 			}
@@ -113,13 +142,13 @@ func (bn *BirdNET) GetProbableSpecies(date time.Time, week float32) ([]SpeciesSc
 	// Process explicitly included species
 	for _, includedSpecies := range bn.Settings.Realtime.Species.Include {
 		bn.Debug("Processing included species: %s", includedSpecies)
-		addSpeciesWithMaxScore(bn, &speciesScores, includedSpecies, processedSpecies)
+		addSpeciesWithMaxScore(bn, &speciesScores, includedSpecies, conf.RangeFilterReasonInclude, processedSpecies)
 	}
 
 	// Process species with configured actions
 	for species := range bn.Settings.Realtime.Species.Config {
 		bn.Debug("Processing species with actions: %s", species)
-		addSpeciesWithMaxScore(bn, &speciesScores, species, processedSpecies)
+		addSpeciesWithMaxScore(bn, &speciesScores, species, conf.RangeFilterReasonConfig, processedSpecies)
 	}
 
 	// Sort species scores in descending order
@@ -128,8 +157,9 @@ func (bn *BirdNET) GetProbableSpecies(date time.Time, week float32) ([]SpeciesSc
 	return speciesScores, nil
 }
 
-// addSpeciesWithMaxScore adds all matching species to the scores list with maximum score
-func addSpeciesWithMaxScore(bn *BirdNET, speciesScores *[]SpeciesScore, speciesName string, processedSpecies map[string]bool) {
+// addSpeciesWithMaxScore adds all matching species to the scores list with maximum score.
+// Species already on the list are raised to the maximum score instead of being added twice.
+func addSpeciesWithMaxScore(bn *BirdNET, speciesScores *[]SpeciesScore, speciesName, reason string, processedSpecies map[string]bool) {
 	// Skip if already processed
 	if processedSpecies[speciesName] {
 		return
@@ -139,7 +169,7 @@ func addSpeciesWithMaxScore(bn *BirdNET, speciesScores *[]SpeciesScore, speciesN
 	for _, label := range bn.Settings.BirdNET.Labels {
 		if matchesSpecies(label, speciesName) {
 			bn.Debug("Adding species with max score: %s (matched with: %s)", label, speciesName)
-			*speciesScores = append(*speciesScores, SpeciesScore{Score: 1.0, Label: label})
+			updateOrAddSpecies(speciesScores, label, reason)
 			matchFound = true
 		}
 	}
@@ -240,15 +270,17 @@ func getWeekForFilter(date time.Time) float32 {
 	return float32(weeksFromMonths + weekInMonth)
 }
 
-// Function to update the score of existing species or add new ones
-func updateOrAddSpecies(scoreMap map[string]*SpeciesScore, scores *[]SpeciesScore, label string) {
-	if score, exists := scoreMap[label]; exists {
-		score.Score = 1.0 // Updates the score of the existing species
-	} else {
-		newScore := SpeciesScore{Score: 1.0, Label: label}
-		*scores = append(*scores, newScore)          // Adds new species to the slice
-		scoreMap[label] = &(*scores)[len(*scores)-1] // Update map with new score reference
+// updateOrAddSpecies raises an existing species to the maximum score or adds it with the
+// maximum score, recording why it is included
+func updateOrAddSpecies(scores *[]SpeciesScore, label, reason string) {
+	for i := range *scores {
+		if (*scores)[i].Label == label {
+			(*scores)[i].Score = 1.0
+			(*scores)[i].Reason = reason
+			return
+		}
 	}
+	*scores = append(*scores, SpeciesScore{Score: 1.0, Label: label, Reason: reason})
 }
 
 // loadSpeciesFromCSV reads species names from a CSV file located in one of the default config paths.
@@ -350,3 +382,115 @@ func PrintSpeciesScores(date time.Time, speciesScores []SpeciesScore) {
 
 	fmt.Printf("\nTotal number of species: %d\n", numSpecies)
 }
+
+// WeekScores holds the probable species of a range filter week.
+type WeekScores struct {
+	Week   int
+	Scores []SpeciesScore
+}
+
+// GetWeekSpeciesScores returns the probable species of a range filter week, the week is taken
+// from the date if it is not set.
+func (bn *BirdNET) GetWeekSpeciesScores(date time.Time, week float32) (WeekScores, error) {
+	if week == 0 {
+		week = getWeekForFilter(date)
+	}
+	scores, err := bn.GetProbableSpecies(date, week)
+	if err != nil {
+		return WeekScores{}, err
+	}
+	return WeekScores{Week: int(week), Scores: scores}, nil
+}
+
+// GetYearlySpeciesScores returns the probable species of every range filter week of the year.
+func (bn *BirdNET) GetYearlySpeciesScores() ([]WeekScores, error) {
+	weeks := make([]WeekScores, 0, 48)
+	for week := 1; week <= 48; week++ {
+		ws, err := bn.GetWeekSpeciesScores(time.Time{}, float32(week))
+		if err != nil {
+			return nil, fmt.Errorf("error computing range filter week %d: %w", week, err)
+		}
+		weeks = append(weeks, ws)
+	}
+	return weeks, nil
+}
+
+// speciesScoreRecord is the JSON representation of a species score.
+type speciesScoreRecord struct {
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	Label          string  `json:"label"`
+	Score          float64 `json:"score"`
+	Reason         string  `json:"reason"`
+}
+
+// WriteSpeciesScores writes the probable species of range filter weeks as text, json or csv.
+func WriteSpeciesScores(w io.Writer, format string, weeks []WeekScores) error {
+	settings := conf.Setting()
+	lat, lon := settings.BirdNET.Latitude, settings.BirdNET.Longitude
+	threshold := settings.BirdNET.RangeFilter.Threshold
+
+	switch format {
+	case "text":
+		for _, ws := range weeks {
+			fmt.Fprintf(w, "Included species for %v, %v in week %d, threshold %.6f\n\n", lat, lon, ws.Week, threshold)
+			fmt.Fprintf(w, "%-33s %-33s %-6s %s\n", "Scientific Name", "Common Name", "Score", "Reason")
+			fmt.Fprintln(w, strings.Repeat("-", 33), strings.Repeat("-", 33), strings.Repeat("-", 6), strings.Repeat("-", 10))
+			for _, s := range ws.Scores {
+				scientificName, commonName, _ := observation.ParseSpeciesString(s.Label)
+				fmt.Fprintf(w, "%-33s %-33s %.4f %s\n", scientificName, commonName, s.Score, s.Reason)
+			}
+			fmt.Fprintf(w, "\nTotal number of species: %d\n\n", len(ws.Scores))
+		}
+		return nil
+
+	case "json":
+		type weekRecord struct {
+			Week    int                  `json:"week"`
+			Species []speciesScoreRecord `json:"species"`
+		}
+		output := struct {
+			Latitude  float64      `json:"latitude"`
+			Longitude float64      `json:"longitude"`
+			Threshold float32      `json:"threshold"`
+			Weeks     []weekRecord `json:"weeks"`
+		}{Latitude: lat, Longitude: lon, Threshold: threshold}
+		for _, ws := range weeks {
+			record := weekRecord{Week: ws.Week, Species: make([]speciesScoreRecord, 0, len(ws.Scores))}
+			for _, s := range ws.Scores {
+				scientificName, commonName, _ := observation.ParseSpeciesString(s.Label)
+				record.Species = append(record.Species, speciesScoreRecord{
+					ScientificName: scientificName,
+					CommonName:     commonName,
+					Label:          s.Label,
+					Score:          s.Score,
+					Reason:         s.Reason,
+				})
+			}
+			output.Weeks = append(output.Weeks, record)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"week", "scientific_name", "common_name", "score", "reason"}); err != nil {
+			return err
+		}
+		for _, ws := range weeks {
+			for _, s := range ws.Scores {
+				scientificName, commonName, _ := observation.ParseSpeciesString(s.Label)
+				record := []string{strconv.Itoa(ws.Week), scientificName, commonName, strconv.FormatFloat(s.Score, 'f', 4, 64), s.Reason}
+				if err := writer.Write(record); err != nil {
+					return err
+				}
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
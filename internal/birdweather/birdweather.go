@@ -42,20 +42,46 @@ type BwClient struct {
 	Latitude      float64
 	Longitude     float64
 	HTTPClient    *http.Client
+	Buffer        *Buffer       // submissions that failed while BirdWeather was unreachable, nil if disabled
+	replayNudge   chan struct{} // wakes up the buffer replay when new submissions are buffered
+}
+
+// bufferReplayInterval is the interval at which buffered submissions are retried
+const bufferReplayInterval = time.Minute
+
+// HTTPError is returned when the BirdWeather API responds with an error status.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("status code: %d, response: %s", e.StatusCode, e.Body)
 }
 
 // New creates and initializes a new BwClient with the given settings.
 // The HTTP client is configured with a 45-second timeout to prevent hanging requests.
 func New(settings *conf.Settings) (*BwClient, error) {
 	// We expect that Birdweather ID is validated before this function is called
-	return &BwClient{
+	client := &BwClient{
 		Settings:      settings,
 		BirdweatherID: settings.Realtime.Birdweather.ID,
 		Accuracy:      settings.Realtime.Birdweather.LocationAccuracy,
 		Latitude:      settings.BirdNET.Latitude,
 		Longitude:     settings.BirdNET.Longitude,
 		HTTPClient:    &http.Client{Timeout: 45 * time.Second},
-	}, nil
+		replayNudge:   make(chan struct{}, 1),
+	}
+
+	if path := settings.Realtime.Birdweather.BufferPath; path != "" {
+		buffer, err := NewBuffer(path, settings.Realtime.Birdweather.BufferMaxEntries)
+		if err != nil {
+			return nil, err
+		}
+		client.Buffer = buffer
+	}
+
+	return client, nil
 }
 
 // RandomizeLocation adds a random offset to the given latitude and longitude to fuzz the location
@@ -96,6 +122,23 @@ func handleNetworkError(err error) error {
 	return fmt.Errorf("network error: %w", err)
 }
 
+// isTemporary reports whether a failed submission may succeed later, e.g. because the network or
+// the BirdWeather API was unavailable. Such submissions are buffered and retried.
+func isTemporary(err error) bool {
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+	// Proxies and captive portals answer with HTML instead of JSON
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr)
+}
+
 // UploadSoundscape uploads a soundscape file to the Birdweather API and returns the soundscape ID if successful.
 // It handles the PCM to WAV conversion, compresses the data, and manages HTTP request creation and response handling safely.
 func (b *BwClient) UploadSoundscape(timestamp string, pcmData []byte) (soundscapeID string, err error) {
@@ -111,10 +154,15 @@ func (b *BwClient) UploadSoundscape(timestamp string, pcmData []byte) (soundscap
 		return "", fmt.Errorf("failed to encode PCM to WAV: %w", err)
 	}
 
+	return b.uploadWAV(timestamp, wavBuffer.Bytes())
+}
+
+// uploadWAV uploads a WAV encoded soundscape to the Birdweather API and returns the soundscape ID.
+func (b *BwClient) uploadWAV(timestamp string, wavData []byte) (soundscapeID string, err error) {
 	// Compress the WAV data
 	var gzipWavData bytes.Buffer
 	gzipWriter := gzip.NewWriter(&gzipWavData)
-	if _, err := io.Copy(gzipWriter, wavBuffer); err != nil {
+	if _, err := gzipWriter.Write(wavData); err != nil {
 		log.Printf("Failed to compress WAV data: %v\n", err)
 		return "", fmt.Errorf("failed to compress WAV data: %w", err)
 	}
@@ -156,6 +204,11 @@ func (b *BwClient) UploadSoundscape(timestamp string, pcmData []byte) (soundscap
 		log.Println("Response Body:", string(responseBody))
 	}
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.Printf("Failed to upload soundscape, status code: %d, response body: %s\n", resp.StatusCode, string(responseBody))
+		return "", fmt.Errorf("failed to upload soundscape: %w", &HTTPError{StatusCode: resp.StatusCode, Body: string(responseBody)})
+	}
+
 	var sdata SoundscapeResponse
 	if err := json.Unmarshal(responseBody, &sdata); err != nil {
 		log.Printf("Failed to decode JSON response: %v\n", err)
@@ -249,50 +302,150 @@ func (b *BwClient) PostDetection(soundscapeID, timestamp, commonName, scientific
 			return fmt.Errorf("failed to read response body: %w", err)
 		}
 		log.Printf("Failed to post detection, status code: %d, response body: %s\n", resp.StatusCode, string(responseBody))
-		return fmt.Errorf("failed to post detection, status code: %d, response: %s", resp.StatusCode, string(responseBody))
+		return fmt.Errorf("failed to post detection: %w", &HTTPError{StatusCode: resp.StatusCode, Body: string(responseBody)})
 	}
 
 	return nil
 }
 
+// noteTime returns the detection time of a note in the time zone of the node that made it.
+// Notes without a UTC timestamp fall back to parsing the Date and Time strings in local time.
+func noteTime(note *datastore.Note) (time.Time, error) {
+	loc := time.Local
+	if note.TimeZone != "" {
+		if l, err := time.LoadLocation(note.TimeZone); err == nil {
+			loc = l
+		}
+	}
+
+	if !note.Timestamp.IsZero() {
+		return note.Timestamp.In(loc), nil
+	}
+
+	// Combine date and time from note to form a full timestamp string
+	dateTimeString := fmt.Sprintf("%sT%s", note.Date, note.Time)
+	return time.ParseInLocation("2006-01-02T15:04:05", dateTimeString, loc)
+}
+
 // Upload function handles the uploading of detected clips and their details to Birdweather.
 // It first parses the timestamp from the note, then uploads the soundscape, and finally posts the detection.
+// Submissions that fail because BirdWeather is unreachable are buffered and replayed in order
+// once it is reachable again.
 func (b *BwClient) Publish(note *datastore.Note, pcmData []byte) error {
 	// Add check for empty pcmData
 	if len(pcmData) == 0 {
 		return fmt.Errorf("pcmData is empty")
 	}
 
-	// Use system's local timezone for timestamp parsing
-	loc := time.Local
-
-	// Combine date and time from note to form a full timestamp string
-	dateTimeString := fmt.Sprintf("%sT%s", note.Date, note.Time)
+	wavBuffer, err := encodePCMtoWAV(pcmData)
+	if err != nil {
+		log.Printf("Failed to encode PCM to WAV: %v\n", err)
+		return fmt.Errorf("failed to encode PCM to WAV: %w", err)
+	}
 
-	// Parse the timestamp using the given format and the system's local timezone
-	parsedTime, err := time.ParseInLocation("2006-01-02T15:04:05", dateTimeString, loc)
+	sub, parsedTime, err := newSubmission(note)
 	if err != nil {
-		log.Printf("Error parsing date: %v\n", err)
-		return fmt.Errorf("error parsing date: %w", err)
+		return err
+	}
+
+	if b.Buffer == nil {
+		return b.submit(sub, wavBuffer.Bytes())
+	}
+
+	if b.Buffer.IsSubmitted(sub.Keys) {
+		if b.Settings.Realtime.Birdweather.Debug {
+			log.Printf("Detection of %s at %s already submitted to Birdweather\n", sub.CommonName, sub.Timestamp)
+		}
+		return nil
+	}
+
+	// Queue behind earlier buffered submissions so that detections are submitted in order
+	if b.Buffer.Len() > 0 {
+		if err := b.Buffer.Add(sub, parsedTime, wavBuffer.Bytes()); err != nil {
+			return fmt.Errorf("failed to buffer Birdweather submission: %w", err)
+		}
+		b.nudgeReplay()
+		return nil
 	}
 
-	// Format the parsed time to the required timestamp format with timezone information
-	timestamp := parsedTime.Format("2006-01-02T15:04:05.000-0700")
+	if err := b.submit(sub, wavBuffer.Bytes()); err != nil {
+		if !isTemporary(err) {
+			return err
+		}
+		log.Printf("Birdweather is unreachable, buffering submission for later: %v\n", err)
+		if err := b.Buffer.Add(sub, parsedTime, wavBuffer.Bytes()); err != nil {
+			return fmt.Errorf("failed to buffer Birdweather submission: %w", err)
+		}
+		return nil
+	}
+
+	if err := b.Buffer.MarkSubmitted(sub.Keys); err != nil {
+		log.Printf("Failed to record Birdweather submission: %v\n", err)
+	}
+	return nil
+}
+
+// Submitted reports whether a note has already been submitted to Birdweather.
+func (b *BwClient) Submitted(note *datastore.Note) bool {
+	return b.Buffer != nil && b.Buffer.IsSubmitted(submissionKeys(note))
+}
+
+// SubmitWAV submits a note with a WAV encoded soundscape to Birdweather without buffering,
+// and records it as submitted.
+func (b *BwClient) SubmitWAV(note *datastore.Note, wavData []byte) error {
+	sub, _, err := newSubmission(note)
+	if err != nil {
+		return err
+	}
+	if err := b.submit(sub, wavData); err != nil {
+		return err
+	}
+	if b.Buffer != nil {
+		if err := b.Buffer.MarkSubmitted(sub.Keys); err != nil {
+			log.Printf("Failed to record Birdweather submission: %v\n", err)
+		}
+	}
+	return nil
+}
 
-	// Upload the soundscape to Birdweather and retrieve the soundscape ID
-	soundscapeID, err := b.UploadSoundscape(timestamp, pcmData)
+// newSubmission creates the submission of a note.
+func newSubmission(note *datastore.Note) (*submission, time.Time, error) {
+	parsedTime, err := noteTime(note)
 	if err != nil {
-		log.Printf("Failed to upload soundscape to Birdweather: %v\n", err)
-		return fmt.Errorf("failed to upload soundscape to Birdweather: %w", err)
+		log.Printf("Error parsing date: %v\n", err)
+		return nil, time.Time{}, fmt.Errorf("error parsing date: %w", err)
 	}
 
-	// Log the successful posting of the soundscape, if debugging is enabled
-	if b.Settings.Realtime.Birdweather.Debug {
-		log.Println("Soundscape successfully posted to Birdweather")
+	return &submission{
+		Keys: submissionKeys(note),
+		// Format the parsed time to the required timestamp format with timezone information
+		Timestamp:      parsedTime.Format("2006-01-02T15:04:05.000-0700"),
+		CommonName:     note.CommonName,
+		ScientificName: note.ScientificName,
+		Confidence:     note.Confidence,
+	}, parsedTime, nil
+}
+
+// submit uploads the soundscape of a submission unless it was uploaded by an earlier attempt,
+// and posts the detection.
+func (b *BwClient) submit(sub *submission, wavData []byte) error {
+	if sub.SoundscapeID == "" {
+		// Upload the soundscape to Birdweather and retrieve the soundscape ID
+		soundscapeID, err := b.uploadWAV(sub.Timestamp, wavData)
+		if err != nil {
+			log.Printf("Failed to upload soundscape to Birdweather: %v\n", err)
+			return fmt.Errorf("failed to upload soundscape to Birdweather: %w", err)
+		}
+		sub.SoundscapeID = soundscapeID
+
+		// Log the successful posting of the soundscape, if debugging is enabled
+		if b.Settings.Realtime.Birdweather.Debug {
+			log.Println("Soundscape successfully posted to Birdweather")
+		}
 	}
 
 	// Post the detection details to Birdweather using the retrieved soundscape ID
-	err = b.PostDetection(soundscapeID, timestamp, note.CommonName, note.ScientificName, note.Confidence)
+	err := b.PostDetection(sub.SoundscapeID, sub.Timestamp, sub.CommonName, sub.ScientificName, sub.Confidence)
 	if err != nil {
 		log.Printf("Failed to post detection to Birdweather: %v\n", err)
 		return fmt.Errorf("failed to post detection to Birdweather: %w", err)
@@ -300,3 +453,78 @@ func (b *BwClient) Publish(note *datastore.Note, pcmData []byte) error {
 
 	return nil
 }
+
+// StartBufferReplay starts replaying buffered submissions in the background. Submissions are
+// retried periodically and whenever new submissions are buffered.
+func (b *BwClient) StartBufferReplay() {
+	if b.Buffer == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(bufferReplayInterval)
+		defer ticker.Stop()
+
+		for {
+			b.replayBuffer()
+			select {
+			case <-ticker.C:
+			case <-b.replayNudge:
+			}
+		}
+	}()
+}
+
+// nudgeReplay wakes up the buffer replay.
+func (b *BwClient) nudgeReplay() {
+	select {
+	case b.replayNudge <- struct{}{}:
+	default:
+	}
+}
+
+// replayBuffer submits buffered submissions oldest first. It stops at the first submission that
+// fails temporarily, and drops submissions that are rejected by Birdweather.
+func (b *BwClient) replayBuffer() {
+	for {
+		name, sub, wavData, err := b.Buffer.Oldest()
+		if name == "" {
+			return
+		}
+		if err != nil {
+			log.Printf("Dropping unreadable Birdweather submission: %v\n", err)
+			b.Buffer.Remove(name)
+			continue
+		}
+
+		if b.Buffer.IsSubmitted(sub.Keys) {
+			b.Buffer.Remove(name)
+			continue
+		}
+
+		uploaded := sub.SoundscapeID != ""
+		if err := b.submit(sub, wavData); err != nil {
+			if isTemporary(err) {
+				// Keep the uploaded soundscape so that it is not uploaded again
+				if !uploaded && sub.SoundscapeID != "" {
+					if err := b.Buffer.Update(name, sub); err != nil {
+						log.Printf("Failed to update buffered Birdweather submission: %v\n", err)
+					}
+				}
+				return
+			}
+			log.Printf("Birdweather rejected buffered submission of %s at %s, dropping it: %v\n", sub.CommonName, sub.Timestamp, err)
+			b.Buffer.Remove(name)
+			continue
+		}
+
+		if err := b.Buffer.MarkSubmitted(sub.Keys); err != nil {
+			log.Printf("Failed to record Birdweather submission: %v\n", err)
+		}
+		b.Buffer.Remove(name)
+
+		if b.Settings.Realtime.Birdweather.Debug {
+			log.Printf("Submitted buffered detection of %s at %s to Birdweather\n", sub.CommonName, sub.Timestamp)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// buffer.go this code stores BirdWeather submissions that failed while offline so they can be replayed later.
+package birdweather
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// maxSubmittedKeys is the number of submitted detection keys remembered for deduplication
+const maxSubmittedKeys = 10000
+
+// submittedLogName is the file in the buffer directory that lists submitted detection keys
+const submittedLogName = "submitted.log"
+
+// submission is a detection submitted to BirdWeather. Buffered submissions are stored as a
+// JSON file next to a WAV file holding the soundscape.
+type submission struct {
+	Keys           []string `json:"keys"` // deduplication keys of the note
+	Timestamp      string   `json:"timestamp"`
+	CommonName     string   `json:"commonName"`
+	ScientificName string   `json:"scientificName"`
+	Confidence     float64  `json:"confidence"`
+	SoundscapeID   string   `json:"soundscapeId,omitempty"` // set when the soundscape was uploaded but the detection was not
+}
+
+// submissionKeys returns the keys identifying a note for deduplication: its ID once it has been
+// saved, and its detection time, species and source, which are known before it is saved.
+func submissionKeys(note *datastore.Note) []string {
+	var keys []string
+	if note.ID != 0 {
+		keys = append(keys, fmt.Sprintf("id:%d", note.ID))
+	}
+	if !note.Timestamp.IsZero() {
+		keys = append(keys, fmt.Sprintf("at:%d:%s:%s", note.Timestamp.Unix(), note.ScientificName, note.Source))
+	}
+	return keys
+}
+
+// Buffer keeps BirdWeather submissions that failed on disk, in detection order, and remembers
+// which detections have been submitted.
+type Buffer struct {
+	dir        string
+	maxEntries int
+	mu         sync.Mutex
+	pending    []string            // base names of buffered submissions, oldest first
+	pendingKey map[string]string   // keys of buffered submissions to their base name
+	entryKeys  map[string][]string // base names of buffered submissions to their keys
+	submitted  map[string]bool     // keys of submitted detections
+	keyOrder   []string            // submitted keys, oldest first
+}
+
+// NewBuffer opens the buffer directory, creating it if needed.
+func NewBuffer(dir string, maxEntries int) (*Buffer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create BirdWeather buffer directory: %w", err)
+	}
+
+	b := &Buffer{
+		dir:        dir,
+		maxEntries: maxEntries,
+		pendingKey: make(map[string]string),
+		entryKeys:  make(map[string][]string),
+		submitted:  make(map[string]bool),
+	}
+	if err := b.loadSubmitted(); err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buffered submissions: %w", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		b.pending = append(b.pending, name)
+		// Unreadable submissions are reported and dropped when they are replayed
+		if sub, err := b.readSubmission(name); err == nil {
+			b.indexKeys(name, sub.Keys)
+		}
+	}
+	sort.Strings(b.pending)
+	return b, nil
+}
+
+// loadSubmitted reads the keys of submitted detections.
+func (b *Buffer) loadSubmitted() error {
+	f, err := os.Open(filepath.Join(b.dir, submittedLogName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open submitted detections log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key := strings.TrimSpace(scanner.Text()); key != "" && !b.submitted[key] {
+			b.submitted[key] = true
+			b.keyOrder = append(b.keyOrder, key)
+		}
+	}
+	return scanner.Err()
+}
+
+// Len returns the number of buffered submissions.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// IsSubmitted reports whether a detection with any of the keys has been submitted.
+func (b *Buffer) IsSubmitted(keys []string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range keys {
+		if b.submitted[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkSubmitted records the keys of a submitted detection.
+func (b *Buffer) MarkSubmitted(keys []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var added []string
+	for _, key := range keys {
+		if !b.submitted[key] {
+			b.submitted[key] = true
+			b.keyOrder = append(b.keyOrder, key)
+			added = append(added, key)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	// Rewrite the log without the oldest keys once it has grown well beyond the limit
+	if len(b.keyOrder) > 2*maxSubmittedKeys {
+		for _, key := range b.keyOrder[:len(b.keyOrder)-maxSubmittedKeys] {
+			delete(b.submitted, key)
+		}
+		b.keyOrder = append([]string{}, b.keyOrder[len(b.keyOrder)-maxSubmittedKeys:]...)
+		return b.writeFile(submittedLogName, []byte(strings.Join(b.keyOrder, "\n")+"\n"))
+	}
+
+	f, err := os.OpenFile(filepath.Join(b.dir, submittedLogName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open submitted detections log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strings.Join(added, "\n") + "\n"); err != nil {
+		return fmt.Errorf("failed to write submitted detections log: %w", err)
+	}
+	return nil
+}
+
+// Add stores a submission with its WAV soundscape at the end of the buffer. Submissions of
+// detections that are already buffered or submitted are ignored. The oldest submissions are
+// dropped when the buffer is full.
+func (b *Buffer) Add(sub *submission, detectionTime time.Time, wav []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range sub.Keys {
+		if _, pending := b.pendingKey[key]; pending || b.submitted[key] {
+			return nil
+		}
+	}
+
+	// Name entries by detection time so that they sort in detection order
+	name := fmt.Sprintf("%019d", detectionTime.UnixNano())
+	for b.indexOf(name) >= 0 {
+		name += "a"
+	}
+
+	if err := b.writeFile(name+".wav", wav); err != nil {
+		return err
+	}
+	if err := b.writeSubmission(name, sub); err != nil {
+		os.Remove(filepath.Join(b.dir, name+".wav"))
+		return err
+	}
+
+	b.pending = append(b.pending, name)
+	sort.Strings(b.pending)
+	b.indexKeys(name, sub.Keys)
+
+	for b.maxEntries > 0 && len(b.pending) > b.maxEntries {
+		log.Printf("BirdWeather buffer is full, dropping oldest submission %s\n", b.pending[0])
+		b.removeFiles(b.pending[0])
+		b.unindexKeys(b.pending[0])
+		b.pending = b.pending[1:]
+	}
+	return nil
+}
+
+// indexKeys records the keys of a buffered submission.
+func (b *Buffer) indexKeys(name string, keys []string) {
+	b.entryKeys[name] = keys
+	for _, key := range keys {
+		b.pendingKey[key] = name
+	}
+}
+
+// unindexKeys forgets the keys of a buffered submission.
+func (b *Buffer) unindexKeys(name string) {
+	for _, key := range b.entryKeys[name] {
+		if b.pendingKey[key] == name {
+			delete(b.pendingKey, key)
+		}
+	}
+	delete(b.entryKeys, name)
+}
+
+// Oldest returns the oldest buffered submission and its WAV soundscape, or an empty name if
+// the buffer is empty.
+func (b *Buffer) Oldest() (name string, sub *submission, wav []byte, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) == 0 {
+		return "", nil, nil, nil
+	}
+	name = b.pending[0]
+
+	sub, err = b.readSubmission(name)
+	if err == nil {
+		wav, err = os.ReadFile(filepath.Join(b.dir, name+".wav"))
+	}
+	if err != nil {
+		return name, nil, nil, fmt.Errorf("failed to read buffered submission %s: %w", name, err)
+	}
+	return name, sub, wav, nil
+}
+
+// Update stores the changed metadata of a buffered submission.
+func (b *Buffer) Update(name string, sub *submission) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.writeSubmission(name, sub); err != nil {
+		return err
+	}
+	b.unindexKeys(name)
+	b.indexKeys(name, sub.Keys)
+	return nil
+}
+
+// Remove deletes a buffered submission.
+func (b *Buffer) Remove(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if i := b.indexOf(name); i >= 0 {
+		b.pending = append(b.pending[:i], b.pending[i+1:]...)
+	}
+	b.removeFiles(name)
+	b.unindexKeys(name)
+}
+
+// indexOf returns the index of a pending submission, or -1.
+func (b *Buffer) indexOf(name string) int {
+	for i, pending := range b.pending {
+		if pending == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// readSubmission reads the metadata of a buffered submission.
+func (b *Buffer) readSubmission(name string) (*submission, error) {
+	data, err := os.ReadFile(filepath.Join(b.dir, name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var sub submission
+	if err := json.Unmarshal(data, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// writeSubmission writes the metadata of a buffered submission.
+func (b *Buffer) writeSubmission(name string, sub *submission) error {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to encode buffered submission: %w", err)
+	}
+	return b.writeFile(name+".json", data)
+}
+
+// writeFile atomically replaces a file in the buffer directory.
+func (b *Buffer) writeFile(name string, data []byte) error {
+	path := filepath.Join(b.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// removeFiles deletes the files of a buffered submission.
+func (b *Buffer) removeFiles(name string) {
+	for _, ext := range []string{".json", ".wav"} {
+		if err := os.Remove(filepath.Join(b.dir, name+ext)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove buffered BirdWeather submission file: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package birdweather
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBufferOrderAndDedup(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatalf("NewBuffer: %v", err)
+	}
+
+	base := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	later := &submission{Keys: []string{"at:2"}, CommonName: "later"}
+	earlier := &submission{Keys: []string{"at:1"}, CommonName: "earlier"}
+	if err := b.Add(later, base.Add(time.Minute), []byte("b")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := b.Add(earlier, base, []byte("a")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// Buffering the same detection again is ignored
+	if err := b.Add(earlier, base, []byte("a")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if b.Len() != 2 {
+		t.Fatalf("expected 2 buffered submissions, got %d", b.Len())
+	}
+
+	name, sub, wav, err := b.Oldest()
+	if err != nil {
+		t.Fatalf("Oldest: %v", err)
+	}
+	if sub.CommonName != "earlier" || string(wav) != "a" {
+		t.Errorf("expected earliest detection first, got %s", sub.CommonName)
+	}
+	b.Remove(name)
+	if err := b.MarkSubmitted(sub.Keys); err != nil {
+		t.Fatalf("MarkSubmitted: %v", err)
+	}
+
+	// State survives reopening the buffer
+	reopened, err := NewBuffer(dir, 0)
+	if err != nil {
+		t.Fatalf("NewBuffer: %v", err)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("expected 1 buffered submission after reopen, got %d", reopened.Len())
+	}
+	if !reopened.IsSubmitted([]string{"id:5", "at:1"}) {
+		t.Error("expected submitted key to be remembered")
+	}
+	if err := reopened.Add(earlier, base, []byte("a")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if reopened.Len() != 1 {
+		t.Error("expected submitted detection not to be buffered again")
+	}
+}
+
+func TestBufferDropsOldest(t *testing.T) {
+	b, err := NewBuffer(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("NewBuffer: %v", err)
+	}
+
+	base := time.Now()
+	for i := 0; i < 3; i++ {
+		sub := &submission{Keys: []string{fmt.Sprintf("at:%d", i)}, CommonName: fmt.Sprint(i)}
+		if err := b.Add(sub, base.Add(time.Duration(i)*time.Second), nil); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	if b.Len() != 2 {
+		t.Fatalf("expected 2 buffered submissions, got %d", b.Len())
+	}
+	_, sub, _, err := b.Oldest()
+	if err != nil {
+		t.Fatalf("Oldest: %v", err)
+	}
+	if sub.CommonName != "1" {
+		t.Errorf("expected oldest submission to be dropped, oldest is %s", sub.CommonName)
+	}
+}
+
+func TestBufferPendingKeys(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewBuffer(dir, 2)
+	if err != nil {
+		t.Fatalf("NewBuffer: %v", err)
+	}
+
+	// Concurrent submissions of the same detection are buffered once
+	base := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := b.Add(&submission{Keys: []string{"id:1", "at:1"}}, base, nil); err != nil {
+				t.Errorf("Add: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if b.Len() != 1 {
+		t.Fatalf("expected 1 buffered submission, got %d", b.Len())
+	}
+
+	// Pending keys are loaded when the buffer is reopened
+	reopened, err := NewBuffer(dir, 2)
+	if err != nil {
+		t.Fatalf("NewBuffer: %v", err)
+	}
+	if err := reopened.Add(&submission{Keys: []string{"at:1"}}, base, nil); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if reopened.Len() != 1 {
+		t.Fatalf("expected buffered detection not to be buffered again, got %d", reopened.Len())
+	}
+
+	// Keys of dropped submissions are forgotten
+	for i := 2; i <= 3; i++ {
+		sub := &submission{Keys: []string{fmt.Sprintf("at:%d", i)}}
+		if err := reopened.Add(sub, base.Add(time.Duration(i)*time.Second), nil); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if err := reopened.Add(&submission{Keys: []string{"at:1"}}, base.Add(4*time.Second), nil); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, sub, _, err := reopened.Oldest(); err != nil || sub.Keys[0] != "at:3" {
+		t.Errorf("expected dropped detection to be buffered again, oldest is %+v (%v)", sub, err)
+	}
+}
+
+func TestIsTemporary(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", fmt.Errorf("network error: %w", &url.Error{Op: "Post", URL: "x", Err: errors.New("refused")}), true},
+		{"server error", fmt.Errorf("failed: %w", &HTTPError{StatusCode: 503}), true},
+		{"rate limited", &HTTPError{StatusCode: 429}, true},
+		{"rejected", fmt.Errorf("failed: %w", &HTTPError{StatusCode: 422}), false},
+		{"other", errors.New("pcmData is empty"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isTemporary(tt.err); got != tt.want {
+			t.Errorf("%s: isTemporary() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
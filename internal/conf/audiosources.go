@@ -0,0 +1,283 @@
+// audiosources.go: named audio sources and their legacy equivalents
+package conf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Audio source types
+const (
+	SourceTypeSoundCard = "soundcard"
+	SourceTypeRTSP      = "rtsp"
+	SourceTypeHTTP      = "http"
+	SourceTypeFile      = "file"
+)
+
+// Channel modes of multichannel sources
+const (
+	ChannelModeSplit   = "split"   // each channel is analyzed as its own source
+	ChannelModeDownmix = "downmix" // channels are averaged to a single mono source
+)
+
+// MaxSourceChannels is the maximum number of channels captured from a source.
+const MaxSourceChannels = 32
+
+// LegacySoundCardSource is the source name of the sound card configured with realtime.audio.source.
+// It matches the name stored in detections before named sources were introduced.
+const LegacySoundCardSource = "malgo"
+
+// sourceNamePattern restricts source names to characters that are safe in file names,
+// URLs and MQTT topics.
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// AudioSources returns all configured audio sources. Named sources are followed by the sound card
+// configured in realtime.audio.source and the streams in realtime.rtsp.urls, which are converted
+// to enabled sources named after their URL without credentials, see legacyStreamNames.
+func (s *Settings) AudioSources() []AudioSourceSettings {
+	thresholdMutex.RLock()
+	named := s.Realtime.Audio.Sources
+	thresholdMutex.RUnlock()
+
+	sources := make([]AudioSourceSettings, 0, len(named)+len(s.Realtime.RTSP.URLs)+1)
+	sources = append(sources, named...)
+
+	if s.Realtime.Audio.Source != "" {
+		sources = append(sources, AudioSourceSettings{
+			Name:      LegacySoundCardSource,
+			Type:      SourceTypeSoundCard,
+			Device:    s.Realtime.Audio.Source,
+			Enabled:   true,
+			Equalizer: s.Realtime.Audio.Equalizer,
+		})
+	}
+
+	reserved := make(map[string]bool, len(sources))
+	for i := range sources {
+		reserved[sources[i].Name] = true
+	}
+	// A stream listed twice is captured once
+	var urls []string
+	seen := make(map[string]bool, len(s.Realtime.RTSP.URLs))
+	for _, streamURL := range s.Realtime.RTSP.URLs {
+		if !seen[streamURL] {
+			seen[streamURL] = true
+			urls = append(urls, streamURL)
+		}
+	}
+	names := legacyStreamNames(urls, reserved)
+	for i, streamURL := range urls {
+		sources = append(sources, AudioSourceSettings{
+			Name:      names[i],
+			Type:      SourceTypeRTSP,
+			URL:       streamURL,
+			Transport: s.Realtime.RTSP.Transport,
+			Enabled:   true,
+		})
+	}
+
+	return sources
+}
+
+// legacyStreamNames returns the source names of the streams in realtime.rtsp.urls. A stream is
+// named after its URL without credentials. URLs that differ only in credentials or query
+// parameters would share a name, and the name keys the buffers and filters of the source, so
+// every stream whose name is not unique, or is already used by another source, gets a suffix
+// derived from its full URL. The suffix keeps names stable when the list is reordered.
+func legacyStreamNames(urls []string, reserved map[string]bool) []string {
+	names := make([]string, len(urls))
+	count := make(map[string]int, len(urls))
+	for i, streamURL := range urls {
+		names[i] = SanitizeStreamURL(streamURL)
+		count[names[i]]++
+	}
+	for i, streamURL := range urls {
+		if count[names[i]] > 1 || reserved[names[i]] {
+			sum := sha256.Sum256([]byte(streamURL))
+			names[i] += "#" + hex.EncodeToString(sum[:4])
+		}
+	}
+	return names
+}
+
+// EnabledAudioSources returns the audio sources that should be captured.
+func (s *Settings) EnabledAudioSources() []AudioSourceSettings {
+	var enabled []AudioSourceSettings
+	for _, source := range s.AudioSources() {
+		if source.Enabled {
+			enabled = append(enabled, source)
+		}
+	}
+	return enabled
+}
+
+// FindAudioSource returns the audio source with the given name. The name may also be the
+// analysis source of one channel of a split multichannel source.
+func (s *Settings) FindAudioSource(name string) (AudioSourceSettings, bool) {
+	source, _, found := s.FindAnalysisSource(name)
+	return source, found
+}
+
+// FindAnalysisSource returns the audio source an analysis source belongs to, together with the
+// channel analyzed by it. The channel is 0 when all channels are analyzed as one.
+func (s *Settings) FindAnalysisSource(name string) (source AudioSourceSettings, channel int, found bool) {
+	for _, source := range s.AudioSources() {
+		if source.Name == name {
+			return source, 0, true
+		}
+		if !source.IsSplit() {
+			continue
+		}
+		for i, analysisSource := range source.AnalysisSources() {
+			if analysisSource == name {
+				return source, i + 1, true
+			}
+		}
+	}
+	return AudioSourceSettings{}, 0, false
+}
+
+// CaptureChannels returns the number of channels captured from the source.
+func (source *AudioSourceSettings) CaptureChannels() int {
+	if source.Channels < 1 {
+		return 1
+	}
+	return source.Channels
+}
+
+// IsSplit reports whether the channels of the source are analyzed separately.
+func (source *AudioSourceSettings) IsSplit() bool {
+	return source.CaptureChannels() > 1 && source.ChannelMode != ChannelModeDownmix
+}
+
+// AnalysisSources returns the names of the sources analyzed by BirdNET for this audio source,
+// one per channel for split sources.
+func (source *AudioSourceSettings) AnalysisSources() []string {
+	if !source.IsSplit() {
+		return []string{source.Name}
+	}
+	names := make([]string, source.CaptureChannels())
+	for i := range names {
+		names[i] = ChannelSourceName(source.Name, i+1)
+	}
+	return names
+}
+
+// ChannelSourceName returns the analysis source name of a channel of a split source. Channels
+// are numbered from 1.
+func ChannelSourceName(name string, channel int) string {
+	return fmt.Sprintf("%s_ch%d", name, channel)
+}
+
+// IsStream reports whether the source is read with FFmpeg.
+func (source *AudioSourceSettings) IsStream() bool {
+	switch source.Type {
+	case SourceTypeRTSP, SourceTypeHTTP, SourceTypeFile:
+		return true
+	}
+	return false
+}
+
+// SanitizeStreamURL removes credentials and query parameters from a stream URL so it can be
+// shown and stored without exposing secrets.
+func SanitizeStreamURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		// Not a URL, strip anything that looks like user info
+		if at := strings.LastIndex(rawURL, "@"); at >= 0 {
+			return rawURL[at+1:]
+		}
+		return rawURL
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// validateAudioSources validates the named audio sources.
+func validateAudioSources(settings *Settings) error {
+	names := make(map[string]bool)
+	devices := make(map[string]bool) // a sound card can only be captured once
+	if settings.Realtime.Audio.Source != "" {
+		names[LegacySoundCardSource] = true
+		devices[settings.Realtime.Audio.Source] = true
+	}
+
+	for i := range settings.Realtime.Audio.Sources {
+		source := &settings.Realtime.Audio.Sources[i]
+		if source.Name == "" {
+			return fmt.Errorf("audio source %d has no name", i+1)
+		}
+		if !sourceNamePattern.MatchString(source.Name) {
+			return fmt.Errorf("audio source name %q may only contain letters, digits, - and _", source.Name)
+		}
+		if names[source.Name] {
+			return fmt.Errorf("duplicate audio source name %q", source.Name)
+		}
+		names[source.Name] = true
+
+		switch source.Type {
+		case SourceTypeSoundCard:
+			if source.Device == "" {
+				return fmt.Errorf("audio source %q has no sound card device", source.Name)
+			}
+			if devices[source.Device] {
+				return fmt.Errorf("audio source %q uses sound card %q which is already used by another source", source.Name, source.Device)
+			}
+			devices[source.Device] = true
+		case SourceTypeRTSP, SourceTypeHTTP:
+			u, err := url.Parse(source.URL)
+			if err != nil || u.Host == "" {
+				return fmt.Errorf("audio source %q has an invalid URL", source.Name)
+			}
+			if !strings.HasPrefix(strings.ToLower(u.Scheme), source.Type) {
+				return fmt.Errorf("audio source %q of type %s needs a %s:// URL", source.Name, source.Type, source.Type)
+			}
+		case SourceTypeFile:
+			if source.URL == "" {
+				return fmt.Errorf("audio source %q has no file path", source.Name)
+			}
+		default:
+			return fmt.Errorf("audio source %q has invalid type %q, must be soundcard, rtsp, http or file", source.Name, source.Type)
+		}
+
+		if source.Type == SourceTypeRTSP && source.Transport == "" {
+			source.Transport = settings.Realtime.RTSP.Transport
+		}
+		if source.Channels < 0 || source.Channels > MaxSourceChannels {
+			return fmt.Errorf("audio source %q channels must be between 0 and %d", source.Name, MaxSourceChannels)
+		}
+		switch source.ChannelMode {
+		case "":
+			source.ChannelMode = ChannelModeSplit
+		case ChannelModeSplit, ChannelModeDownmix:
+		default:
+			return fmt.Errorf("audio source %q has invalid channel mode %q, must be split or downmix", source.Name, source.ChannelMode)
+		}
+		if source.Threshold < 0 || source.Threshold > 1 {
+			return fmt.Errorf("audio source %q threshold must be between 0 and 1", source.Name)
+		}
+		if source.Latitude < -90 || source.Latitude > 90 || source.Longitude < -180 || source.Longitude > 180 {
+			return fmt.Errorf("audio source %q has an invalid location", source.Name)
+		}
+	}
+
+	// Channel sources of split sources must not clash with other source names
+	for i := range settings.Realtime.Audio.Sources {
+		source := &settings.Realtime.Audio.Sources[i]
+		if !source.IsSplit() {
+			continue
+		}
+		for _, name := range source.AnalysisSources() {
+			if names[name] {
+				return fmt.Errorf("audio source name %q clashes with channel %s of source %q", name, strings.TrimPrefix(name, source.Name+"_"), source.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ const (
 	NumChannels   = 1     // Number of channels of the audio fed to BirdNET Analyzer
 	CaptureLength = 3     // Length of audio data fed to BirdNET Analyzer in seconds
 
+	DetectionHoldTime = 15 // Time in seconds a detection is held for further matches before it is final
+
 	SpeciesConfigCSV  = "species_config.csv"
 	SpeciesActionsCSV = "species_actions.csv"
 
@@ -51,6 +51,9 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.audio.export.path", "clips/")
 	viper.SetDefault("realtime.audio.export.type", "wav")
 	viper.SetDefault("realtime.audio.export.bitrate", "128k")
+	viper.SetDefault("realtime.audio.export.preroll", 2)
+	viper.SetDefault("realtime.audio.export.postroll", 2)
+	viper.SetDefault("realtime.audio.export.maxlength", 30)
 
 	// Audio equalizer configuration
 	viper.SetDefault("realtime.audio.equalizer.enabled", false)
@@ -69,11 +72,23 @@ func setDefaultConfig() {
 		},
 	})
 
+	// Named audio sources
+	viper.SetDefault("realtime.audio.sources", []map[string]interface{}{})
+
 	// Dashboard thumbnails configuration
 	viper.SetDefault("realtime.dashboard.thumbnails.debug", false)
 	viper.SetDefault("realtime.dashboard.thumbnails.summary", false)
 	viper.SetDefault("realtime.dashboard.thumbnails.recent", true)
 	viper.SetDefault("realtime.dashboard.summarylimit", 30)
+	viper.SetDefault("realtime.dashboard.spectrogram.renderer", "native")
+	viper.SetDefault("realtime.dashboard.spectrogram.format", "png")
+	viper.SetDefault("realtime.dashboard.spectrogram.fftsize", 1024)
+	viper.SetDefault("realtime.dashboard.spectrogram.minfreq", 0)
+	viper.SetDefault("realtime.dashboard.spectrogram.maxfreq", 12000)
+	viper.SetDefault("realtime.dashboard.spectrogram.colormap", "inferno")
+	viper.SetDefault("realtime.dashboard.spectrogram.dynamicrange", 100)
+	viper.SetDefault("realtime.dashboard.spectrogram.showband", true)
+	viper.SetDefault("realtime.dashboard.spectrogram.showwindows", false)
 
 	// Retention policy configuration
 	viper.SetDefault("realtime.audio.export.retention.enabled", true)
@@ -89,6 +104,11 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.dynamicthreshold.trigger", 0.90)
 	viper.SetDefault("realtime.dynamicthreshold.min", 0.20)
 	viper.SetDefault("realtime.dynamicthreshold.validhours", 24)
+	viper.SetDefault("realtime.dynamicthreshold.levels", []map[string]interface{}{
+		{"count": 1, "multiplier": 0.75},
+		{"count": 2, "multiplier": 0.5},
+		{"count": 3, "multiplier": 0.25},
+	})
 
 	// Log configuration
 	viper.SetDefault("realtime.log.enabled", false)
@@ -100,6 +120,8 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.birdweather.id", "")
 	viper.SetDefault("realtime.birdweather.threshold", 0.8)
 	viper.SetDefault("realtime.birdweather.locationaccuracy", 500)
+	viper.SetDefault("realtime.birdweather.bufferpath", "birdweather/")
+	viper.SetDefault("realtime.birdweather.buffermaxentries", 1000)
 
 	// OpenWeather configuration
 	/*
@@ -133,6 +155,46 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.mqtt.topic", "birdnet")
 	viper.SetDefault("realtime.mqtt.username", "birdnet")
 	viper.SetDefault("realtime.mqtt.password", "secret")
+	viper.SetDefault("realtime.mqtt.qos", 1)
+	viper.SetDefault("realtime.mqtt.retain", false)
+	viper.SetDefault("realtime.mqtt.payloadmode", MQTTPayloadFull)
+	viper.SetDefault("realtime.mqtt.homeassistant.enabled", false)
+	viper.SetDefault("realtime.mqtt.homeassistant.discoveryprefix", "homeassistant")
+	viper.SetDefault("realtime.mqtt.commands.enabled", false)
+	viper.SetDefault("realtime.mqtt.commands.topic", "")
+	viper.SetDefault("realtime.mqtt.commands.replytopic", "")
+	viper.SetDefault("realtime.mqtt.commands.token", "")
+
+	// Species tracking configuration
+	viper.SetDefault("realtime.speciestracking.enabled", true)
+	viper.SetDefault("realtime.speciestracking.returningdays", 30)
+
+	// Rejected detections log configuration
+	viper.SetDefault("realtime.rejected.enabled", false)
+	viper.SetDefault("realtime.rejected.retention", 14)
+	viper.SetDefault("realtime.rejected.samplerate", 1.0)
+	viper.SetDefault("realtime.rejected.minconfidence", 0.3)
+
+	// Persistent event queue configuration
+	viper.SetDefault("realtime.eventqueue.enabled", false)
+	viper.SetDefault("realtime.eventqueue.path", "queue.db")
+	viper.SetDefault("realtime.eventqueue.maxattempts", 10)
+	viper.SetDefault("realtime.eventqueue.retrydelay", 30)
+	viper.SetDefault("realtime.eventqueue.maxretrydelay", 3600)
+
+	// Set default values for multi-node hub
+	viper.SetDefault("realtime.hub.server.enabled", false)
+	viper.SetDefault("realtime.hub.server.nodes", []HubNode{})
+	viper.SetDefault("realtime.hub.client.enabled", false)
+	viper.SetDefault("realtime.hub.client.url", "")
+	viper.SetDefault("realtime.hub.client.token", "")
+	viper.SetDefault("realtime.hub.client.spoolpath", "hubspool/")
+	viper.SetDefault("realtime.hub.client.spoolmaxentries", 1000)
+
+	// Notification configuration
+	viper.SetDefault("realtime.notifications.enabled", false)
+	viper.SetDefault("realtime.notifications.debug", false)
+	viper.SetDefault("realtime.notifications.targets", []NotificationTarget{})
 
 	// Privacy filter configuration
 	viper.SetDefault("realtime.privacyfilter.enabled", true)
@@ -146,6 +208,14 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.dogbarkfilter.confidence", 0.1)
 	viper.SetDefault("realtime.dogbarkfilter.species", []string{})
 
+	// Daily detection schedule
+	viper.SetDefault("realtime.schedule.enabled", false)
+	viper.SetDefault("realtime.schedule.from", "civildawn")
+	viper.SetDefault("realtime.schedule.to", "civildusk")
+
+	// Suppressor rules in addition to the privacy and dog bark filters
+	viper.SetDefault("realtime.suppressors", []SuppressorRule{})
+
 	// Telemetry configuration
 	viper.SetDefault("realtime.telemetry.enabled", false)
 	viper.SetDefault("realtime.telemetry.listen", "0.0.0.0:8090")
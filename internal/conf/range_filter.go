@@ -16,9 +16,35 @@ func (s *Settings) UpdateIncludedSpecies(species []string) {
 	defer speciesListMutex.Unlock()
 	s.BirdNET.RangeFilter.Species = make([]string, len(species))
 	copy(s.BirdNET.RangeFilter.Species, species)
+	s.BirdNET.RangeFilter.Scores = nil
 	s.BirdNET.RangeFilter.LastUpdated = time.Now()
 }
 
+// UpdateRangeFilterScores updates the included species list and their scores computed for a
+// range filter week
+func (s *Settings) UpdateRangeFilterScores(scores []RangeFilterScore, week int) {
+	speciesListMutex.Lock()
+	defer speciesListMutex.Unlock()
+	s.BirdNET.RangeFilter.Species = make([]string, len(scores))
+	for i, score := range scores {
+		s.BirdNET.RangeFilter.Species[i] = score.Label
+	}
+	s.BirdNET.RangeFilter.Scores = make([]RangeFilterScore, len(scores))
+	copy(s.BirdNET.RangeFilter.Scores, scores)
+	s.BirdNET.RangeFilter.Week = week
+	s.BirdNET.RangeFilter.LastUpdated = time.Now()
+}
+
+// GetRangeFilterScores returns the scores of the included species, the range filter week they
+// were computed for and when they were computed
+func (s *Settings) GetRangeFilterScores() (scores []RangeFilterScore, week int, updated time.Time) {
+	speciesListMutex.RLock()
+	defer speciesListMutex.RUnlock()
+	scores = make([]RangeFilterScore, len(s.BirdNET.RangeFilter.Scores))
+	copy(scores, s.BirdNET.RangeFilter.Scores)
+	return scores, s.BirdNET.RangeFilter.Week, s.BirdNET.RangeFilter.LastUpdated
+}
+
 // GetIncludedSpecies returns the current included species list from the RangeFilter
 func (s *Settings) GetIncludedSpecies() []string {
 	speciesListMutex.RLock()
@@ -0,0 +1,63 @@
+// suntime.go: times of day relative to sun events
+package conf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sun events a SunTime can be relative to
+const (
+	SunEventCivilDawn = "civildawn"
+	SunEventSunrise   = "sunrise"
+	SunEventSunset    = "sunset"
+	SunEventCivilDusk = "civildusk"
+)
+
+// SunTime is a time of day, relative to a sun event or a fixed clock time.
+type SunTime struct {
+	Event  string        // sun event, empty for a clock time
+	Offset time.Duration // offset from the sun event, or from midnight for a clock time
+}
+
+// ParseSunTime parses a time of day given as a sun event with an optional offset, such as
+// "sunrise", "sunset+30m" or "civildawn-1h", or as a clock time such as "22:30".
+func ParseSunTime(value string) (SunTime, error) {
+	value = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+
+	if clock, err := time.Parse("15:04", value); err == nil {
+		return SunTime{Offset: time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute}, nil
+	}
+
+	event, offset := value, ""
+	if i := strings.IndexAny(value, "+-"); i >= 0 {
+		event, offset = value[:i], value[i:]
+	}
+	switch event {
+	case SunEventCivilDawn, SunEventSunrise, SunEventSunset, SunEventCivilDusk:
+	default:
+		return SunTime{}, fmt.Errorf("invalid time of day %q, expected HH:MM or one of civildawn, sunrise, sunset and civildusk with an optional offset such as +30m", value)
+	}
+
+	st := SunTime{Event: event}
+	if offset != "" {
+		d, err := time.ParseDuration(offset)
+		if err != nil {
+			return SunTime{}, fmt.Errorf("invalid offset in time of day %q: %w", value, err)
+		}
+		st.Offset = d
+	}
+	return st, nil
+}
+
+// ParseSunPeriod parses the start and end of a period of the day.
+func ParseSunPeriod(from, to string) (start, end SunTime, err error) {
+	if start, err = ParseSunTime(from); err != nil {
+		return SunTime{}, SunTime{}, err
+	}
+	if end, err = ParseSunTime(to); err != nil {
+		return SunTime{}, SunTime{}, err
+	}
+	return start, end, nil
+}
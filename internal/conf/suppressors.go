@@ -0,0 +1,68 @@
+// suppressors.go: rules discarding detections heard around suppressing sounds
+package conf
+
+import "fmt"
+
+// Names of the suppressor rules of the privacy and dog bark filters
+const (
+	SuppressorPrivacy = "privacy"
+	SuppressorDogBark = "dogbark"
+)
+
+// suppressorMaxNameLength is the longest rule name that fits the rejection reason of a
+// rejected detection.
+const suppressorMaxNameLength = 20
+
+// SuppressorRules returns the privacy and dog bark filters expressed as suppressor rules,
+// followed by the configured suppressor rules.
+//
+// The privacy filter suppresses all species heard during or after a human voice until the
+// detection is final. The dog bark filter suppresses the species of its list heard up to
+// Remember minutes after a dog bark, it suppresses nothing while the list is empty.
+func (s *RealtimeSettings) SuppressorRules() []SuppressorRule {
+	rules := []SuppressorRule{
+		{
+			Name:       SuppressorPrivacy,
+			Enabled:    s.PrivacyFilter.Enabled,
+			Labels:     []string{"human"},
+			Confidence: s.PrivacyFilter.Confidence,
+			Lookahead:  DetectionHoldTime,
+			Debug:      s.PrivacyFilter.Debug,
+		},
+		{
+			Name:       SuppressorDogBark,
+			Enabled:    s.DogBarkFilter.Enabled && len(s.DogBarkFilter.Species) > 0,
+			Labels:     []string{"dog"},
+			Confidence: s.DogBarkFilter.Confidence,
+			Lookback:   s.DogBarkFilter.Remember * 60,
+			Lookahead:  DetectionHoldTime,
+			Species:    s.DogBarkFilter.Species,
+			Debug:      s.DogBarkFilter.Debug,
+		},
+	}
+	return append(rules, s.Suppressors...)
+}
+
+// validateSuppressorRules validates the configured suppressor rules
+func validateSuppressorRules(rules []SuppressorRule) error {
+	names := map[string]bool{SuppressorPrivacy: true, SuppressorDogBark: true}
+	for _, rule := range rules {
+		if rule.Name == "" || len(rule.Name) > suppressorMaxNameLength {
+			return fmt.Errorf("Suppressor rule names must be 1 to %d characters long", suppressorMaxNameLength)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("Suppressor rule name %s is already in use", rule.Name)
+		}
+		names[rule.Name] = true
+		if len(rule.Labels) == 0 {
+			return fmt.Errorf("Suppressor rule %s must have at least one label", rule.Name)
+		}
+		if rule.Confidence < 0 || rule.Confidence > 1 {
+			return fmt.Errorf("Suppressor rule %s confidence must be between 0 and 1", rule.Name)
+		}
+		if rule.Lookback < 0 || rule.Lookahead < 0 {
+			return fmt.Errorf("Suppressor rule %s lookback and lookahead must be non-negative", rule.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package conf
+
+import (
+	"strings"
+	"sync"
+)
+
+// thresholdMutex guards the settings that can be changed at runtime by remote commands: the
+// global threshold, the species configuration and the audio source thresholds. The species
+// configuration map and the audio sources slice are replaced rather than modified, so a map or
+// slice obtained under the lock stays valid after it is released.
+var thresholdMutex sync.RWMutex
+
+// Snapshot returns a copy of the settings. The settings guarded by thresholdMutex are copied
+// under the lock.
+func (s *Settings) Snapshot() Settings {
+	thresholdMutex.RLock()
+	defer thresholdMutex.RUnlock()
+	return *s
+}
+
+// UpdateThresholds calls update while holding the lock of the settings that can be changed by
+// remote commands, for changes to them made by other means such as the web UI. update must
+// replace the species configuration map and the audio sources slice rather than modify them.
+func UpdateThresholds(update func()) {
+	thresholdMutex.Lock()
+	defer thresholdMutex.Unlock()
+	update()
+}
+
+// GetThreshold returns the global confidence threshold
+func (s *Settings) GetThreshold() float64 {
+	thresholdMutex.RLock()
+	defer thresholdMutex.RUnlock()
+	return s.BirdNET.Threshold
+}
+
+// SetThreshold sets the global confidence threshold
+func (s *Settings) SetThreshold(threshold float64) {
+	thresholdMutex.Lock()
+	defer thresholdMutex.Unlock()
+	s.BirdNET.Threshold = threshold
+}
+
+// GetSpeciesConfig returns the configuration of a species by lower case common name
+func (s *Settings) GetSpeciesConfig(species string) (SpeciesConfig, bool) {
+	thresholdMutex.RLock()
+	defer thresholdMutex.RUnlock()
+	config, exists := s.Realtime.Species.Config[species]
+	return config, exists
+}
+
+// GetSpeciesConfigs returns the configuration of all species, the map must not be modified
+func (s *Settings) GetSpeciesConfigs() map[string]SpeciesConfig {
+	thresholdMutex.RLock()
+	defer thresholdMutex.RUnlock()
+	return s.Realtime.Species.Config
+}
+
+// SetSpeciesThreshold sets the custom confidence threshold of a species
+func (s *Settings) SetSpeciesThreshold(species string, threshold float64) {
+	thresholdMutex.Lock()
+	defer thresholdMutex.Unlock()
+
+	species = strings.ToLower(species)
+	configs := make(map[string]SpeciesConfig, len(s.Realtime.Species.Config)+1)
+	for name, config := range s.Realtime.Species.Config {
+		configs[name] = config
+	}
+	config := configs[species]
+	config.Threshold = threshold
+	configs[species] = config
+	s.Realtime.Species.Config = configs
+}
+
+// SetSourceThreshold sets the confidence threshold of a named audio source, it returns false if
+// there is no such source
+func (s *Settings) SetSourceThreshold(name string, threshold float64) bool {
+	thresholdMutex.Lock()
+	defer thresholdMutex.Unlock()
+
+	sources := make([]AudioSourceSettings, len(s.Realtime.Audio.Sources))
+	copy(sources, s.Realtime.Audio.Sources)
+	found := false
+	for i := range sources {
+		if sources[i].Name == name {
+			sources[i].Threshold = threshold
+			found = true
+		}
+	}
+	if found {
+		s.Realtime.Audio.Sources = sources
+	}
+	return found
+}
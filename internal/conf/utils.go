@@ -292,6 +292,36 @@ func GetLocalTimezone() (*time.Location, error) {
 	return time.Local, nil
 }
 
+// GetLocalTimezoneName returns the IANA name of the local time zone, such as "Europe/Helsinki".
+// time.Local reports "Local" unless TZ is set, so the zone is resolved from the TZ environment
+// variable, the /etc/localtime symlink or /etc/timezone. If none of these is available the
+// name of time.Local is returned.
+func GetLocalTimezoneName() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && tz != "Local" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, found := strings.Cut(target, "zoneinfo/"); found {
+			if _, err := time.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+
+	if data, err := os.ReadFile("/etc/timezone"); err == nil {
+		if name := strings.TrimSpace(string(data)); name != "" {
+			if _, err := time.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+
+	return time.Local.String()
+}
+
 // ConvertUTCToLocal converts a UTC time to the local time zone.
 func ConvertUTCToLocal(utcTime time.Time) (time.Time, error) {
 	localLoc, err := GetLocalTimezone()
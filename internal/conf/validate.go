@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/url"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -57,16 +58,41 @@ func ValidateSettings(settings *Settings) error {
 		ve.Errors = append(ve.Errors, err.Error())
 	}
 
+	// Validate named audio sources
+	if err := validateAudioSources(settings); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate dynamic threshold settings
+	if err := validateDynamicThresholdSettings(&settings.Realtime.DynamicThreshold); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
 	// Validate Dashboard settings
 	if err := validateDashboardSettings(&settings.Realtime.Dashboard); err != nil {
 		ve.Errors = append(ve.Errors, err.Error())
 	}
 
+	// Validate Notification settings
+	if err := validateNotificationSettings(&settings.Realtime.Notifications); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
 	// Validate Weather settings
 	if err := validateWeatherSettings(&settings.Realtime.Weather); err != nil {
 		ve.Errors = append(ve.Errors, err.Error())
 	}
 
+	// Validate MQTT settings
+	if err := validateMQTTSettings(&settings.Realtime.MQTT); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate hub settings
+	if err := validateHubSettings(&settings.Realtime.Hub); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
 	// If there are any errors, return the ValidationError
 	if len(ve.Errors) > 0 {
 		return ve
@@ -171,6 +197,47 @@ func validateRealtimeSettings(settings *RealtimeSettings) error {
 	if settings.Interval < 0 {
 		return errors.New("Realtime interval must be non-negative")
 	}
+	if settings.SpeciesTracking.ReturningDays < 0 {
+		return errors.New("Species tracking returning days must be non-negative")
+	}
+	if err := validateSuppressorRules(settings.Suppressors); err != nil {
+		return err
+	}
+	if settings.Schedule.Enabled {
+		if _, _, err := ParseSunPeriod(settings.Schedule.From, settings.Schedule.To); err != nil {
+			return fmt.Errorf("Invalid detection schedule: %w", err)
+		}
+	}
+	for i, rule := range settings.Species.TimeRules {
+		if _, _, err := ParseSunPeriod(rule.From, rule.To); err != nil {
+			return fmt.Errorf("Invalid period in species time rule %d: %w", i+1, err)
+		}
+		if rule.Threshold < 0 || rule.Threshold > 1 {
+			return fmt.Errorf("Species time rule %d threshold must be between 0 and 1", i+1)
+		}
+	}
+	if settings.Rejected.Enabled {
+		if settings.Rejected.Retention < 1 {
+			return errors.New("Rejected detections retention must be at least 1 day")
+		}
+		if settings.Rejected.SampleRate < 0 || settings.Rejected.SampleRate > 1 {
+			return errors.New("Rejected detections sample rate must be between 0 and 1")
+		}
+		if settings.Rejected.MinConfidence < 0 || settings.Rejected.MinConfidence > 1 {
+			return errors.New("Rejected detections minimum confidence must be between 0 and 1")
+		}
+	}
+	if settings.EventQueue.Enabled {
+		if settings.EventQueue.Path == "" {
+			return errors.New("Event queue path must be set when the event queue is enabled")
+		}
+		if settings.EventQueue.MaxAttempts < 1 {
+			return errors.New("Event queue max attempts must be at least 1")
+		}
+		if settings.EventQueue.RetryDelay < 1 || settings.EventQueue.MaxRetryDelay < settings.EventQueue.RetryDelay {
+			return errors.New("Event queue retry delay must be at least 1 second and not exceed the max retry delay")
+		}
+	}
 	// Add more realtime settings validation as needed
 	return nil
 }
@@ -202,6 +269,11 @@ func validateBirdweatherSettings(settings *BirdweatherSettings) error {
 		if settings.LocationAccuracy < 0 {
 			return errors.New("Birdweather location accuracy must be non-negative")
 		}
+
+		// Check if buffer size is non-negative
+		if settings.BufferMaxEntries < 0 {
+			return errors.New("Birdweather buffer max entries must be non-negative")
+		}
 	}
 	return nil
 }
@@ -229,8 +301,11 @@ func validateAudioSettings(settings *AudioSettings) error {
 	// Validate audio export settings
 	if settings.Export.Enabled {
 		if settings.FfmpegPath == "" {
-			settings.Export.Type = "wav"
-			log.Printf("FFmpeg not available, using WAV format for audio export")
+			// WAV and FLAC are encoded natively, other formats require FFmpeg
+			if settings.Export.Type != "wav" && settings.Export.Type != "flac" {
+				settings.Export.Type = "flac"
+				log.Printf("FFmpeg not available, using FLAC format for audio export")
+			}
 		} else {
 			// Validate audio type and bitrate
 			switch settings.Export.Type {
@@ -251,6 +326,17 @@ func validateAudioSettings(settings *AudioSettings) error {
 				return fmt.Errorf("unsupported audio export type: %s", settings.Export.Type)
 			}
 		}
+
+		// Clips are read from the capture buffer, pre-roll and clip length must fit in it
+		if settings.Export.PreRoll < 0 || settings.Export.PreRoll > 10 {
+			return fmt.Errorf("audio export pre-roll must be between 0 and 10 seconds")
+		}
+		if settings.Export.PostRoll < 0 || settings.Export.PostRoll > 10 {
+			return fmt.Errorf("audio export post-roll must be between 0 and 10 seconds")
+		}
+		if settings.Export.MaxLength < CaptureLength || settings.Export.MaxLength > 45 {
+			return fmt.Errorf("audio export max length must be between %d and 45 seconds", CaptureLength)
+		}
 	}
 
 	return nil
@@ -276,6 +362,44 @@ func validateDashboardSettings(settings *Dashboard) error {
 		return fmt.Errorf("Dashboard SummaryLimit must be between 10 and 1000")
 	}
 
+	// Validate spectrogram settings
+	spectrogram := &settings.Spectrogram
+	if spectrogram.Renderer != "native" && spectrogram.Renderer != "sox" {
+		return fmt.Errorf("spectrogram renderer must be native or sox, got %s", spectrogram.Renderer)
+	}
+	if spectrogram.Format != "png" && spectrogram.Format != "webp" {
+		return fmt.Errorf("spectrogram format must be png or webp, got %s", spectrogram.Format)
+	}
+	if spectrogram.FFTSize < 64 || spectrogram.FFTSize > 16384 || spectrogram.FFTSize&(spectrogram.FFTSize-1) != 0 {
+		return fmt.Errorf("spectrogram FFT size must be a power of two between 64 and 16384, got %d", spectrogram.FFTSize)
+	}
+	if spectrogram.MinFreq < 0 || spectrogram.MaxFreq > SampleRate/2 || spectrogram.MinFreq >= spectrogram.MaxFreq {
+		return fmt.Errorf("spectrogram frequency range must be within 0-%d Hz, got %d-%d Hz", SampleRate/2, spectrogram.MinFreq, spectrogram.MaxFreq)
+	}
+	switch spectrogram.ColorMap {
+	case "inferno", "magma", "viridis", "grayscale":
+	default:
+		return fmt.Errorf("unknown spectrogram colour map: %s", spectrogram.ColorMap)
+	}
+	if spectrogram.DynamicRange <= 0 {
+		return fmt.Errorf("spectrogram dynamic range must be positive")
+	}
+
+	return nil
+}
+
+// validateDynamicThresholdSettings validates the dynamic threshold step schedule
+func validateDynamicThresholdSettings(settings *DynamicThresholdSettings) error {
+	previous := 0
+	for _, level := range settings.Levels {
+		if level.Count <= previous {
+			return fmt.Errorf("dynamic threshold level counts must be positive and increasing, got %d after %d", level.Count, previous)
+		}
+		if level.Multiplier <= 0 || level.Multiplier > 1 {
+			return fmt.Errorf("dynamic threshold level multiplier must be between 0 and 1, got %.2f", level.Multiplier)
+		}
+		previous = level.Count
+	}
 	return nil
 }
 
@@ -287,3 +411,104 @@ func validateWeatherSettings(settings *WeatherSettings) error {
 	}
 	return nil
 }
+
+// validateMQTTSettings validates MQTT settings
+func validateMQTTSettings(settings *MQTTSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.QoS < 0 || settings.QoS > 2 {
+		return fmt.Errorf("MQTT QoS must be 0, 1 or 2")
+	}
+	switch settings.PayloadMode {
+	case "":
+		settings.PayloadMode = MQTTPayloadFull
+	case MQTTPayloadFull, MQTTPayloadLight:
+	default:
+		return fmt.Errorf("invalid MQTT payload mode %q, must be full or light", settings.PayloadMode)
+	}
+	if strings.ContainsAny(settings.Topic, "+#") {
+		return fmt.Errorf("MQTT topic must not contain MQTT wildcards")
+	}
+
+	if settings.Commands.Enabled {
+		if settings.Commands.Token == "" {
+			return fmt.Errorf("MQTT command token is required when MQTT commands are enabled")
+		}
+		if strings.ContainsAny(settings.Commands.Topic+settings.Commands.ReplyTopic, "+#{}") {
+			return fmt.Errorf("MQTT command and reply topics must not contain wildcards or placeholders")
+		}
+	}
+
+	if settings.HomeAssistant.Enabled {
+		settings.HomeAssistant.DiscoveryPrefix = strings.Trim(settings.HomeAssistant.DiscoveryPrefix, "/")
+		if settings.HomeAssistant.DiscoveryPrefix == "" {
+			settings.HomeAssistant.DiscoveryPrefix = "homeassistant"
+		}
+		if strings.ContainsAny(settings.HomeAssistant.DiscoveryPrefix, "+#") {
+			return fmt.Errorf("Home Assistant discovery prefix must not contain MQTT wildcards")
+		}
+		if settings.Topic == "" {
+			return fmt.Errorf("MQTT topic is required for Home Assistant discovery")
+		}
+	}
+	return nil
+}
+
+// validateNotificationSettings validates notification target definitions
+func validateNotificationSettings(settings *NotificationSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for i, target := range settings.Targets {
+		if target.Name == "" {
+			return fmt.Errorf("notification target %d has no name", i+1)
+		}
+		if names[target.Name] {
+			return fmt.Errorf("duplicate notification target name %q", target.Name)
+		}
+		names[target.Name] = true
+		if target.URL == "" {
+			return fmt.Errorf("notification target %q has no URL", target.Name)
+		}
+		if target.Interval < 0 {
+			return fmt.Errorf("notification target %q interval must be non-negative", target.Name)
+		}
+	}
+	return nil
+}
+
+// validateHubSettings validates the multi-node hub settings
+func validateHubSettings(settings *HubSettings) error {
+	if settings.Server.Enabled {
+		names := make(map[string]bool)
+		tokens := make(map[string]bool)
+		for _, node := range settings.Server.Nodes {
+			if node.Name == "" || node.Token == "" {
+				return fmt.Errorf("hub nodes must have a name and a token")
+			}
+			if names[node.Name] {
+				return fmt.Errorf("duplicate hub node name %q", node.Name)
+			}
+			if tokens[node.Token] {
+				return fmt.Errorf("hub node %q reuses the token of another node", node.Name)
+			}
+			names[node.Name] = true
+			tokens[node.Token] = true
+		}
+	}
+
+	if settings.Client.Enabled {
+		u, err := url.Parse(settings.Client.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("hub URL must be an http or https URL")
+		}
+		if settings.Client.Token == "" {
+			return fmt.Errorf("hub token is required when pushing detections to a hub")
+		}
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -27,6 +28,8 @@ type Interface interface {
 	GetHourlyOccurrences(date, commonName string, minConfidenceNormalized float64) ([24]int, error)
 	SpeciesDetections(species, date, hour string, duration int, sortAscending bool, limit int, offset int) ([]Note, error)
 	GetLastDetections(numDetections int) ([]Note, error)
+	GetDetectionsPage(limit int, offset int) ([]Note, error)
+	CountDetections() (int64, error)
 	GetAllDetectedSpecies() ([]Note, error)
 	SearchNotes(query string, sortAscending bool, limit int, offset int) ([]Note, error)
 	GetNoteClipPath(noteID string) (string, error)
@@ -44,7 +47,16 @@ type Interface interface {
 	GetHourlyWeather(date string) ([]HourlyWeather, error)
 	LatestHourlyWeather() (*HourlyWeather, error)
 	GetHourlyDetections(date, hour string, duration int) ([]Note, error)
+	GetHourlyDetectionsPage(date, hour string, duration int, limit int, offset int) ([]Note, error)
+	CountHourlyDetections(date, hour string, duration int) (int64, error)
 	CountSpeciesDetections(species, date, hour string, duration int) (int64, error)
+	CountSpeciesDetectionsBetween(scientificName string, start, end time.Time) (int64, error)
+	GetNotesBetween(start, end time.Time) ([]Note, error)
+	GetSpeciesLastSeen(scientificName string, before time.Time) (time.Time, error)
+	NoteExists(sourceNode, source, scientificName string, timestamp time.Time) (bool, error)
+	GetNoteByClipName(clipName string) (Note, error)
+	GetNodeStatistics() ([]NodeStatistics, error)
+	ForNode(node string) Interface
 	CountSearchResults(query string) (int64, error)
 	Transaction(fc func(tx *gorm.DB) error) error
 	// Lock management methods
@@ -56,6 +68,20 @@ type Interface interface {
 	GetImageCache(scientificName string) (*ImageCache, error)
 	SaveImageCache(cache *ImageCache) error
 	GetAllImageCaches() ([]ImageCache, error)
+	// Dynamic threshold methods
+	GetDynamicThresholds() ([]DynamicThreshold, error)
+	SaveDynamicThresholds(thresholds []DynamicThreshold) error
+	DeleteDynamicThreshold(species string) error
+	// Range filter history methods
+	SaveRangeFilterScores(date string, scores []RangeFilterScore) error
+	GetRangeFilterScores(date string) ([]RangeFilterScore, error)
+	GetRangeFilterSnapshots() ([]RangeFilterSnapshot, error)
+	// Rejected detection methods
+	SaveRejectedDetection(rejected *RejectedDetection) error
+	GetRejectedDetections(reason string, limit, offset int) ([]RejectedDetection, error)
+	CountRejectedDetections(reason string) (int64, error)
+	DeleteRejectedDetectionsBefore(before time.Time) (int64, error)
+	PromoteRejectedDetection(id string) (Note, error)
 }
 
 // DataStore implements StoreInterface using a GORM database.
@@ -271,15 +297,20 @@ func (ds *DataStore) GetTopBirdsData(selectedDate string, minConfidenceNormalize
 	// Get the number of species to report from the dashboard settings
 	reportCount := conf.Setting().Realtime.Dashboard.SummaryLimit
 
+	start, end, err := dayRange(selectedDate)
+	if err != nil {
+		return nil, err
+	}
+
 	// First, get the count and common names
-	query := ds.DB.Table("notes").
+	query := whereTimeRange(ds.DB.Table("notes"), start, end).
 		Select("common_name, MAX(scientific_name) as scientific_name, COUNT(*) as count").
-		Where("date = ? AND confidence >= ?", selectedDate, minConfidenceNormalized).
+		Where("confidence >= ?", minConfidenceNormalized).
 		Group("common_name").
 		Order("count DESC").
 		Limit(reportCount)
 
-	err := query.Scan(&results).Error
+	err = query.Scan(&results).Error
 	return results, err
 }
 
@@ -328,44 +359,32 @@ func (ds *DataStore) GetClipsQualifyingForRemoval(minHours, minClips int) ([]Cli
 	return results, nil
 }
 
-// GetHourFormat returns the database-specific SQL fragment for formatting a time column as hour.
-func (ds *DataStore) GetHourFormat() string {
-	// Handling for supported databases: SQLite and MySQL
-	switch ds.DB.Dialector.Name() {
-	case "sqlite":
-		return "strftime('%H', time)"
-	case "mysql":
-		return "TIME_FORMAT(time, '%H')"
-	default:
-		// Log or handle unsupported database types
-		return ""
-	}
-}
-
 // GetHourlyOccurrences retrieves hourly occurrences of a specified bird species.
+// Detections are bucketed by their local hour, so on DST transition days a repeated
+// hour is counted in the same bucket and a skipped hour stays empty.
 func (ds *DataStore) GetHourlyOccurrences(date, commonName string, minConfidenceNormalized float64) ([24]int, error) {
 	var hourlyCounts [24]int
-	var results []struct {
-		Hour  int
-		Count int
-	}
-
-	hourFormat := ds.GetHourFormat()
 
-	err := ds.DB.Model(&Note{}).
-		Select(fmt.Sprintf("%s as hour, COUNT(*) as count", hourFormat)).
-		Where("date = ? AND common_name = ? AND confidence >= ?", date, commonName, minConfidenceNormalized).
-		Group(hourFormat).
-		Scan(&results).Error
+	start, end, err := dayRange(date)
+	if err != nil {
+		return hourlyCounts, err
+	}
+	loc, err := conf.GetLocalTimezone()
+	if err != nil {
+		return hourlyCounts, fmt.Errorf("failed to get local timezone: %w", err)
+	}
 
+	var notes []Note
+	err = whereTimeRange(ds.DB.Model(&Note{}), start, end).
+		Select("timestamp").
+		Where("common_name = ? AND confidence >= ?", commonName, minConfidenceNormalized).
+		Find(&notes).Error
 	if err != nil {
 		return hourlyCounts, err
 	}
 
-	for _, result := range results {
-		if result.Hour >= 0 && result.Hour < 24 {
-			hourlyCounts[result.Hour] = result.Count
-		}
+	for i := range notes {
+		hourlyCounts[notes[i].Timestamp.In(loc).Hour()]++
 	}
 
 	return hourlyCounts, nil
@@ -377,18 +396,20 @@ func (ds *DataStore) SpeciesDetections(species, date, hour string, duration int,
 
 	query := ds.DB.Preload("Review").Preload("Lock").Preload("Comments", func(db *gorm.DB) *gorm.DB {
 		return db.Order("created_at DESC") // Order comments by creation time, newest first
-	}).Where("common_name = ? AND date = ?", species, date)
-	if hour != "" {
-		startTime, endTime := getHourRange(hour, duration)
-		query = query.Where("time >= ? AND time < ?", startTime, endTime)
+	}).Where("common_name = ?", species)
+
+	start, end, err := detectionRange(date, hour, duration)
+	if err != nil {
+		return nil, err
 	}
+	query = whereTimeRange(query, start, end)
 
 	query = query.Order("id " + sortOrder).
 		Limit(limit).
 		Offset(offset)
 
 	var detections []Note
-	err := query.Find(&detections).Error
+	err = query.Find(&detections).Error
 
 	// Populate virtual fields
 	for i := range detections {
@@ -427,6 +448,40 @@ func (ds *DataStore) GetLastDetections(numDetections int) ([]Note, error) {
 	return notes, nil
 }
 
+// GetDetectionsPage retrieves a page of detections, most recent first.
+func (ds *DataStore) GetDetectionsPage(limit, offset int) ([]Note, error) {
+	var notes []Note
+
+	err := ds.DB.Preload("Review").Preload("Lock").Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC") // Order comments by creation time, newest first
+	}).Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notes).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting detections: %w", err)
+	}
+
+	// Populate virtual fields
+	for i := range notes {
+		if notes[i].Review != nil {
+			notes[i].Verified = notes[i].Review.Verified
+		}
+		notes[i].Locked = notes[i].Lock != nil
+	}
+
+	return notes, nil
+}
+
+// CountDetections counts the number of detections.
+func (ds *DataStore) CountDetections() (int64, error) {
+	var count int64
+	if err := ds.DB.Model(&Note{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error counting detections: %w", err)
+	}
+	return count, nil
+}
+
 // GetLastDetections retrieves all detected species.
 func (ds *DataStore) GetAllDetectedSpecies() ([]Note, error) {
 	var results []Note
@@ -542,25 +597,72 @@ func (ds *DataStore) LatestHourlyWeather() (*HourlyWeather, error) {
 func (ds *DataStore) GetHourlyDetections(date, hour string, duration int) ([]Note, error) {
 	var detections []Note
 
-	startTime, endTime := getHourRange(hour, duration)
-	err := ds.DB.Where("date = ? AND time >= ? AND time < ?", date, startTime, endTime).
-		Order("time ASC").
+	start, end, err := hourRange(date, hour, duration)
+	if err != nil {
+		return nil, err
+	}
+	err = whereTimeRange(ds.DB, start, end).
+		Order("timestamp ASC").
 		Find(&detections).Error
 
 	return detections, err
 }
 
+// GetHourlyDetectionsPage retrieves a page of bird detections for a specific date and hour,
+// in chronological order.
+func (ds *DataStore) GetHourlyDetectionsPage(date, hour string, duration, limit, offset int) ([]Note, error) {
+	var detections []Note
+
+	start, end, err := hourRange(date, hour, duration)
+	if err != nil {
+		return nil, err
+	}
+	query := ds.DB.Preload("Review").Preload("Lock").Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC") // Order comments by creation time, newest first
+	})
+	err = whereTimeRange(query, start, end).
+		Order("timestamp ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&detections).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting hourly detections: %w", err)
+	}
+
+	// Populate virtual fields
+	for i := range detections {
+		if detections[i].Review != nil {
+			detections[i].Verified = detections[i].Review.Verified
+		}
+		detections[i].Locked = detections[i].Lock != nil
+	}
+
+	return detections, nil
+}
+
+// CountHourlyDetections counts the number of detections for a specific date and hour.
+func (ds *DataStore) CountHourlyDetections(date, hour string, duration int) (int64, error) {
+	var count int64
+	start, end, err := hourRange(date, hour, duration)
+	if err != nil {
+		return 0, err
+	}
+	if err := whereTimeRange(ds.DB.Model(&Note{}), start, end).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error counting hourly detections: %w", err)
+	}
+	return count, nil
+}
+
 // CountSpeciesDetections counts the number of detections for a specific species, date, and hour.
 func (ds *DataStore) CountSpeciesDetections(species, date, hour string, duration int) (int64, error) {
 	var count int64
-	query := ds.DB.Model(&Note{}).Where("common_name = ? AND date = ?", species, date)
-
-	if hour != "" {
-		startTime, endTime := getHourRange(hour, duration)
-		query = query.Where("time >= ? AND time < ?", startTime, endTime)
+	start, end, err := detectionRange(date, hour, duration)
+	if err != nil {
+		return 0, err
 	}
+	query := whereTimeRange(ds.DB.Model(&Note{}), start, end).Where("common_name = ?", species)
 
-	err := query.Count(&count).Error
+	err = query.Count(&count).Error
 	if err != nil {
 		return 0, fmt.Errorf("error counting species detections: %w", err)
 	}
@@ -568,6 +670,117 @@ func (ds *DataStore) CountSpeciesDetections(species, date, hour string, duration
 	return count, nil
 }
 
+// CountSpeciesDetectionsBetween counts the detections of a species, by scientific name, with a
+// timestamp in the half-open range [start, end). A zero start counts from the first detection.
+func (ds *DataStore) CountSpeciesDetectionsBetween(scientificName string, start, end time.Time) (int64, error) {
+	var count int64
+	query := ds.DB.Model(&Note{}).Where("scientific_name = ?", scientificName)
+	if start.IsZero() {
+		query = query.Where("notes.timestamp < ?", end.Unix())
+	} else {
+		query = whereTimeRange(query, start, end)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error counting species detections: %w", err)
+	}
+	return count, nil
+}
+
+// GetNotesBetween returns the detections with a timestamp in the half-open range [start, end),
+// oldest first.
+func (ds *DataStore) GetNotesBetween(start, end time.Time) ([]Note, error) {
+	var notes []Note
+	if err := whereTimeRange(ds.DB, start, end).Order("timestamp ASC, id ASC").Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("error getting detections between %s and %s: %w", start, end, err)
+	}
+	return notes, nil
+}
+
+// GetSpeciesLastSeen returns the timestamp of the latest detection of a species, by scientific name,
+// before the given time. A zero time is returned if the species has not been detected.
+func (ds *DataStore) GetSpeciesLastSeen(scientificName string, before time.Time) (time.Time, error) {
+	var notes []Note
+	err := ds.DB.Model(&Note{}).Select("timestamp").
+		Where("scientific_name = ? AND notes.timestamp < ?", scientificName, before.Unix()).
+		Order("notes.timestamp DESC").Limit(1).
+		Find(&notes).Error
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error getting last detection of %s: %w", scientificName, err)
+	}
+	if len(notes) == 0 {
+		return time.Time{}, nil
+	}
+	return notes[0].Timestamp, nil
+}
+
+// NoteExists reports whether a node has a detection of a species from an audio source at the
+// given time, used to skip detections that a remote node pushes more than once.
+func (ds *DataStore) NoteExists(sourceNode, source, scientificName string, timestamp time.Time) (bool, error) {
+	var count int64
+	err := ds.DB.Model(&Note{}).
+		Where("source_node = ? AND source = ? AND scientific_name = ? AND notes.timestamp = ?",
+			sourceNode, source, scientificName, timestamp.Unix()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("error checking for existing detection: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetNoteByClipName retrieves the note of an audio clip with its results.
+func (ds *DataStore) GetNoteByClipName(clipName string) (Note, error) {
+	var note Note
+	if err := ds.DB.Preload("Results").Where("clip_name = ?", clipName).First(&note).Error; err != nil {
+		return Note{}, fmt.Errorf("error getting note of clip %s: %w", clipName, err)
+	}
+	return note, nil
+}
+
+// NodeStatistics summarizes the detections made by a node.
+type NodeStatistics struct {
+	Node       string
+	Detections int64
+	Species    int64
+	LastSeen   time.Time
+}
+
+// GetNodeStatistics returns the detection count, species count and latest detection time of
+// each node, ordered by node name.
+func (ds *DataStore) GetNodeStatistics() ([]NodeStatistics, error) {
+	var rows []struct {
+		Node       string
+		Detections int64
+		Species    int64
+		LastSeen   int64
+	}
+	err := ds.DB.Model(&Note{}).
+		Select("source_node AS node, COUNT(*) AS detections, COUNT(DISTINCT scientific_name) AS species, MAX(notes.timestamp) AS last_seen").
+		Group("source_node").
+		Order("source_node").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting node statistics: %w", err)
+	}
+
+	stats := make([]NodeStatistics, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, NodeStatistics{
+			Node:       row.Node,
+			Detections: row.Detections,
+			Species:    row.Species,
+			LastSeen:   time.Unix(row.LastSeen, 0).UTC(),
+		})
+	}
+	return stats, nil
+}
+
+// nodeDB returns a session of the database that only sees the notes of a node. The session
+// must only be used for queries of the notes table.
+func (ds *DataStore) nodeDB(node string) *gorm.DB {
+	return ds.DB.Where("notes.source_node = ?", node).Session(&gorm.Session{})
+}
+
 // CountSearchResults counts the number of search results for a given query.
 func (ds *DataStore) CountSearchResults(query string) (int64, error) {
 	var count int64
@@ -712,13 +925,13 @@ func (ds *DataStore) UpdateNoteComment(commentID, entry string) error {
 	return nil
 }
 
-// getHourRange returns the start and end times for a given hour and duration.
-func getHourRange(hour string, duration int) (startTime, endTime string) {
-	startHour, _ := strconv.Atoi(hour)
-	endHour := (startHour + duration) % 24
-	startTime = fmt.Sprintf("%02d:00:00", startHour)
-	endTime = fmt.Sprintf("%02d:00:00", endHour)
-	return startTime, endTime
+// detectionRange returns the UTC range of the given date, narrowed to duration hours
+// starting at hour when hour is set.
+func detectionRange(date, hour string, duration int) (start, end time.Time, err error) {
+	if hour == "" {
+		return dayRange(date)
+	}
+	return hourRange(date, hour, duration)
 }
 
 // sortOrderAscendingString returns "ASC" or "DESC" based on the boolean input.
@@ -913,3 +1126,140 @@ func (ds *DataStore) GetAllImageCaches() ([]ImageCache, error) {
 	}
 	return caches, nil
 }
+
+// GetDynamicThresholds retrieves the saved dynamic threshold state of all species
+func (ds *DataStore) GetDynamicThresholds() ([]DynamicThreshold, error) {
+	var thresholds []DynamicThreshold
+	if err := ds.DB.Find(&thresholds).Error; err != nil {
+		return nil, fmt.Errorf("error getting dynamic thresholds: %w", err)
+	}
+	return thresholds, nil
+}
+
+// SaveDynamicThresholds saves or updates the dynamic threshold state of species
+func (ds *DataStore) SaveDynamicThresholds(thresholds []DynamicThreshold) error {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	if err := ds.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&thresholds).Error; err != nil {
+		return fmt.Errorf("failed to save dynamic thresholds: %w", err)
+	}
+	return nil
+}
+
+// DeleteDynamicThreshold deletes the saved dynamic threshold state of a species
+func (ds *DataStore) DeleteDynamicThreshold(species string) error {
+	if err := ds.DB.Where("species = ?", species).Delete(&DynamicThreshold{}).Error; err != nil {
+		return fmt.Errorf("failed to delete dynamic threshold of %s: %w", species, err)
+	}
+	return nil
+}
+
+// SaveRangeFilterScores replaces the range filter scores saved for a date
+func (ds *DataStore) SaveRangeFilterScores(date string, scores []RangeFilterScore) error {
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("date = ?", date).Delete(&RangeFilterScore{}).Error; err != nil {
+			return err
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(scores, 100).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save range filter scores for %s: %w", date, err)
+	}
+	return nil
+}
+
+// GetRangeFilterScores retrieves the range filter scores saved for a date, highest score first
+func (ds *DataStore) GetRangeFilterScores(date string) ([]RangeFilterScore, error) {
+	var scores []RangeFilterScore
+	if err := ds.DB.Where("date = ?", date).Order("score DESC, label ASC").Find(&scores).Error; err != nil {
+		return nil, fmt.Errorf("error getting range filter scores for %s: %w", date, err)
+	}
+	return scores, nil
+}
+
+// GetRangeFilterSnapshots returns the dates with saved range filter scores, newest first
+func (ds *DataStore) GetRangeFilterSnapshots() ([]RangeFilterSnapshot, error) {
+	var snapshots []RangeFilterSnapshot
+	err := ds.DB.Model(&RangeFilterScore{}).
+		Select("date, week, COUNT(*) AS species").
+		Group("date, week").
+		Order("date DESC").
+		Scan(&snapshots).Error
+	if err != nil {
+		return nil, fmt.Errorf("error getting range filter history: %w", err)
+	}
+	return snapshots, nil
+}
+
+// SaveRejectedDetection stores a detection discarded by a filter
+func (ds *DataStore) SaveRejectedDetection(rejected *RejectedDetection) error {
+	if err := ds.DB.Create(rejected).Error; err != nil {
+		return fmt.Errorf("error saving rejected detection: %w", err)
+	}
+	return nil
+}
+
+// GetRejectedDetections retrieves rejected detections, newest first. An empty reason matches all reasons.
+func (ds *DataStore) GetRejectedDetections(reason string, limit, offset int) ([]RejectedDetection, error) {
+	var rejected []RejectedDetection
+	query := ds.DB.Order("timestamp DESC, id DESC").Limit(limit).Offset(offset)
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if err := query.Find(&rejected).Error; err != nil {
+		return nil, fmt.Errorf("error getting rejected detections: %w", err)
+	}
+	return rejected, nil
+}
+
+// CountRejectedDetections counts rejected detections. An empty reason matches all reasons.
+func (ds *DataStore) CountRejectedDetections(reason string) (int64, error) {
+	var count int64
+	query := ds.DB.Model(&RejectedDetection{})
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("error counting rejected detections: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteRejectedDetectionsBefore deletes rejected detections older than before and returns the number deleted
+func (ds *DataStore) DeleteRejectedDetectionsBefore(before time.Time) (int64, error) {
+	result := ds.DB.Where("timestamp < ?", before.Unix()).Delete(&RejectedDetection{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("error deleting rejected detections: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ErrAlreadyPromoted is returned when promoting a rejected detection that already has a note
+var ErrAlreadyPromoted = errors.New("rejected detection was already promoted")
+
+// PromoteRejectedDetection saves a rejected detection as a note and links the note to it
+func (ds *DataStore) PromoteRejectedDetection(id string) (Note, error) {
+	var note Note
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		var rejected RejectedDetection
+		if err := tx.First(&rejected, id).Error; err != nil {
+			return err
+		}
+		if rejected.NoteID != 0 {
+			return fmt.Errorf("rejected detection %s has note %d: %w", id, rejected.NoteID, ErrAlreadyPromoted)
+		}
+		note = rejected.Note()
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		return tx.Model(&rejected).Update("note_id", note.ID).Error
+	})
+	if err != nil {
+		return Note{}, fmt.Errorf("error promoting rejected detection: %w", err)
+	}
+	return note, nil
+}
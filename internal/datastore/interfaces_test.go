@@ -1,6 +1,8 @@
 package datastore
 
 import (
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -76,3 +78,222 @@ func TestGetClipsQualifyingForRemoval(t *testing.T) {
 		t.Errorf("Expected ScientificName to be 'Cool bird', got '%s'", clipsForRemoval[0].ScientificName)
 	}
 }
+
+// TestNodeView verifies that node views only see the notes of their node and that node
+// statistics and duplicate checks use the source node.
+func TestNodeView(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.Dashboard.SummaryLimit = 10
+	dataStore := createDatabase(t, settings)
+
+	now := time.Now().Truncate(time.Second)
+	notes := []Note{
+		{SourceNode: "hub", Source: "garden", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.9, Timestamp: now},
+		{SourceNode: "backyard", Source: "garden", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.8, Timestamp: now},
+		{SourceNode: "backyard", Source: "garden", ScientificName: "Pica pica", CommonName: "Magpie", Confidence: 0.7, Timestamp: now.Add(-time.Minute)},
+	}
+	for i := range notes {
+		if err := dataStore.Save(&notes[i], []Results{}); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	last, err := dataStore.ForNode("backyard").GetLastDetections(10)
+	if err != nil {
+		t.Fatalf("GetLastDetections failed: %v", err)
+	}
+	if len(last) != 2 {
+		t.Fatalf("expected 2 detections of backyard, got %d", len(last))
+	}
+	for i := range last {
+		if last[i].SourceNode != "backyard" {
+			t.Errorf("node view returned a detection of %s", last[i].SourceNode)
+		}
+	}
+
+	// The view must not restrict queries made through the store afterwards
+	all, err := dataStore.GetLastDetections(10)
+	if err != nil || len(all) != 3 {
+		t.Errorf("expected 3 detections in total, got %d (%v)", len(all), err)
+	}
+
+	exists, err := dataStore.NoteExists("backyard", "garden", "Pica pica", now.Add(-time.Minute))
+	if err != nil || !exists {
+		t.Errorf("expected existing detection to be found, got %v (%v)", exists, err)
+	}
+	exists, err = dataStore.NoteExists("hub", "garden", "Pica pica", now.Add(-time.Minute))
+	if err != nil || exists {
+		t.Errorf("expected detection of another node not to be found, got %v (%v)", exists, err)
+	}
+
+	stats, err := dataStore.GetNodeStatistics()
+	if err != nil {
+		t.Fatalf("GetNodeStatistics failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Node != "backyard" || stats[0].Detections != 2 || stats[0].Species != 2 || !stats[0].LastSeen.Equal(now) {
+		t.Errorf("unexpected node statistics: %+v", stats)
+	}
+}
+
+// TestDetectionPages verifies that detection pages are limited in the database and that the
+// counts cover all matching detections rather than the returned page.
+func TestDetectionPages(t *testing.T) {
+	dataStore := createDatabase(t, &conf.Settings{})
+	loc, err := conf.GetLocalTimezone()
+	if err != nil {
+		t.Fatalf("GetLocalTimezone failed: %v", err)
+	}
+
+	// Five detections at 10:00 and two at 12:00
+	for i := 0; i < 7; i++ {
+		ts := time.Date(2024, 5, 1, 10, i, 0, 0, loc)
+		if i >= 5 {
+			ts = ts.Add(2 * time.Hour)
+		}
+		note := &Note{CommonName: "Great Tit", ScientificName: "Parus major", Confidence: 0.9, Timestamp: ts}
+		if err := dataStore.Save(note, nil); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	page, err := dataStore.GetDetectionsPage(3, 5)
+	if err != nil {
+		t.Fatalf("GetDetectionsPage failed: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != 2 || page[1].ID != 1 {
+		t.Errorf("expected detections 2 and 1 on the last page, got %v", page)
+	}
+	if total, err := dataStore.CountDetections(); err != nil || total != 7 {
+		t.Errorf("expected 7 detections in total, got %d (%v)", total, err)
+	}
+
+	hourly, err := dataStore.GetHourlyDetectionsPage("2024-05-01", "10", 1, 2, 1)
+	if err != nil {
+		t.Fatalf("GetHourlyDetectionsPage failed: %v", err)
+	}
+	if len(hourly) != 2 || hourly[0].Timestamp.Minute() != 1 || hourly[1].Timestamp.Minute() != 2 {
+		t.Errorf("expected the second and third detection of the hour, got %v", hourly)
+	}
+	if total, err := dataStore.CountHourlyDetections("2024-05-01", "10", 1); err != nil || total != 5 {
+		t.Errorf("expected 5 detections in the hour, got %d (%v)", total, err)
+	}
+	if total, err := dataStore.CountHourlyDetections("2024-05-01", "10", 3); err != nil || total != 7 {
+		t.Errorf("expected 7 detections in three hours, got %d (%v)", total, err)
+	}
+}
+
+func TestDynamicThresholds(t *testing.T) {
+	dataStore := createDatabase(t, &conf.Settings{})
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	thresholds := []DynamicThreshold{
+		{Species: "great tit", Level: 1, CurrentValue: 0.6, HighConfCount: 1, ExpiresAt: expires},
+		{Species: "eurasian magpie", Level: 0, CurrentValue: 0.8, ExpiresAt: expires},
+	}
+	if err := dataStore.SaveDynamicThresholds(thresholds); err != nil {
+		t.Fatalf("SaveDynamicThresholds failed: %v", err)
+	}
+
+	// Saving again updates the existing state
+	thresholds[0].Level, thresholds[0].CurrentValue, thresholds[0].HighConfCount = 2, 0.4, 2
+	if err := dataStore.SaveDynamicThresholds(thresholds[:1]); err != nil {
+		t.Fatalf("SaveDynamicThresholds failed: %v", err)
+	}
+	if err := dataStore.DeleteDynamicThreshold("eurasian magpie"); err != nil {
+		t.Fatalf("DeleteDynamicThreshold failed: %v", err)
+	}
+
+	saved, err := dataStore.GetDynamicThresholds()
+	if err != nil {
+		t.Fatalf("GetDynamicThresholds failed: %v", err)
+	}
+	if len(saved) != 1 || saved[0].Species != "great tit" || saved[0].Level != 2 || saved[0].CurrentValue != 0.4 || !saved[0].ExpiresAt.Equal(expires) {
+		t.Errorf("unexpected dynamic thresholds: %+v", saved)
+	}
+}
+
+func TestRangeFilterScores(t *testing.T) {
+	dataStore := createDatabase(t, &conf.Settings{})
+
+	week19 := []RangeFilterScore{
+		{Date: "2024-05-12", Week: 19, Label: "Parus major_Great Tit", Score: 0.9, Reason: "score"},
+		{Date: "2024-05-12", Week: 19, Label: "Pica pica_Eurasian Magpie", Score: 1, Reason: "include"},
+	}
+	if err := dataStore.SaveRangeFilterScores("2024-05-12", week19); err != nil {
+		t.Fatalf("SaveRangeFilterScores failed: %v", err)
+	}
+	week20 := []RangeFilterScore{
+		{Date: "2024-05-15", Week: 20, Label: "Parus major_Great Tit", Score: 0.8, Reason: "score"},
+	}
+	if err := dataStore.SaveRangeFilterScores("2024-05-15", week20); err != nil {
+		t.Fatalf("SaveRangeFilterScores failed: %v", err)
+	}
+
+	// Saving the same date again replaces its scores
+	week20[0].Score = 0.85
+	if err := dataStore.SaveRangeFilterScores("2024-05-15", week20); err != nil {
+		t.Fatalf("SaveRangeFilterScores failed: %v", err)
+	}
+
+	scores, err := dataStore.GetRangeFilterScores("2024-05-12")
+	if err != nil {
+		t.Fatalf("GetRangeFilterScores failed: %v", err)
+	}
+	if len(scores) != 2 || scores[0].Label != "Pica pica_Eurasian Magpie" || scores[0].Reason != "include" {
+		t.Errorf("unexpected range filter scores: %+v", scores)
+	}
+
+	snapshots, err := dataStore.GetRangeFilterSnapshots()
+	if err != nil {
+		t.Fatalf("GetRangeFilterSnapshots failed: %v", err)
+	}
+	want := []RangeFilterSnapshot{{Date: "2024-05-15", Week: 20, Species: 1}, {Date: "2024-05-12", Week: 19, Species: 2}}
+	if len(snapshots) != len(want) || snapshots[0] != want[0] || snapshots[1] != want[1] {
+		t.Errorf("GetRangeFilterSnapshots() = %+v, want %+v", snapshots, want)
+	}
+}
+
+func TestRejectedDetections(t *testing.T) {
+	dataStore := createDatabase(t, &conf.Settings{})
+
+	now := time.Now().Truncate(time.Second)
+	note := Note{Date: now.Format("2006-01-02"), Time: now.Format("15:04:05"), Timestamp: now.UTC(),
+		ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.6, Threshold: 0.8}
+	for i, reason := range []string{RejectReasonThreshold, RejectReasonRangeFilter, RejectReasonThreshold} {
+		rejected := NewRejectedDetection(&note, reason, 1)
+		rejected.Timestamp = now.Add(time.Duration(i-2) * 24 * time.Hour).UTC()
+		if err := dataStore.SaveRejectedDetection(&rejected); err != nil {
+			t.Fatalf("SaveRejectedDetection failed: %v", err)
+		}
+	}
+
+	rejected, err := dataStore.GetRejectedDetections(RejectReasonThreshold, 10, 0)
+	if err != nil {
+		t.Fatalf("GetRejectedDetections failed: %v", err)
+	}
+	if len(rejected) != 2 || !rejected[0].Timestamp.After(rejected[1].Timestamp) {
+		t.Errorf("expected two threshold rejections, newest first: %+v", rejected)
+	}
+	if count, err := dataStore.CountRejectedDetections(""); err != nil || count != 3 {
+		t.Errorf("CountRejectedDetections() = %d, %v, want 3", count, err)
+	}
+
+	// Promoting creates a note and can be done once
+	id := strconv.FormatUint(uint64(rejected[0].ID), 10)
+	promoted, err := dataStore.PromoteRejectedDetection(id)
+	if err != nil {
+		t.Fatalf("PromoteRejectedDetection failed: %v", err)
+	}
+	if promoted.ID == 0 || promoted.ScientificName != "Parus major" || promoted.Confidence != 0.6 {
+		t.Errorf("unexpected promoted note: %+v", promoted)
+	}
+	if _, err := dataStore.PromoteRejectedDetection(id); !errors.Is(err, ErrAlreadyPromoted) {
+		t.Error("expected an error when promoting a detection twice")
+	}
+
+	// Retention removes the detections saved before the cut-off
+	deleted, err := dataStore.DeleteRejectedDetectionsBefore(now.Add(-12 * time.Hour))
+	if err != nil || deleted != 2 {
+		t.Errorf("DeleteRejectedDetectionsBefore() = %d, %v, want 2", deleted, err)
+	}
+}
@@ -22,9 +22,12 @@ func createGormLogger() logger.Interface {
 	)
 }
 
+// models are the models whose tables are kept in sync by AutoMigrate.
+var models = []interface{}{&Note{}, &Results{}, &NoteReview{}, &NoteComment{}, &DailyEvents{}, &HourlyWeather{}, &NoteLock{}, &ImageCache{}, &DynamicThreshold{}, &RangeFilterScore{}, &RejectedDetection{}}
+
 // performAutoMigration automates database migrations with error handling.
-func performAutoMigration(db *gorm.DB, debug bool, dbType, connectionInfo string) error {
-	if err := db.AutoMigrate(&Note{}, &Results{}, &NoteReview{}, &NoteComment{}, &DailyEvents{}, &HourlyWeather{}, &NoteLock{}, &ImageCache{}); err != nil {
+func performAutoMigration(db *gorm.DB, debug bool, dbType string) error {
+	if err := db.AutoMigrate(models...); err != nil {
 		return fmt.Errorf("failed to auto-migrate %s database: %w", dbType, err)
 	}
 
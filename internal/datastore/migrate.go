@@ -0,0 +1,201 @@
+// migrate.go: versioned schema and data migrations for the datastore
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaVersion records a migration that has been applied to the database.
+type SchemaVersion struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName overrides the GORM table name of SchemaVersion.
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// Migration is a single versioned up-migration. Up runs inside a transaction,
+// if it returns an error the transaction is rolled back and the version is not recorded.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// MigrationStatus describes whether a registered migration has been applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Maintainer is implemented by datastores that support schema maintenance operations.
+type Maintainer interface {
+	Interface
+	Connect() error // Connect opens the database connection without running migrations
+	Migrate() error // Migrate applies pending migrations, taking a backup first when needed
+	MigrationStatus() ([]MigrationStatus, error)
+	Export(w io.Writer) (*ArchiveManifest, error)
+	Import(r io.ReaderAt, size int64) ([]TableImportStats, error)
+}
+
+// migrations is the ordered registry of all known migrations.
+var migrations []Migration
+
+// registerMigration adds a migration to the registry. Versions must be unique.
+func registerMigration(m Migration) {
+	for _, existing := range migrations {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("datastore: duplicate migration version %d", m.Version))
+		}
+	}
+	migrations = append(migrations, m)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
+
+func init() {
+	// Version 1 marks the schema as it was before versioned migrations were introduced.
+	// The tables themselves are created by GORM AutoMigrate.
+	registerMigration(Migration{
+		Version: 1,
+		Name:    "baseline schema",
+		Up:      func(tx *gorm.DB) error { return nil },
+	})
+	registerMigration(Migration{
+		Version: 2,
+		Name:    "backfill note timestamps",
+		Up:      backfillNoteTimestamps,
+	})
+}
+
+// LatestSchemaVersion returns the highest registered migration version.
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// appliedVersions returns the applied migrations keyed by version.
+func appliedVersions(db *gorm.DB) (map[int]SchemaVersion, error) {
+	applied := make(map[int]SchemaVersion)
+	if !db.Migrator().HasTable(&SchemaVersion{}) {
+		return applied, nil
+	}
+
+	var rows []SchemaVersion
+	if err := db.Order("version ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// pendingMigrations returns the registered migrations that have not been applied, in order.
+func pendingMigrations(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// hasExistingData reports whether the database already contains detection data worth backing up.
+func hasExistingData(db *gorm.DB) bool {
+	if !db.Migrator().HasTable(&Note{}) {
+		return false
+	}
+	var count int64
+	if err := db.Model(&Note{}).Limit(1).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// runMigrations brings the database schema up to date. If there are pending migrations
+// and the database already holds data, backup is called before anything is changed.
+func runMigrations(db *gorm.DB, debug bool, dbType string, backup func() error) error {
+	pending, err := pendingMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 && backup != nil && hasExistingData(db) {
+		if err := backup(); err != nil {
+			return fmt.Errorf("pre-migration backup of %s database failed: %w", dbType, err)
+		}
+	}
+
+	// Keep table structure in sync with the models
+	if err := db.AutoMigrate(&SchemaVersion{}); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+	if err := performAutoMigration(db, debug, dbType); err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		start := time.Now()
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaVersion{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("Applied %s database migration %d: %s (%v)", dbType, m.Version, m.Name, time.Since(start))
+	}
+
+	return nil
+}
+
+// MigrationStatus returns the state of every registered migration.
+func (ds *DataStore) MigrationStatus() ([]MigrationStatus, error) {
+	if ds.DB == nil {
+		return nil, errors.New("database connection is not initialized")
+	}
+
+	applied, err := appliedVersions(ds.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = row.AppliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
@@ -0,0 +1,79 @@
+package datastore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"gorm.io/gorm"
+)
+
+// TestOpenRecordsSchemaVersion verifies that opening a fresh database applies and records all migrations.
+func TestOpenRecordsSchemaVersion(t *testing.T) {
+	settings := &conf.Settings{}
+	dataStore := createDatabase(t, settings)
+
+	status, err := dataStore.(Maintainer).MigrationStatus()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	if len(status) != len(migrations) {
+		t.Fatalf("Expected %d migrations, got %d", len(migrations), len(status))
+	}
+	for _, s := range status {
+		if !s.Applied {
+			t.Errorf("Expected migration %d (%s) to be applied", s.Version, s.Name)
+		}
+	}
+}
+
+// TestMigrateBacksUpBeforePendingMigration verifies that a pending migration is applied once
+// and that the SQLite database file is backed up before it runs.
+func TestMigrateBacksUpBeforePendingMigration(t *testing.T) {
+	settings := &conf.Settings{}
+	dataStore := createDatabase(t, settings)
+	store := dataStore.(*SQLiteStore)
+
+	if err := store.Save(&Note{ScientificName: "Turdus merula", Date: "2024-01-01", BeginTime: time.Now()}, nil); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	// Register a temporary data migration and remove it when the test ends
+	original := migrations
+	t.Cleanup(func() { migrations = original })
+	runs := 0
+	migrations = append(append([]Migration{}, original...), Migration{
+		Version: LatestSchemaVersion() + 1,
+		Name:    "test data migration",
+		Up: func(tx *gorm.DB) error {
+			runs++
+			return tx.Model(&Note{}).Where("date = ?", "2024-01-01").Update("common_name", "Blackbird").Error
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := store.Migrate(); err != nil {
+			t.Fatalf("Migrate failed: %v", err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("Expected migration to run once, ran %d times", runs)
+	}
+
+	var note Note
+	if err := store.DB.First(&note).Error; err != nil {
+		t.Fatalf("Failed to read note: %v", err)
+	}
+	if note.CommonName != "Blackbird" {
+		t.Errorf("Expected migrated common name 'Blackbird', got '%s'", note.CommonName)
+	}
+
+	backups, err := filepath.Glob(settings.Output.SQLite.Path + ".backup_*")
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Errorf("Expected one backup file, got %d", len(backups))
+	}
+}
@@ -7,10 +7,13 @@ import "time"
 type Note struct {
 	ID         uint `gorm:"primaryKey"`
 	SourceNode string
-	Date       string `gorm:"index:idx_notes_date;index:idx_notes_date_commonname_confidence"`
-	Time       string `gorm:"index:idx_notes_time"`
+	Date       string    `gorm:"index:idx_notes_date;index:idx_notes_date_commonname_confidence"`
+	Time       string    `gorm:"index:idx_notes_time"`
+	Timestamp  time.Time `gorm:"serializer:unixutc;type:bigint;index:idx_notes_timestamp"` // Detection time in UTC, stored as Unix seconds
+	TimeZone   string    `gorm:"size:64"`                                                  // IANA time zone of the node, e.g. "Europe/Helsinki"
 	//InputFile      string
 	Source         string
+	Channel        int // Channel of a split multichannel source, numbered from 1, 0 for mono sources
 	BeginTime      time.Time
 	EndTime        time.Time
 	SpeciesCode    string
@@ -23,6 +26,7 @@ type Note struct {
 	Sensitivity    float64
 	ClipName       string
 	ProcessingTime time.Duration
+	DetectionEvent string        `gorm:"size:20;index"` // New or returning species event, see EventLifer etc.
 	Results        []Results     `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"`
 	Review         *NoteReview   `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-one relationship with cascade delete
 	Comments       []NoteComment `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-many relationship with cascade delete
@@ -33,12 +37,21 @@ type Note struct {
 	Locked   bool   `gorm:"-"` // This will be populated from Lock presence
 }
 
+// Detection events stored in Note.DetectionEvent. A detection gets the first matching event in this order.
+const (
+	EventLifer         = "lifer"         // first detection of the species at this station
+	EventFirstOfYear   = "firstofyear"   // first detection of the species this calendar year
+	EventFirstOfSeason = "firstofseason" // first detection of the species this meteorological season
+	EventReturning     = "returning"     // first detection after a configured number of days without one
+)
+
 // Result represents the identification result with a species name and its confidence level, linked to a Note.
 type Results struct {
 	ID         uint `gorm:"primaryKey"`
 	NoteID     uint `gorm:"index;not null;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;foreignKey:NoteID;references:ID"` // Foreign key to associate with Note
 	Species    string
 	Confidence float32
+	StartTime  time.Time // Start of the prediction window of the result, zero for results saved before windows were recorded
 }
 
 // Copy creates a deep copy of the Results struct
@@ -48,6 +61,7 @@ func (r Results) Copy() Results {
 		NoteID:     r.NoteID,
 		Species:    r.Species,
 		Confidence: r.Confidence,
+		StartTime:  r.StartTime,
 	}
 }
 
@@ -110,6 +124,119 @@ type HourlyWeather struct {
 	WeatherIcon   string
 }
 
+// DynamicThreshold is the saved dynamic confidence threshold state of a species, restored
+// when the detection processor starts.
+type DynamicThreshold struct {
+	Species       string    `gorm:"primaryKey;size:255"` // Lower case common name of the species
+	Level         int       // Active level of the threshold schedule, 0 for the base threshold
+	CurrentValue  float64   // Effective confidence threshold
+	HighConfCount int       // High confidence detections counted toward the level
+	ExpiresAt     time.Time // When the threshold reverts to the base threshold
+}
+
+// RangeFilterScore is a species included by the range filter on a date. One set of scores is
+// kept per day as history of how the species list changes over the year.
+type RangeFilterScore struct {
+	ID     uint    `gorm:"primaryKey"`
+	Date   string  `gorm:"index;size:10;not null"` // Date the scores were computed, YYYY-MM-DD
+	Week   int     // Range filter week of the date, 1 to 48
+	Label  string  // BirdNET label of the species
+	Score  float64 // Occurrence score, 1 for species on the include list or with a custom configuration
+	Reason string  // Why the species is included: score, include, config or nolocation
+}
+
+// RangeFilterSnapshot summarizes the range filter scores saved for a date.
+type RangeFilterSnapshot struct {
+	Date    string
+	Week    int
+	Species int64
+}
+
+// Reasons stored in RejectedDetection.Reason. Detections discarded by a suppressor rule store
+// the name of the rule, the privacy and dog bark filters are the rules named below.
+const (
+	RejectReasonThreshold   = "threshold"   // confidence at or below the confidence threshold
+	RejectReasonRangeFilter = "rangefilter" // species not included by the range filter
+	RejectReasonMatches     = "matches"     // detected in too few consecutive predictions
+	RejectReasonPrivacy     = "privacy"     // human voice heard during the detection
+	RejectReasonDogBark     = "dogbark"     // dog bark heard shortly before the detection
+	RejectReasonTimeOfDay   = "timeofday"   // species excluded at this time of day by a time rule
+)
+
+// RejectedDetection is a detection discarded by a filter. Rejected detections are kept for a
+// limited time to audit the filters, and can be promoted to a note.
+type RejectedDetection struct {
+	ID             uint `gorm:"primaryKey"`
+	SourceNode     string
+	Date           string `gorm:"index"`
+	Time           string
+	Timestamp      time.Time `gorm:"serializer:unixutc;type:bigint;index"` // Detection time in UTC, stored as Unix seconds
+	TimeZone       string    `gorm:"size:64"`
+	Source         string
+	Channel        int
+	BeginTime      time.Time
+	EndTime        time.Time
+	SpeciesCode    string
+	ScientificName string `gorm:"index"`
+	CommonName     string
+	Confidence     float64
+	Latitude       float64
+	Longitude      float64
+	Threshold      float64 // Confidence threshold in effect for the detection
+	Sensitivity    float64
+	Reason         string `gorm:"size:20;index"` // Filter that rejected the detection, see RejectReasonThreshold etc.
+	Matches        int    // Number of predictions the species was detected in
+	NoteID         uint   // Note created by promoting the detection, 0 if not promoted
+}
+
+// NewRejectedDetection returns a rejected detection of a note.
+func NewRejectedDetection(note *Note, reason string, matches int) RejectedDetection {
+	return RejectedDetection{
+		SourceNode:     note.SourceNode,
+		Date:           note.Date,
+		Time:           note.Time,
+		Timestamp:      note.Timestamp,
+		TimeZone:       note.TimeZone,
+		Source:         note.Source,
+		Channel:        note.Channel,
+		BeginTime:      note.BeginTime,
+		EndTime:        note.EndTime,
+		SpeciesCode:    note.SpeciesCode,
+		ScientificName: note.ScientificName,
+		CommonName:     note.CommonName,
+		Confidence:     note.Confidence,
+		Latitude:       note.Latitude,
+		Longitude:      note.Longitude,
+		Threshold:      note.Threshold,
+		Sensitivity:    note.Sensitivity,
+		Reason:         reason,
+		Matches:        matches,
+	}
+}
+
+// Note returns the note of a rejected detection, without audio clip.
+func (r *RejectedDetection) Note() Note {
+	return Note{
+		SourceNode:     r.SourceNode,
+		Date:           r.Date,
+		Time:           r.Time,
+		Timestamp:      r.Timestamp,
+		TimeZone:       r.TimeZone,
+		Source:         r.Source,
+		Channel:        r.Channel,
+		BeginTime:      r.BeginTime,
+		EndTime:        r.EndTime,
+		SpeciesCode:    r.SpeciesCode,
+		ScientificName: r.ScientificName,
+		CommonName:     r.CommonName,
+		Confidence:     r.Confidence,
+		Latitude:       r.Latitude,
+		Longitude:      r.Longitude,
+		Threshold:      r.Threshold,
+		Sensitivity:    r.Sensitivity,
+	}
+}
+
 // ImageCache represents cached image metadata for species
 type ImageCache struct {
 	ID             uint      `gorm:"primaryKey"`
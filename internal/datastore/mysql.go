@@ -3,11 +3,13 @@ package datastore
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // MySQLStore implements DataStore for MySQL
@@ -22,8 +24,16 @@ func validateMySQLConfig() error {
 	return nil
 }
 
-// InitializeDatabase sets up the MySQL database connection
+// Open sets up the MySQL database connection and applies pending migrations
 func (store *MySQLStore) Open() error {
+	if err := store.Connect(); err != nil {
+		return err
+	}
+	return store.Migrate()
+}
+
+// Connect opens the MySQL database connection without running migrations
+func (store *MySQLStore) Connect() error {
 	if err := validateMySQLConfig(); err != nil {
 		return err // validateMySQLConfig returns a properly formatted error
 	}
@@ -44,7 +54,59 @@ func (store *MySQLStore) Open() error {
 	}
 
 	store.DB = db
-	return performAutoMigration(db, store.Settings.Debug, "MySQL", dsn)
+	return nil
+}
+
+// Migrate applies pending schema migrations, copying the tables of the database first
+func (store *MySQLStore) Migrate() error {
+	if store.DB == nil {
+		return fmt.Errorf("database connection is not initialized")
+	}
+
+	return runMigrations(store.DB, store.Settings.Debug, "MySQL", store.createBackup)
+}
+
+// createBackup copies every table of the database to a table with a timestamped backup suffix
+// in the same database. The copies are left in place, drop them once the migrated database
+// has been checked. If any copy fails the copies made so far are dropped.
+func (store *MySQLStore) createBackup() error {
+	suffix := "_backup_" + time.Now().Format("20060102_150405")
+
+	var copies []string
+	dropCopies := func() {
+		for _, table := range copies {
+			if err := store.DB.Migrator().DropTable(table); err != nil {
+				log.Printf("Failed to drop incomplete backup table %s: %v", table, err)
+			}
+		}
+	}
+
+	tables := append([]interface{}{&SchemaVersion{}}, models...)
+	for _, model := range tables {
+		if !store.DB.Migrator().HasTable(model) {
+			continue
+		}
+		stmt := &gorm.Statement{DB: store.DB}
+		if err := stmt.Parse(model); err != nil {
+			dropCopies()
+			return fmt.Errorf("failed to resolve table of %T: %w", model, err)
+		}
+		table := stmt.Schema.Table
+		backup := table + suffix
+
+		if err := store.DB.Exec("CREATE TABLE ? LIKE ?", clause.Table{Name: backup}, clause.Table{Name: table}).Error; err != nil {
+			dropCopies()
+			return fmt.Errorf("failed to create backup table %s: %w", backup, err)
+		}
+		copies = append(copies, backup)
+		if err := store.DB.Exec("INSERT INTO ? SELECT * FROM ?", clause.Table{Name: backup}, clause.Table{Name: table}).Error; err != nil {
+			dropCopies()
+			return fmt.Errorf("failed to copy table %s to %s: %w", table, backup, err)
+		}
+	}
+
+	log.Printf("Created backup of MySQL database %s in tables with suffix %s", store.Settings.Output.MySQL.Database, suffix)
+	return nil
 }
 
 // Close MySQL database connections
@@ -70,6 +132,14 @@ func (store *MySQLStore) Close() error {
 	return nil
 }
 
+// ForNode returns a read-only view of the store whose detection queries only return the notes
+// of a node. The view shares the connection of the store and must not be closed.
+func (store *MySQLStore) ForNode(node string) Interface {
+	view := *store
+	view.DB = store.nodeDB(node)
+	return &view
+}
+
 // UpdateNote updates specific fields of a note in MySQL
 func (m *MySQLStore) UpdateNote(id string, updates map[string]interface{}) error {
 	return m.DB.Model(&Note{}).Where("id = ?", id).Updates(updates).Error
@@ -86,8 +86,16 @@ func (s *SQLiteStore) createBackup(dbPath string) error {
 	return nil
 }
 
-// Open initializes the SQLite database connection
+// Open initializes the SQLite database connection and applies pending migrations
 func (s *SQLiteStore) Open() error {
+	if err := s.Connect(); err != nil {
+		return err
+	}
+	return s.Migrate()
+}
+
+// Connect opens the SQLite database connection without running migrations
+func (s *SQLiteStore) Connect() error {
 	// Get database path from settings
 	dbPath := s.Settings.Output.SQLite.Path
 
@@ -131,12 +139,23 @@ func (s *SQLiteStore) Open() error {
 	// Store the database connection
 	s.DB = db
 
-	// Perform auto-migration
-	if err := performAutoMigration(db, s.Settings.Debug, "SQLite", dbPath); err != nil {
-		return err
+	return nil
+}
+
+// Migrate applies pending schema migrations, backing up the database file first
+func (s *SQLiteStore) Migrate() error {
+	if s.DB == nil {
+		return fmt.Errorf("database connection is not initialized")
 	}
 
-	return nil
+	dbPath := s.Settings.Output.SQLite.Path
+	return runMigrations(s.DB, s.Settings.Debug, "SQLite", func() error {
+		// Flush the WAL so the backup file contains all committed data
+		if err := s.DB.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error; err != nil {
+			log.Printf("Warning: Failed to checkpoint WAL before backup: %v", err)
+		}
+		return s.createBackup(dbPath)
+	})
 }
 
 // Close closes the SQLite database connection
@@ -151,6 +170,14 @@ func (s *SQLiteStore) Close() error {
 	return nil
 }
 
+// ForNode returns a read-only view of the store whose detection queries only return the notes
+// of a node. The view shares the connection of the store and must not be closed.
+func (s *SQLiteStore) ForNode(node string) Interface {
+	view := *s
+	view.DB = s.nodeDB(node)
+	return &view
+}
+
 // UpdateNote updates specific fields of a note in SQLite
 func (s *SQLiteStore) UpdateNote(id string, updates map[string]interface{}) error {
 	return s.DB.Model(&Note{}).Where("id = ?", id).Updates(updates).Error
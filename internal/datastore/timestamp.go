@@ -0,0 +1,185 @@
+// timestamp.go: UTC detection timestamps and time range helpers
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// dateTimeLayout is the combined layout of the Note Date and Time strings.
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// localTimezoneName caches the IANA zone name of this node.
+var localTimezoneName = sync.OnceValue(conf.GetLocalTimezoneName)
+
+func init() {
+	schema.RegisterSerializer("unixutc", UnixUTCSerializer{})
+}
+
+// UnixUTCSerializer stores a time.Time field as Unix seconds and reads it back in UTC.
+// Integer storage keeps range queries independent of database and driver time zone handling.
+// A zero time is stored as NULL.
+type UnixUTCSerializer struct{}
+
+// Scan implements schema.SerializerInterface.
+func (UnixUTCSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var seconds sql.NullInt64
+	if err := seconds.Scan(dbValue); err != nil {
+		return fmt.Errorf("failed to scan unix timestamp: %w", err)
+	}
+	var t time.Time
+	if seconds.Valid {
+		t = time.Unix(seconds.Int64, 0).UTC()
+	}
+	return field.Set(ctx, dst, t)
+}
+
+// Value implements schema.SerializerValuerInterface.
+func (UnixUTCSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	t, ok := fieldValue.(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("invalid field type %T for UnixUTCSerializer, only time.Time supported", fieldValue)
+	}
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.Unix(), nil
+}
+
+// BeforeCreate fills in the UTC timestamp and time zone of notes created without them,
+// deriving the timestamp from the local Date and Time strings.
+func (n *Note) BeforeCreate(tx *gorm.DB) error {
+	if n.Timestamp.IsZero() {
+		loc := time.Local
+		if n.TimeZone != "" {
+			if l, err := time.LoadLocation(n.TimeZone); err == nil {
+				loc = l
+			}
+		}
+		if t, err := time.ParseInLocation(dateTimeLayout, n.Date+" "+n.Time, loc); err == nil {
+			n.Timestamp = t
+		}
+	}
+	n.Timestamp = n.Timestamp.Truncate(time.Second).UTC()
+	if n.TimeZone == "" {
+		n.TimeZone = localTimezoneName()
+	}
+	return nil
+}
+
+// dayRange returns the UTC start and end of a local calendar date given as YYYY-MM-DD.
+// The range is half-open and is 23 or 25 hours long on DST transition days.
+func dayRange(date string) (start, end time.Time, err error) {
+	return timeRange(date, 0, 24)
+}
+
+// hourRange returns the UTC start and end of duration hours starting at the given local hour of date.
+func hourRange(date, hour string, duration int) (start, end time.Time, err error) {
+	startHour, err := strconv.Atoi(hour)
+	if err != nil || startHour < 0 || startHour > 23 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid hour %q", hour)
+	}
+	if duration < 1 {
+		duration = 1
+	}
+	return timeRange(date, startHour, duration)
+}
+
+// timeRange converts a local date and hour span into UTC bounds.
+func timeRange(date string, startHour, hours int) (start, end time.Time, err error) {
+	loc, err := conf.GetLocalTimezone()
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to get local timezone: %w", err)
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	start = earliestInstant(time.Date(day.Year(), day.Month(), day.Day(), startHour, 0, 0, 0, loc))
+	end = earliestInstant(time.Date(day.Year(), day.Month(), day.Day(), startHour+hours, 0, 0, 0, loc))
+	return start.UTC(), end.UTC(), nil
+}
+
+// earliestInstant returns the first instant at which the wall clock shows the same time as t.
+// time.Date picks the later instant when a wall clock time repeats at the end of DST, which
+// would leave the first occurrence of the repeated hour outside any range.
+func earliestInstant(t time.Time) time.Time {
+	_, offset := t.Zone()
+	_, prevOffset := t.Add(-3 * time.Hour).Zone()
+	if prevOffset > offset {
+		c := t.Add(-time.Duration(prevOffset-offset) * time.Second)
+		if c.Hour() == t.Hour() && c.Minute() == t.Minute() {
+			return c
+		}
+	}
+	return t
+}
+
+// whereTimeRange restricts a notes query to the half-open range [start, end).
+func whereTimeRange(query *gorm.DB, start, end time.Time) *gorm.DB {
+	return query.Where("notes.timestamp >= ? AND notes.timestamp < ?", start.Unix(), end.Unix())
+}
+
+// backfillNoteTimestamps sets the UTC timestamp and time zone of notes stored before
+// the columns existed. Their Date and Time strings are interpreted in the local time zone.
+func backfillNoteTimestamps(tx *gorm.DB) error {
+	const batchSize = 1000
+
+	loc, err := conf.GetLocalTimezone()
+	if err != nil {
+		return fmt.Errorf("failed to get local timezone: %w", err)
+	}
+	zone := localTimezoneName()
+
+	var lastID uint
+	var updated, skipped int
+	for {
+		var rows []struct {
+			ID   uint
+			Date string
+			Time string
+		}
+		err := tx.Table("notes").Select("id, date, time").
+			Where("timestamp IS NULL AND id > ?", lastID).
+			Order("id ASC").Limit(batchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to read notes: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			t, err := time.ParseInLocation(dateTimeLayout, row.Date+" "+row.Time, loc)
+			if err != nil {
+				skipped++
+				continue
+			}
+			err = tx.Exec("UPDATE notes SET timestamp = ?, time_zone = ? WHERE id = ?", t.Unix(), zone, row.ID).Error
+			if err != nil {
+				return fmt.Errorf("failed to update note %d: %w", row.ID, err)
+			}
+			updated++
+		}
+	}
+
+	if skipped > 0 {
+		log.Printf("Timestamp backfill skipped %d notes with unparseable date or time", skipped)
+	}
+	if updated > 0 {
+		log.Printf("Backfilled timestamps of %d notes using time zone %s", updated, zone)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// useLocation sets time.Local for the duration of the test.
+func useLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("Time zone %s not available: %v", name, err)
+	}
+	original := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = original })
+	return loc
+}
+
+// TestHourlyQueriesAcrossDST verifies that detections in the repeated hour at the end of
+// daylight saving time keep distinct timestamps and are found by range queries.
+func TestHourlyQueriesAcrossDST(t *testing.T) {
+	loc := useLocation(t, "Europe/Helsinki")
+	store := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+
+	// 03:30 local time occurs twice on 2024-10-27, first at UTC+3 and then at UTC+2
+	first := time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	for _, ts := range []time.Time{first, second} {
+		local := ts.In(loc)
+		note := &Note{
+			Date:       local.Format("2006-01-02"),
+			Time:       local.Format("15:04:05"),
+			Timestamp:  ts,
+			CommonName: "Tawny Owl",
+			Confidence: 0.9,
+		}
+		if err := store.Save(note, nil); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	detections, err := store.GetHourlyDetections("2024-10-27", "3", 1)
+	if err != nil {
+		t.Fatalf("GetHourlyDetections failed: %v", err)
+	}
+	if len(detections) != 2 {
+		t.Fatalf("Expected 2 detections in the repeated hour, got %d", len(detections))
+	}
+	if !detections[0].Timestamp.Equal(first) || !detections[1].Timestamp.Equal(second) {
+		t.Errorf("Expected timestamps %v and %v, got %v and %v", first, second, detections[0].Timestamp, detections[1].Timestamp)
+	}
+
+	counts, err := store.GetHourlyOccurrences("2024-10-27", "Tawny Owl", 0)
+	if err != nil {
+		t.Fatalf("GetHourlyOccurrences failed: %v", err)
+	}
+	if counts[3] != 2 {
+		t.Errorf("Expected 2 occurrences at hour 3, got %d", counts[3])
+	}
+
+	count, err := store.CountSpeciesDetections("Tawny Owl", "2024-10-26", "", 0)
+	if err != nil {
+		t.Fatalf("CountSpeciesDetections failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no detections on the previous day, got %d", count)
+	}
+}
+
+// TestBackfillNoteTimestamps verifies that notes without a timestamp get one derived from
+// their local date and time.
+func TestBackfillNoteTimestamps(t *testing.T) {
+	loc := useLocation(t, "America/New_York")
+	store := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+
+	if err := store.Save(&Note{Date: "2024-07-04", Time: "21:15:00", ScientificName: "Strix varia"}, nil); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+	// Simulate a row written before the timestamp columns existed
+	if err := store.DB.Exec("UPDATE notes SET timestamp = NULL, time_zone = ''").Error; err != nil {
+		t.Fatalf("Failed to clear timestamp: %v", err)
+	}
+
+	if err := backfillNoteTimestamps(store.DB); err != nil {
+		t.Fatalf("Backfill failed: %v", err)
+	}
+
+	var note Note
+	if err := store.DB.First(&note).Error; err != nil {
+		t.Fatalf("Failed to read note: %v", err)
+	}
+	want := time.Date(2024, 7, 4, 21, 15, 0, 0, loc)
+	if !note.Timestamp.Equal(want) {
+		t.Errorf("Expected timestamp %v, got %v", want.UTC(), note.Timestamp)
+	}
+	if note.TimeZone == "" {
+		t.Error("Expected time zone to be set")
+	}
+}
@@ -0,0 +1,527 @@
+// transfer.go: export and import of the full database as a portable archive
+package datastore
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ArchiveFormatVersion is the version of the export archive layout.
+const ArchiveFormatVersion = 1
+
+// manifestFile is the name of the manifest inside the export archive.
+const manifestFile = "manifest.json"
+
+// transferBatchSize is the number of rows read or written per database round trip.
+const transferBatchSize = 500
+
+// Archive tables in dependency order, parents before children.
+const (
+	tableNotes         = "notes"
+	tableResults       = "results"
+	tableNoteReviews   = "note_reviews"
+	tableNoteComments  = "note_comments"
+	tableNoteLocks     = "note_locks"
+	tableDailyEvents   = "daily_events"
+	tableHourlyWeather = "hourly_weathers"
+	tableImageCaches   = "image_caches"
+	tableDynamicThresh = "dynamic_thresholds"
+	tableRangeFilter   = "range_filter_scores"
+	tableRejected      = "rejected_detections"
+)
+
+// ArchiveManifest describes the content of an export archive.
+type ArchiveManifest struct {
+	FormatVersion int            `json:"formatVersion"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	SourceDB      string         `json:"sourceDb"`
+	SchemaVersion int            `json:"schemaVersion"`
+	Tables        []ArchiveTable `json:"tables"`
+}
+
+// ArchiveTable describes a single table file in an export archive.
+type ArchiveTable struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Rows int    `json:"rows"`
+}
+
+// TableImportStats counts the outcome of importing a single table.
+type TableImportStats struct {
+	Table    string
+	Imported int
+	Skipped  int
+}
+
+// Export writes every table of the database to w as a zip archive holding one
+// JSON Lines file per table and a manifest.
+func (ds *DataStore) Export(w io.Writer) (*ArchiveManifest, error) {
+	if ds.DB == nil {
+		return nil, errors.New("database connection is not initialized")
+	}
+
+	schemaVersion := 0
+	if applied, err := appliedVersions(ds.DB); err == nil {
+		for v := range applied {
+			if v > schemaVersion {
+				schemaVersion = v
+			}
+		}
+	}
+
+	manifest := &ArchiveManifest{
+		FormatVersion: ArchiveFormatVersion,
+		CreatedAt:     time.Now(),
+		SourceDB:      ds.DB.Dialector.Name(),
+		SchemaVersion: schemaVersion,
+	}
+
+	zw := zip.NewWriter(w)
+
+	exporters := []struct {
+		name   string
+		export func(*gorm.DB, *zip.Writer, string) (int, error)
+	}{
+		{tableNotes, exportTable[Note]},
+		{tableResults, exportTable[Results]},
+		{tableNoteReviews, exportTable[NoteReview]},
+		{tableNoteComments, exportTable[NoteComment]},
+		{tableNoteLocks, exportTable[NoteLock]},
+		{tableDailyEvents, exportTable[DailyEvents]},
+		{tableHourlyWeather, exportTable[HourlyWeather]},
+		{tableImageCaches, exportTable[ImageCache]},
+		{tableDynamicThresh, exportTable[DynamicThreshold]},
+		{tableRangeFilter, exportTable[RangeFilterScore]},
+		{tableRejected, exportTable[RejectedDetection]},
+	}
+
+	for _, e := range exporters {
+		file := e.name + ".jsonl"
+		rows, err := e.export(ds.DB, zw, file)
+		if err != nil {
+			return nil, fmt.Errorf("exporting table %s: %w", e.name, err)
+		}
+		manifest.Tables = append(manifest.Tables, ArchiveTable{Name: e.name, File: file, Rows: rows})
+	}
+
+	mw, err := zw.Create(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("creating manifest: %w", err)
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("finalizing archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// exportTable writes all rows of the model T to file in the archive, one JSON object per line.
+func exportTable[T any](db *gorm.DB, zw *zip.Writer, file string) (int, error) {
+	fw, err := zw.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(fw)
+
+	count := 0
+	var batch []T
+	result := db.Model(new(T)).FindInBatches(&batch, transferBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := enc.Encode(&batch[i]); err != nil {
+				return err
+			}
+		}
+		count += len(batch)
+		return nil
+	})
+
+	return count, result.Error
+}
+
+// importer holds the ID mappings built while importing an archive.
+type importer struct {
+	db *gorm.DB
+
+	noteIDs      map[uint]uint // archive note ID -> database note ID
+	existingNote map[uint]bool // archive note IDs that were already present
+	dailyIDs     map[uint]uint // archive daily events ID -> database daily events ID
+}
+
+// Import reads an archive created by Export into the database. Row IDs are remapped,
+// foreign keys follow the remapped IDs, and rows that already exist are skipped so
+// that importing the same archive twice does not create duplicates.
+func (ds *DataStore) Import(r io.ReaderAt, size int64) ([]TableImportStats, error) {
+	if ds.DB == nil {
+		return nil, errors.New("database connection is not initialized")
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("opening archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifest, err := readManifest(files[manifestFile])
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &importer{
+		db:           ds.DB,
+		noteIDs:      make(map[uint]uint),
+		existingNote: make(map[uint]bool),
+		dailyIDs:     make(map[uint]uint),
+	}
+
+	importers := map[string]func(tx *gorm.DB, line []byte) (bool, error){
+		tableNotes:         imp.importNote,
+		tableResults:       imp.importResult,
+		tableNoteReviews:   imp.importReview,
+		tableNoteComments:  imp.importComment,
+		tableNoteLocks:     imp.importLock,
+		tableDailyEvents:   imp.importDailyEvents,
+		tableHourlyWeather: imp.importHourlyWeather,
+		tableImageCaches:   imp.importImageCache,
+		tableDynamicThresh: imp.importDynamicThreshold,
+		tableRangeFilter:   imp.importRangeFilterScore,
+		tableRejected:      imp.importRejectedDetection,
+	}
+
+	// Import in fixed dependency order regardless of manifest order
+	order := []string{tableNotes, tableResults, tableNoteReviews, tableNoteComments, tableNoteLocks,
+		tableDailyEvents, tableHourlyWeather, tableImageCaches, tableDynamicThresh, tableRangeFilter, tableRejected}
+
+	tables := make(map[string]ArchiveTable, len(manifest.Tables))
+	for _, t := range manifest.Tables {
+		tables[t.Name] = t
+	}
+
+	var stats []TableImportStats
+	for _, name := range order {
+		table, ok := tables[name]
+		if !ok {
+			continue
+		}
+		f, ok := files[table.File]
+		if !ok {
+			return stats, fmt.Errorf("archive is missing file %s for table %s", table.File, name)
+		}
+
+		s, err := imp.importFile(f, name, importers[name])
+		stats = append(stats, s)
+		if err != nil {
+			return stats, fmt.Errorf("importing table %s: %w", name, err)
+		}
+	}
+
+	return stats, nil
+}
+
+// readManifest decodes and validates the archive manifest.
+func readManifest(f *zip.File) (*ArchiveManifest, error) {
+	if f == nil {
+		return nil, fmt.Errorf("archive has no %s", manifestFile)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening manifest: %w", err)
+	}
+	defer rc.Close()
+
+	var manifest ArchiveManifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > ArchiveFormatVersion {
+		return nil, fmt.Errorf("unsupported archive format version %d", manifest.FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// importFile feeds every line of an archive file to fn, committing in batches.
+func (imp *importer) importFile(f *zip.File, table string, fn func(tx *gorm.DB, line []byte) (bool, error)) (TableImportStats, error) {
+	stats := TableImportStats{Table: table}
+
+	rc, err := f.Open()
+	if err != nil {
+		return stats, err
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var batch [][]byte
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := imp.db.Transaction(func(tx *gorm.DB) error {
+			for _, line := range batch {
+				imported, err := fn(tx, line)
+				if err != nil {
+					return err
+				}
+				if imported {
+					stats.Imported++
+				} else {
+					stats.Skipped++
+				}
+			}
+			return nil
+		})
+		batch = batch[:0]
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		batch = append(batch, append([]byte(nil), line...))
+		if len(batch) >= transferBatchSize {
+			if err := flush(); err != nil {
+				return stats, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+
+	return stats, flush()
+}
+
+// exists reports whether a row matching the query exists.
+func exists(tx *gorm.DB, model interface{}, query string, args ...interface{}) (bool, error) {
+	var count int64
+	if err := tx.Model(model).Where(query, args...).Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (imp *importer) importNote(tx *gorm.DB, line []byte) (bool, error) {
+	var note Note
+	if err := json.Unmarshal(line, &note); err != nil {
+		return false, err
+	}
+	archiveID := note.ID
+
+	// Archives from before UTC timestamps get them derived from the local date and time
+	if err := note.BeforeCreate(tx); err != nil {
+		return false, err
+	}
+
+	// A detection is identified by its time, species, source and confidence
+	var existing Note
+	err := tx.Select("id").
+		Where("timestamp = ? AND scientific_name = ? AND source = ? AND confidence = ?",
+			note.Timestamp.Unix(), note.ScientificName, note.Source, note.Confidence).
+		First(&existing).Error
+	if err == nil {
+		imp.noteIDs[archiveID] = existing.ID
+		imp.existingNote[archiveID] = true
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	note.ID = 0
+	note.Results, note.Review, note.Comments, note.Lock = nil, nil, nil, nil
+	if err := tx.Omit(clause.Associations).Create(&note).Error; err != nil {
+		return false, err
+	}
+	imp.noteIDs[archiveID] = note.ID
+	return true, nil
+}
+
+func (imp *importer) importResult(tx *gorm.DB, line []byte) (bool, error) {
+	var result Results
+	if err := json.Unmarshal(line, &result); err != nil {
+		return false, err
+	}
+	noteID, ok := imp.noteIDs[result.NoteID]
+	if !ok || imp.existingNote[result.NoteID] {
+		return false, nil
+	}
+
+	result.ID = 0
+	result.NoteID = noteID
+	return true, tx.Create(&result).Error
+}
+
+func (imp *importer) importReview(tx *gorm.DB, line []byte) (bool, error) {
+	var review NoteReview
+	if err := json.Unmarshal(line, &review); err != nil {
+		return false, err
+	}
+	noteID, ok := imp.noteIDs[review.NoteID]
+	if !ok {
+		return false, nil
+	}
+	if found, err := exists(tx, &NoteReview{}, "note_id = ?", noteID); err != nil || found {
+		return false, err
+	}
+
+	review.ID = 0
+	review.NoteID = noteID
+	return true, tx.Create(&review).Error
+}
+
+func (imp *importer) importComment(tx *gorm.DB, line []byte) (bool, error) {
+	var comment NoteComment
+	if err := json.Unmarshal(line, &comment); err != nil {
+		return false, err
+	}
+	noteID, ok := imp.noteIDs[comment.NoteID]
+	if !ok {
+		return false, nil
+	}
+	if found, err := exists(tx, &NoteComment{}, "note_id = ? AND entry = ?", noteID, comment.Entry); err != nil || found {
+		return false, err
+	}
+
+	comment.ID = 0
+	comment.NoteID = noteID
+	return true, tx.Create(&comment).Error
+}
+
+func (imp *importer) importLock(tx *gorm.DB, line []byte) (bool, error) {
+	var lock NoteLock
+	if err := json.Unmarshal(line, &lock); err != nil {
+		return false, err
+	}
+	noteID, ok := imp.noteIDs[lock.NoteID]
+	if !ok {
+		return false, nil
+	}
+	if found, err := exists(tx, &NoteLock{}, "note_id = ?", noteID); err != nil || found {
+		return false, err
+	}
+
+	lock.ID = 0
+	lock.NoteID = noteID
+	return true, tx.Create(&lock).Error
+}
+
+func (imp *importer) importDailyEvents(tx *gorm.DB, line []byte) (bool, error) {
+	var events DailyEvents
+	if err := json.Unmarshal(line, &events); err != nil {
+		return false, err
+	}
+	archiveID := events.ID
+
+	var existing DailyEvents
+	err := tx.Select("id").Where("date = ?", events.Date).First(&existing).Error
+	if err == nil {
+		imp.dailyIDs[archiveID] = existing.ID
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	events.ID = 0
+	if err := tx.Create(&events).Error; err != nil {
+		return false, err
+	}
+	imp.dailyIDs[archiveID] = events.ID
+	return true, nil
+}
+
+func (imp *importer) importHourlyWeather(tx *gorm.DB, line []byte) (bool, error) {
+	var weather HourlyWeather
+	if err := json.Unmarshal(line, &weather); err != nil {
+		return false, err
+	}
+	if found, err := exists(tx, &HourlyWeather{}, "time = ?", weather.Time); err != nil || found {
+		return false, err
+	}
+	dailyID, ok := imp.dailyIDs[weather.DailyEventsID]
+	if !ok {
+		log.Printf("Skipping hourly weather of %s: daily events %d are not in the archive",
+			weather.Time.Format(time.RFC3339), weather.DailyEventsID)
+		return false, nil
+	}
+
+	weather.ID = 0
+	weather.DailyEventsID = dailyID
+	return true, tx.Create(&weather).Error
+}
+
+func (imp *importer) importImageCache(tx *gorm.DB, line []byte) (bool, error) {
+	var cache ImageCache
+	if err := json.Unmarshal(line, &cache); err != nil {
+		return false, err
+	}
+	if found, err := exists(tx, &ImageCache{}, "scientific_name = ?", cache.ScientificName); err != nil || found {
+		return false, err
+	}
+
+	cache.ID = 0
+	return true, tx.Create(&cache).Error
+}
+
+func (imp *importer) importDynamicThreshold(tx *gorm.DB, line []byte) (bool, error) {
+	var threshold DynamicThreshold
+	if err := json.Unmarshal(line, &threshold); err != nil {
+		return false, err
+	}
+	// The running state of the database wins over the archived state
+	if found, err := exists(tx, &DynamicThreshold{}, "species = ?", threshold.Species); err != nil || found {
+		return false, err
+	}
+
+	return true, tx.Create(&threshold).Error
+}
+
+func (imp *importer) importRangeFilterScore(tx *gorm.DB, line []byte) (bool, error) {
+	var score RangeFilterScore
+	if err := json.Unmarshal(line, &score); err != nil {
+		return false, err
+	}
+	if found, err := exists(tx, &RangeFilterScore{}, "date = ? AND label = ?", score.Date, score.Label); err != nil || found {
+		return false, err
+	}
+
+	score.ID = 0
+	return true, tx.Create(&score).Error
+}
+
+func (imp *importer) importRejectedDetection(tx *gorm.DB, line []byte) (bool, error) {
+	var rejected RejectedDetection
+	if err := json.Unmarshal(line, &rejected); err != nil {
+		return false, err
+	}
+	if found, err := exists(tx, &RejectedDetection{}, "timestamp = ? AND scientific_name = ? AND source = ? AND confidence = ?",
+		rejected.Timestamp.Unix(), rejected.ScientificName, rejected.Source, rejected.Confidence); err != nil || found {
+		return false, err
+	}
+
+	// A promoted detection follows its remapped note, or is no longer promoted if the note is gone
+	rejected.ID = 0
+	rejected.NoteID = imp.noteIDs[rejected.NoteID]
+	return true, tx.Create(&rejected).Error
+}
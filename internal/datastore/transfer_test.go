@@ -0,0 +1,213 @@
+package datastore
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestExportImportRoundTrip verifies that an exported archive can be imported into another
+// database with remapped IDs and that importing it a second time skips every row.
+func TestExportImportRoundTrip(t *testing.T) {
+	source := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+
+	// Offset source IDs so that remapping is observable in the target database
+	for i := 0; i < 3; i++ {
+		if err := source.Save(&Note{Date: "2024-01-01", Time: "05:00:00", ScientificName: "Filler", Confidence: float64(i)}, nil); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+	note := &Note{Date: "2024-05-01", Time: "06:15:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.92, BeginTime: time.Now()}
+	if err := source.Save(note, []Results{{Species: "Turdus merula", Confidence: 0.92}}); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+	if err := source.SaveNoteReview(&NoteReview{NoteID: note.ID, Verified: "correct"}); err != nil {
+		t.Fatalf("Failed to save review: %v", err)
+	}
+	if err := source.SaveNoteComment(&NoteComment{NoteID: note.ID, Entry: "singing"}); err != nil {
+		t.Fatalf("Failed to save comment: %v", err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := source.Export(&archive)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if manifest.Tables[0].Name != tableNotes || manifest.Tables[0].Rows != 4 {
+		t.Fatalf("Expected 4 exported notes, got %+v", manifest.Tables[0])
+	}
+
+	target := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+	if err := target.Save(&Note{Date: "2023-12-31", Time: "23:00:00", ScientificName: "Strix aluco"}, nil); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	reader := bytes.NewReader(archive.Bytes())
+	stats, err := target.Import(reader, int64(reader.Len()))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if stats[0].Imported != 4 {
+		t.Errorf("Expected 4 imported notes, got %d", stats[0].Imported)
+	}
+
+	var imported Note
+	if err := target.DB.Preload("Results").Preload("Review").Preload("Comments").
+		Where("scientific_name = ?", "Turdus merula").First(&imported).Error; err != nil {
+		t.Fatalf("Imported note not found: %v", err)
+	}
+	if imported.ID == note.ID {
+		t.Errorf("Expected note ID to be remapped, got source ID %d", imported.ID)
+	}
+	if len(imported.Results) != 1 || imported.Review == nil || imported.Review.Verified != "correct" || len(imported.Comments) != 1 {
+		t.Errorf("Expected result, review and comment to follow the remapped note, got %+v", imported)
+	}
+
+	// A second import must not duplicate anything
+	stats, err = target.Import(reader, int64(reader.Len()))
+	if err != nil {
+		t.Fatalf("Second import failed: %v", err)
+	}
+	for _, s := range stats {
+		if s.Imported != 0 {
+			t.Errorf("Expected no rows imported from %s on second import, got %d", s.Table, s.Imported)
+		}
+	}
+
+	var count int64
+	target.DB.Model(&Note{}).Count(&count)
+	if count != 5 {
+		t.Errorf("Expected 5 notes in target database, got %d", count)
+	}
+}
+
+// TestImportHourlyWeatherWithoutDailyEvents verifies that hourly weather whose daily events are
+// missing from the archive is skipped instead of being linked to daily events ID 0.
+func TestImportHourlyWeatherWithoutDailyEvents(t *testing.T) {
+	source := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+
+	daily := &DailyEvents{Date: "2024-05-01", Sunrise: 1714532400, Sunset: 1714589000}
+	if err := source.SaveDailyEvents(daily); err != nil {
+		t.Fatalf("Failed to save daily events: %v", err)
+	}
+	hour := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	weathers := []HourlyWeather{
+		{DailyEventsID: daily.ID, Time: hour, Temperature: 12},
+		{DailyEventsID: daily.ID + 100, Time: hour.Add(time.Hour), Temperature: 13},
+	}
+	for i := range weathers {
+		if err := source.SaveHourlyWeather(&weathers[i]); err != nil {
+			t.Fatalf("Failed to save hourly weather: %v", err)
+		}
+	}
+
+	var archive bytes.Buffer
+	if _, err := source.Export(&archive); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	target := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+	reader := bytes.NewReader(archive.Bytes())
+	stats, err := target.Import(reader, int64(reader.Len()))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	for _, s := range stats {
+		if s.Table == tableHourlyWeather && (s.Imported != 1 || s.Skipped != 1) {
+			t.Errorf("Expected 1 imported and 1 skipped hourly weather, got %+v", s)
+		}
+	}
+
+	var imported []HourlyWeather
+	if err := target.DB.Find(&imported).Error; err != nil {
+		t.Fatalf("Failed to read hourly weather: %v", err)
+	}
+	if len(imported) != 1 || imported[0].DailyEventsID == 0 || imported[0].Temperature != 12 {
+		t.Errorf("Expected only the hourly weather with daily events to be imported, got %+v", imported)
+	}
+}
+
+// TestExportImportFilterTables verifies that dynamic thresholds, range filter scores and
+// rejected detections are transferred, and that promoted detections follow their note.
+func TestExportImportFilterTables(t *testing.T) {
+	source := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+
+	if err := source.SaveDynamicThresholds([]DynamicThreshold{{Species: "eurasian blackbird", Level: 2, CurrentValue: 0.5}}); err != nil {
+		t.Fatalf("Failed to save dynamic thresholds: %v", err)
+	}
+	scores := []RangeFilterScore{{Date: "2024-05-01", Week: 17, Label: "Turdus merula_Eurasian Blackbird", Score: 0.8, Reason: "score"}}
+	if err := source.SaveRangeFilterScores("2024-05-01", scores); err != nil {
+		t.Fatalf("Failed to save range filter scores: %v", err)
+	}
+	ts := time.Date(2024, 5, 1, 6, 15, 0, 0, time.UTC)
+	for _, confidence := range []float64{0.6, 0.7} {
+		rejected := &RejectedDetection{Timestamp: ts, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird",
+			Confidence: confidence, Reason: RejectReasonThreshold}
+		if err := source.SaveRejectedDetection(rejected); err != nil {
+			t.Fatalf("Failed to save rejected detection: %v", err)
+		}
+	}
+	note, err := source.PromoteRejectedDetection("2")
+	if err != nil {
+		t.Fatalf("Failed to promote rejected detection: %v", err)
+	}
+
+	var archive bytes.Buffer
+	if _, err := source.Export(&archive); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// Shift the note IDs of the target database so that remapping is observable
+	target := createDatabase(t, &conf.Settings{}).(*SQLiteStore)
+	if err := target.Save(&Note{Date: "2023-12-31", Time: "23:00:00", ScientificName: "Strix aluco"}, nil); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	reader := bytes.NewReader(archive.Bytes())
+	if _, err := target.Import(reader, int64(reader.Len())); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	thresholds, err := target.GetDynamicThresholds()
+	if err != nil || len(thresholds) != 1 || thresholds[0].Level != 2 {
+		t.Errorf("Expected the dynamic threshold to be imported, got %+v (%v)", thresholds, err)
+	}
+	imported, err := target.GetRangeFilterScores("2024-05-01")
+	if err != nil || len(imported) != 1 || imported[0].Label != scores[0].Label {
+		t.Errorf("Expected the range filter score to be imported, got %+v (%v)", imported, err)
+	}
+	rejected, err := target.GetRejectedDetections("", 10, 0)
+	if err != nil || len(rejected) != 2 {
+		t.Fatalf("Expected 2 imported rejected detections, got %d (%v)", len(rejected), err)
+	}
+	var promoted Note
+	if err := target.DB.Where("scientific_name = ?", "Turdus merula").First(&promoted).Error; err != nil {
+		t.Fatalf("Promoted note not found: %v", err)
+	}
+	if promoted.ID == note.ID {
+		t.Errorf("Expected note ID to be remapped, got source ID %d", promoted.ID)
+	}
+	for i := range rejected {
+		want := uint(0)
+		if rejected[i].Confidence == 0.7 {
+			want = promoted.ID
+		}
+		if rejected[i].NoteID != want {
+			t.Errorf("Expected rejected detection with confidence %v to have note %d, got %d",
+				rejected[i].Confidence, want, rejected[i].NoteID)
+		}
+	}
+
+	// A second import must not duplicate anything
+	stats, err := target.Import(reader, int64(reader.Len()))
+	if err != nil {
+		t.Fatalf("Second import failed: %v", err)
+	}
+	for _, s := range stats {
+		if s.Imported != 0 {
+			t.Errorf("Expected no rows imported from %s on second import, got %d", s.Table, s.Imported)
+		}
+	}
+}
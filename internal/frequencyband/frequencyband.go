@@ -0,0 +1,75 @@
+// frequencyband.go: expected vocalisation frequency range of species
+package frequencyband
+
+import (
+	"bytes"
+	_ "embed" // Embedding data directly into the binary.
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Embedded frequency bands keyed by BirdNET label.
+//
+//go:embed bands.csv
+var bandsCSV []byte
+
+// Band is the frequency range in which a species is expected to vocalise.
+type Band struct {
+	MinHz int
+	MaxHz int
+}
+
+var (
+	bands     map[string]Band // bands by lower case scientific name
+	bandsOnce sync.Once
+)
+
+// Lookup returns the frequency band of a species. The species may be given as a BirdNET label,
+// e.g. "Parus major_Great Tit_gretit1", or as a scientific name. Labels of all locales share
+// the scientific name, so bands are found regardless of the label language.
+func Lookup(species string) (Band, bool) {
+	bandsOnce.Do(func() {
+		var err error
+		if bands, err = parseBands(bandsCSV); err != nil {
+			log.Printf("Failed to load species frequency bands: %v", err)
+		}
+	})
+	band, ok := bands[scientificName(species)]
+	return band, ok
+}
+
+// parseBands parses frequency bands from CSV with label, min_hz and max_hz columns.
+func parseBands(data []byte) (map[string]Band, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse frequency bands: %w", err)
+	}
+
+	result := make(map[string]Band, len(records))
+	for i, record := range records {
+		if i == 0 && record[0] == "label" {
+			continue // header
+		}
+		minHz, errMin := strconv.Atoi(record[1])
+		maxHz, errMax := strconv.Atoi(record[2])
+		if errMin != nil || errMax != nil || minHz < 0 || maxHz <= minHz {
+			return nil, fmt.Errorf("invalid frequency band for %s: %s-%s", record[0], record[1], record[2])
+		}
+		result[scientificName(record[0])] = Band{MinHz: minHz, MaxHz: maxHz}
+	}
+	return result, nil
+}
+
+// scientificName returns the lower case scientific name part of a BirdNET label.
+func scientificName(label string) string {
+	name, _, _ := strings.Cut(label, "_")
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,64 @@
+package frequencyband
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"os"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	for _, species := range []string{"Parus major_Great Tit_gretit1", "Parus major", "parus major_Kohlmeise"} {
+		band, ok := Lookup(species)
+		if !ok || band.MinHz != 2500 || band.MaxHz != 8000 {
+			t.Errorf("Lookup(%q) = %+v, %v", species, band, ok)
+		}
+	}
+	if _, ok := Lookup("Unknown species"); ok {
+		t.Error("expected no band for unknown species")
+	}
+}
+
+func TestBandsMatchLabels(t *testing.T) {
+	// Every band must be keyed by a label of the bundled BirdNET labels
+	data, err := os.ReadFile("../birdnet/data/labels.zip")
+	if err != nil {
+		t.Skipf("labels not available: %v", err)
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open labels: %v", err)
+	}
+	labels := make(map[string]bool)
+	for _, file := range zipReader.File {
+		if file.Name != "labels_en.txt" {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			labels[scanner.Text()] = true
+		}
+		f.Close()
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bandsCSV))
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse bands: %v", err)
+	}
+	for _, record := range records[1:] {
+		if !labels[record[0]] {
+			t.Errorf("band label %q is not a BirdNET label", record[0])
+		}
+	}
+	if _, err := parseBands(bandsCSV); err != nil {
+		t.Error(err)
+	}
+}
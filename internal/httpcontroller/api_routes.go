@@ -0,0 +1,72 @@
+// internal/httpcontroller/api_routes.go
+package httpcontroller
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/hub"
+)
+
+// apiV1Prefix is the path prefix of the versioned JSON REST API.
+const apiV1Prefix = "/api/v1"
+
+// OpenAPI description of the v1 REST API.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// initAPIRoutes registers the JSON REST API routes.
+func (s *Server) initAPIRoutes() {
+	h := s.Handlers
+	api := s.Echo.Group(apiV1Prefix)
+
+	// API description
+	api.GET("/openapi.yaml", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "application/yaml", openAPISpec)
+	})
+
+	// Read-only endpoints
+	api.GET("/detections", h.APIListDetections)
+	api.GET("/detections/stream", h.APIDetectionStream)
+	api.GET("/detections/ws", h.APIDetectionWebSocket)
+	api.GET("/detections/:id", h.APIGetDetection)
+	api.GET("/species", h.APIListSpecies)
+	api.GET("/statistics/daily", h.APIDailySummary)
+	api.GET("/statistics/hourly", h.APIHourlyOccurrences)
+	api.GET("/weather/hourly", h.APIHourlyWeather)
+	api.GET("/hub/nodes", h.APIListNodes)
+	api.GET("/dynamicthresholds", h.APIListDynamicThresholds)
+	api.GET("/rangefilter/species", h.APIRangeFilterSpecies)
+	api.GET("/rangefilter/history", h.APIRangeFilterHistory)
+	api.GET("/rejected", h.APIListRejectedDetections)
+
+	// Detections pushed by remote nodes, authenticated with node tokens
+	s.Echo.POST(hub.IngestPath, h.APIHubIngest)
+
+	// Write endpoints, protected by the authentication middleware
+	api.POST("/detections/:id/review", h.APIReviewDetection, s.AuthMiddleware)
+	api.POST("/detections/:id/lock", h.APILockDetection, s.AuthMiddleware)
+	api.DELETE("/detections/:id", h.APIDeleteDetection, s.AuthMiddleware)
+	api.DELETE("/dynamicthresholds", h.APIResetDynamicThreshold, s.AuthMiddleware)
+	api.DELETE("/dynamicthresholds/:species", h.APIResetDynamicThreshold, s.AuthMiddleware)
+	api.POST("/rejected/:id/promote", h.APIPromoteRejectedDetection, s.AuthMiddleware)
+}
+
+// isAPIRoute reports whether the path belongs to the JSON REST API.
+func isAPIRoute(path string) bool {
+	return strings.HasPrefix(path, apiV1Prefix+"/")
+}
+
+// isStreamRoute reports whether the path is a long-lived live detection stream.
+func isStreamRoute(path string) bool {
+	return path == apiV1Prefix+"/detections/stream" || path == apiV1Prefix+"/detections/ws"
+}
+
+// isProtectedAPIRequest reports whether the request modifies data through the REST API. Nodes
+// pushing detections to the hub authenticate with their node token instead.
+func isProtectedAPIRequest(c echo.Context) bool {
+	return isAPIRoute(c.Path()) && c.Request().Method != http.MethodGet && c.Path() != hub.IngestPath
+}
@@ -0,0 +1,528 @@
+// api.go: JSON REST API handlers served under /api/v1
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"gorm.io/gorm"
+)
+
+// API pagination limits
+const (
+	apiDefaultLimit = 25
+	apiMaxLimit     = 1000
+)
+
+// APIError is the JSON error body returned by all API endpoints.
+type APIError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+// Pagination describes the window of a paginated API response.
+type Pagination struct {
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+	Total  int64 `json:"total"`
+}
+
+// DetectionListResponse is the paginated response of the detections endpoint.
+type DetectionListResponse struct {
+	Data       []Detection `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// Detection is the API representation of a datastore.Note.
+type Detection struct {
+	ID             uint      `json:"id"`
+	SourceNode     string    `json:"sourceNode,omitempty"`
+	Source         string    `json:"source,omitempty"`
+	Channel        int       `json:"channel,omitempty"`
+	Date           string    `json:"date"`
+	Time           string    `json:"time"`
+	Timestamp      time.Time `json:"timestamp"`
+	TimeZone       string    `json:"timeZone,omitempty"`
+	BeginTime      time.Time `json:"beginTime"`
+	EndTime        time.Time `json:"endTime"`
+	SpeciesCode    string    `json:"speciesCode,omitempty"`
+	ScientificName string    `json:"scientificName"`
+	CommonName     string    `json:"commonName"`
+	Confidence     float64   `json:"confidence"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	Threshold      float64   `json:"threshold"`
+	Sensitivity    float64   `json:"sensitivity"`
+	ClipName       string    `json:"clipName,omitempty"`
+	Event          string    `json:"event,omitempty"`
+	Verified       string    `json:"verified,omitempty"`
+	Locked         bool      `json:"locked"`
+	Comments       []string  `json:"comments,omitempty"`
+}
+
+// SpeciesSummary holds the daily detection summary for a single species.
+type SpeciesSummary struct {
+	ScientificName  string  `json:"scientificName"`
+	CommonName      string  `json:"commonName"`
+	HourlyCounts    [24]int `json:"hourlyCounts"`
+	TotalDetections int     `json:"totalDetections"`
+}
+
+// DailySummaryResponse is the response of the daily statistics endpoint.
+type DailySummaryResponse struct {
+	Date          string           `json:"date"`
+	MinConfidence float64          `json:"minConfidence"`
+	Species       []SpeciesSummary `json:"species"`
+}
+
+// HourlyOccurrencesResponse is the response of the hourly statistics endpoint.
+type HourlyOccurrencesResponse struct {
+	Date          string  `json:"date"`
+	Species       string  `json:"species"`
+	MinConfidence float64 `json:"minConfidence"`
+	HourlyCounts  [24]int `json:"hourlyCounts"`
+	Total         int     `json:"total"`
+}
+
+// HourlyWeatherEntry is the API representation of a datastore.HourlyWeather row.
+type HourlyWeatherEntry struct {
+	Time        time.Time `json:"time"`
+	Temperature float64   `json:"temperature"`
+	FeelsLike   float64   `json:"feelsLike"`
+	TempMin     float64   `json:"tempMin"`
+	TempMax     float64   `json:"tempMax"`
+	Pressure    int       `json:"pressure"`
+	Humidity    int       `json:"humidity"`
+	Visibility  int       `json:"visibility"`
+	WindSpeed   float64   `json:"windSpeed"`
+	WindDeg     int       `json:"windDeg"`
+	WindGust    float64   `json:"windGust"`
+	Clouds      int       `json:"clouds"`
+	WeatherMain string    `json:"weatherMain"`
+	WeatherDesc string    `json:"weatherDesc"`
+	WeatherIcon string    `json:"weatherIcon"`
+}
+
+// APIReviewRequest is the body accepted by the review endpoint.
+type APIReviewRequest struct {
+	Verified string `json:"verified" form:"verified"` // "correct" or "false_positive"
+	Comment  string `json:"comment" form:"comment"`
+	Lock     bool   `json:"lock" form:"lock"`
+}
+
+// APILockRequest is the body accepted by the lock endpoint.
+type APILockRequest struct {
+	Locked bool `json:"locked" form:"locked"`
+}
+
+// apiError writes a JSON error response and logs the underlying error.
+func (h *Handlers) apiError(c echo.Context, err error, message string, code int) error {
+	h.logError(&HandlerError{Err: err, Message: message, Code: code})
+	return c.JSON(code, APIError{
+		Error:   http.StatusText(code),
+		Message: message,
+		Code:    code,
+	})
+}
+
+// parsePagination reads the limit and offset query parameters, applying defaults and bounds.
+func parsePagination(c echo.Context) (limit, offset int) {
+	limit = parseNumDetections(c.QueryParam("limit"), apiDefaultLimit)
+	if limit > apiMaxLimit {
+		limit = apiMaxLimit
+	}
+	offset = parseOffset(c.QueryParam("offset"), 0)
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// parseMinConfidence reads the min_confidence query parameter given as a percentage and
+// returns it normalized to the 0.0-1.0 range used by the datastore.
+func parseMinConfidence(c echo.Context) (float64, error) {
+	value := c.QueryParam("min_confidence")
+	if value == "" {
+		return 0, nil
+	}
+	minConfidence, err := strconv.ParseFloat(value, 64)
+	if err != nil || minConfidence < 0 || minConfidence > 100 {
+		return 0, fmt.Errorf("min_confidence must be a number between 0 and 100")
+	}
+	return minConfidence / 100.0, nil
+}
+
+// parseAPIDate reads the date query parameter, defaulting to the current date.
+func parseAPIDate(c echo.Context) (string, error) {
+	date := c.QueryParam("date")
+	if date == "" {
+		return getCurrentDate(), nil
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return "", fmt.Errorf("date must be in YYYY-MM-DD format")
+	}
+	return date, nil
+}
+
+// newDetection converts a datastore note into its API representation.
+func newDetection(note *datastore.Note) Detection {
+	d := Detection{
+		ID:             note.ID,
+		SourceNode:     note.SourceNode,
+		Source:         note.Source,
+		Channel:        note.Channel,
+		Date:           note.Date,
+		Time:           note.Time,
+		Timestamp:      note.Timestamp,
+		TimeZone:       note.TimeZone,
+		BeginTime:      note.BeginTime,
+		EndTime:        note.EndTime,
+		SpeciesCode:    note.SpeciesCode,
+		ScientificName: note.ScientificName,
+		CommonName:     note.CommonName,
+		Confidence:     note.Confidence,
+		Latitude:       note.Latitude,
+		Longitude:      note.Longitude,
+		Threshold:      note.Threshold,
+		Sensitivity:    note.Sensitivity,
+		ClipName:       note.ClipName,
+		Event:          note.DetectionEvent,
+		Verified:       note.Verified,
+		Locked:         note.Locked || note.Lock != nil,
+	}
+	if d.Verified == "" && note.Review != nil {
+		d.Verified = note.Review.Verified
+	}
+	for i := range note.Comments {
+		d.Comments = append(d.Comments, note.Comments[i].Entry)
+	}
+	return d
+}
+
+// newDetections converts a slice of datastore notes into API detections.
+func newDetections(notes []datastore.Note) []Detection {
+	detections := make([]Detection, 0, len(notes))
+	for i := range notes {
+		detections = append(detections, newDetection(&notes[i]))
+	}
+	return detections
+}
+
+// APIListDetections returns detections filtered by search query, species, date and hour.
+// Without filters the most recent detections are returned.
+func (h *Handlers) APIListDetections(c echo.Context) error {
+	limit, offset := parsePagination(c)
+	search := c.QueryParam("search")
+	species := c.QueryParam("species")
+	date := c.QueryParam("date")
+	hour := c.QueryParam("hour")
+
+	duration := 1
+	if durationStr := c.QueryParam("duration"); durationStr != "" {
+		d, err := strconv.Atoi(durationStr)
+		if err != nil || d < 1 || d > 24 {
+			return h.apiError(c, err, "duration must be an integer between 1 and 24", http.StatusBadRequest)
+		}
+		duration = d
+	}
+
+	if hour != "" {
+		if hourInt, err := strconv.Atoi(hour); err != nil || hourInt < 0 || hourInt > 23 {
+			return h.apiError(c, err, "hour must be an integer between 0 and 23", http.StatusBadRequest)
+		}
+	}
+
+	ds := h.nodeStore(c)
+	var notes []datastore.Note
+	var total int64
+	var err error
+
+	switch {
+	case search != "":
+		notes, err = ds.SearchNotes(search, false, limit, offset)
+		if err == nil {
+			total, err = ds.CountSearchResults(search)
+		}
+	case species != "":
+		if date == "" {
+			date = getCurrentDate()
+		}
+		notes, err = ds.SpeciesDetections(species, date, hour, duration, false, limit, offset)
+		if err == nil {
+			total, err = ds.CountSpeciesDetections(species, date, hour, duration)
+		}
+	case date != "" && hour != "":
+		notes, err = ds.GetHourlyDetectionsPage(date, hour, duration, limit, offset)
+		if err == nil {
+			total, err = ds.CountHourlyDetections(date, hour, duration)
+		}
+	case date != "" || hour != "":
+		return h.apiError(c, fmt.Errorf("incomplete filter"), "date and hour must be given together unless species or search is set", http.StatusBadRequest)
+	default:
+		notes, err = ds.GetDetectionsPage(limit, offset)
+		if err == nil {
+			total, err = ds.CountDetections()
+		}
+	}
+
+	if err != nil {
+		return h.apiError(c, err, "Failed to get detections", http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, DetectionListResponse{
+		Data: newDetections(notes),
+		Pagination: Pagination{
+			Limit:  limit,
+			Offset: offset,
+			Total:  total,
+		},
+	})
+}
+
+// APIGetDetection returns a single detection by ID.
+func (h *Handlers) APIGetDetection(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return h.apiError(c, err, "Invalid detection ID", http.StatusBadRequest)
+	}
+
+	note, err := h.DS.Get(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return h.apiError(c, err, "Detection not found", http.StatusNotFound)
+		}
+		return h.apiError(c, err, "Failed to get detection", http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, newDetection(&note))
+}
+
+// APIDeleteDetection deletes a detection and its audio clip and spectrogram.
+func (h *Handlers) APIDeleteDetection(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return h.apiError(c, err, "Invalid detection ID", http.StatusBadRequest)
+	}
+
+	clipPath, err := h.DS.GetNoteClipPath(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return h.apiError(c, err, "Detection not found", http.StatusNotFound)
+		}
+		return h.apiError(c, err, "Failed to get clip path", http.StatusInternalServerError)
+	}
+
+	locked, err := h.DS.IsNoteLocked(id)
+	if err != nil {
+		return h.apiError(c, err, "Failed to check lock status", http.StatusInternalServerError)
+	}
+	if locked {
+		return h.apiError(c, fmt.Errorf("note %s is locked", id), "Detection is locked", http.StatusConflict)
+	}
+
+	if err := h.DS.Delete(id); err != nil {
+		return h.apiError(c, err, "Failed to delete detection", http.StatusInternalServerError)
+	}
+
+	h.deleteClipFiles(clipPath)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// APIReviewDetection sets the review status and comment of a detection, optionally locking it.
+func (h *Handlers) APIReviewDetection(c echo.Context) error {
+	noteID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return h.apiError(c, err, "Invalid detection ID", http.StatusBadRequest)
+	}
+
+	req := new(APIReviewRequest)
+	if err := c.Bind(req); err != nil {
+		return h.apiError(c, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if req.Verified != "" && req.Verified != "correct" && req.Verified != "false_positive" {
+		return h.apiError(c, fmt.Errorf("invalid verified value %q", req.Verified), "verified must be \"correct\" or \"false_positive\"", http.StatusBadRequest)
+	}
+
+	// Retry configuration for SQLite locking
+	maxRetries := 5
+	baseDelay := 100 * time.Millisecond
+
+	if err := h.processComment(uint(noteID), req.Comment, maxRetries, baseDelay); err != nil {
+		return h.apiError(c, err, "Failed to save comment", http.StatusInternalServerError)
+	}
+
+	if err := h.processReview(uint(noteID), req.Verified, req.Lock, maxRetries, baseDelay); err != nil {
+		return h.apiError(c, err, "Failed to save review", http.StatusInternalServerError)
+	}
+
+	note, err := h.DS.Get(strconv.FormatUint(noteID, 10))
+	if err != nil {
+		return h.apiError(c, err, "Failed to get detection", http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, newDetection(&note))
+}
+
+// APILockDetection locks or unlocks a detection.
+func (h *Handlers) APILockDetection(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return h.apiError(c, err, "Invalid detection ID", http.StatusBadRequest)
+	}
+
+	req := new(APILockRequest)
+	if err := c.Bind(req); err != nil {
+		return h.apiError(c, err, "Invalid request body", http.StatusBadRequest)
+	}
+
+	if _, err := h.DS.Get(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return h.apiError(c, err, "Detection not found", http.StatusNotFound)
+		}
+		return h.apiError(c, err, "Failed to get detection", http.StatusInternalServerError)
+	}
+
+	if req.Locked {
+		if err := h.DS.LockNote(id); err != nil {
+			return h.apiError(c, err, "Failed to lock detection", http.StatusInternalServerError)
+		}
+	} else {
+		if err := h.DS.UnlockNote(id); err != nil {
+			return h.apiError(c, err, "Failed to unlock detection", http.StatusInternalServerError)
+		}
+	}
+
+	note, err := h.DS.Get(id)
+	if err != nil {
+		return h.apiError(c, err, "Failed to get detection", http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, newDetection(&note))
+}
+
+// APIListSpecies returns the scientific names of all species ever detected.
+func (h *Handlers) APIListSpecies(c echo.Context) error {
+	notes, err := h.nodeStore(c).GetAllDetectedSpecies()
+	if err != nil {
+		return h.apiError(c, err, "Failed to get species list", http.StatusInternalServerError)
+	}
+
+	species := make([]string, 0, len(notes))
+	for i := range notes {
+		species = append(species, notes[i].ScientificName)
+	}
+
+	return c.JSON(http.StatusOK, species)
+}
+
+// APIDailySummary returns the per-species detection summary for a date, including hourly counts.
+func (h *Handlers) APIDailySummary(c echo.Context) error {
+	date, err := parseAPIDate(c)
+	if err != nil {
+		return h.apiError(c, err, err.Error(), http.StatusBadRequest)
+	}
+	minConfidence, err := parseMinConfidence(c)
+	if err != nil {
+		return h.apiError(c, err, err.Error(), http.StatusBadRequest)
+	}
+
+	ds := h.nodeStore(c)
+	notes, err := ds.GetTopBirdsData(date, minConfidence)
+	if err != nil {
+		return h.apiError(c, err, "Failed to get top birds data", http.StatusInternalServerError)
+	}
+
+	notesWithIndex, err := h.processNotes(ds, notes, date, minConfidence)
+	if err != nil {
+		return h.apiError(c, err, "Failed to get hourly occurrences", http.StatusInternalServerError)
+	}
+
+	summaries := make([]SpeciesSummary, 0, len(notesWithIndex))
+	for i := range notesWithIndex {
+		summaries = append(summaries, SpeciesSummary{
+			ScientificName:  notesWithIndex[i].ScientificName,
+			CommonName:      notesWithIndex[i].CommonName,
+			HourlyCounts:    notesWithIndex[i].HourlyCounts,
+			TotalDetections: notesWithIndex[i].TotalDetections,
+		})
+	}
+
+	return c.JSON(http.StatusOK, DailySummaryResponse{
+		Date:          date,
+		MinConfidence: minConfidence,
+		Species:       summaries,
+	})
+}
+
+// APIHourlyOccurrences returns the hourly detection counts of a species on a date.
+func (h *Handlers) APIHourlyOccurrences(c echo.Context) error {
+	species := c.QueryParam("species")
+	if species == "" {
+		return h.apiError(c, fmt.Errorf("missing species"), "species parameter is required", http.StatusBadRequest)
+	}
+	date, err := parseAPIDate(c)
+	if err != nil {
+		return h.apiError(c, err, err.Error(), http.StatusBadRequest)
+	}
+	minConfidence, err := parseMinConfidence(c)
+	if err != nil {
+		return h.apiError(c, err, err.Error(), http.StatusBadRequest)
+	}
+
+	hourlyCounts, err := h.nodeStore(c).GetHourlyOccurrences(date, species, minConfidence)
+	if err != nil {
+		return h.apiError(c, err, "Failed to get hourly occurrences", http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, HourlyOccurrencesResponse{
+		Date:          date,
+		Species:       species,
+		MinConfidence: minConfidence,
+		HourlyCounts:  hourlyCounts,
+		Total:         sumHourlyCounts(&hourlyCounts),
+	})
+}
+
+// APIHourlyWeather returns the stored hourly weather observations for a date.
+func (h *Handlers) APIHourlyWeather(c echo.Context) error {
+	date, err := parseAPIDate(c)
+	if err != nil {
+		return h.apiError(c, err, err.Error(), http.StatusBadRequest)
+	}
+
+	hourlyWeather, err := h.DS.GetHourlyWeather(date)
+	if err != nil {
+		return h.apiError(c, err, "Failed to get hourly weather", http.StatusInternalServerError)
+	}
+
+	entries := make([]HourlyWeatherEntry, 0, len(hourlyWeather))
+	for i := range hourlyWeather {
+		w := &hourlyWeather[i]
+		entries = append(entries, HourlyWeatherEntry{
+			Time:        w.Time,
+			Temperature: w.Temperature,
+			FeelsLike:   w.FeelsLike,
+			TempMin:     w.TempMin,
+			TempMax:     w.TempMax,
+			Pressure:    w.Pressure,
+			Humidity:    w.Humidity,
+			Visibility:  w.Visibility,
+			WindSpeed:   w.WindSpeed,
+			WindDeg:     w.WindDeg,
+			WindGust:    w.WindGust,
+			Clouds:      w.Clouds,
+			WeatherMain: w.WeatherMain,
+			WeatherDesc: w.WeatherDesc,
+			WeatherIcon: w.WeatherIcon,
+		})
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,115 @@
+// File: internal/httpcontroller/handlers/audiolevel.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// audioLevelClientBuffer is the number of audio levels buffered per SSE client, levels are
+// dropped for clients that are not keeping up
+const audioLevelClientBuffer = 16
+
+// AudioLevelBroadcaster delivers the audio levels of all capture sources to every connected
+// client.
+type AudioLevelBroadcaster struct {
+	mu      sync.Mutex
+	clients map[chan myaudio.AudioLevelData]struct{}
+}
+
+// NewAudioLevelBroadcaster creates an AudioLevelBroadcaster without clients.
+func NewAudioLevelBroadcaster() *AudioLevelBroadcaster {
+	return &AudioLevelBroadcaster{
+		clients: make(map[chan myaudio.AudioLevelData]struct{}),
+	}
+}
+
+// Publish sends a level to all clients without blocking.
+func (b *AudioLevelBroadcaster) Publish(level myaudio.AudioLevelData) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for client := range b.clients {
+		select {
+		case client <- level:
+		default:
+			// Client is not keeping up, levels are only useful when current
+		}
+	}
+}
+
+// Subscribe registers a new client.
+func (b *AudioLevelBroadcaster) Subscribe() chan myaudio.AudioLevelData {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	client := make(chan myaudio.AudioLevelData, audioLevelClientBuffer)
+	b.clients[client] = struct{}{}
+	return client
+}
+
+// Unsubscribe removes a client registered with Subscribe.
+func (b *AudioLevelBroadcaster) Unsubscribe(client chan myaudio.AudioLevelData) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.clients, client)
+}
+
+// AudioLevelSSE handles Server-Sent Events for real-time audio level updates. Levels of all
+// sources are sent unless the source query parameter selects some of them.
+func (h *Handlers) AudioLevelSSE(c echo.Context) error {
+	sources := queryValueSet(c.QueryParams()["source"], false)
+
+	client := h.AudioLevels.Subscribe()
+	defer h.AudioLevels.Unsubscribe(client)
+
+	// Set headers for SSE
+	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			// Client disconnected
+			return nil
+		case audioData := <-client:
+			if len(sources) > 0 && !sources[audioData.Source] {
+				continue
+			}
+
+			// Prepare data structure for JSON encoding
+			data := struct {
+				Level    int    `json:"level"`
+				Clipping bool   `json:"clipping"`
+				Source   string `json:"source"`
+			}{
+				Level:    audioData.Level,
+				Clipping: audioData.Clipping,
+				Source:   audioData.Source,
+			}
+
+			// Marshal data to JSON
+			jsonData, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+
+			// Write SSE formatted data
+			if _, err := fmt.Fprintf(c.Response(), "data: %s\n\n", jsonData); err != nil {
+				return err
+			}
+
+			// Flush the response writer buffer
+			c.Response().Flush()
+		}
+	}
+}
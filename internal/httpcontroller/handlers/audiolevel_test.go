@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+func TestAudioLevelBroadcaster(t *testing.T) {
+	levels := NewAudioLevelBroadcaster()
+	first := levels.Subscribe()
+	second := levels.Subscribe()
+
+	levels.Publish(myaudio.AudioLevelData{Level: 42, Source: "porch"})
+	for _, client := range []chan myaudio.AudioLevelData{first, second} {
+		if level := <-client; level.Level != 42 || level.Source != "porch" {
+			t.Errorf("Unexpected level %+v", level)
+		}
+	}
+
+	// Publishing never blocks on clients that are not reading
+	levels.Unsubscribe(second)
+	for i := 0; i < audioLevelClientBuffer*2; i++ {
+		levels.Publish(myaudio.AudioLevelData{Level: i})
+	}
+	if len(first) != audioLevelClientBuffer {
+		t.Errorf("Expected %d buffered levels, got %d", audioLevelClientBuffer, len(first))
+	}
+	levels.Unsubscribe(first)
+}
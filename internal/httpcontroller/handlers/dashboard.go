@@ -42,14 +42,15 @@ func (h *Handlers) TopBirds(c echo.Context) error {
 	}
 	minConfidenceNormalized := minConfidence / 100.0
 
-	// Get top birds data from the database
-	notes, err := h.DS.GetTopBirdsData(selectedDate, minConfidenceNormalized)
+	// Get top birds data from the database, limited to one node if requested
+	ds := h.nodeStore(c)
+	notes, err := ds.GetTopBirdsData(selectedDate, minConfidenceNormalized)
 	if err != nil {
 		return h.NewHandlerError(err, "Failed to get top birds data", http.StatusInternalServerError)
 	}
 
 	// Process notes with additional data such as hourly occurrences and total detections
-	notesWithIndex, err := h.ProcessNotes(notes, selectedDate, minConfidenceNormalized)
+	notesWithIndex, err := h.processNotes(ds, notes, selectedDate, minConfidenceNormalized)
 	if err != nil {
 		return h.NewHandlerError(err, "Failed to process notes", http.StatusInternalServerError)
 	}
@@ -111,10 +112,15 @@ func closestHour(t time.Time) int {
 
 // Additional helper functions (processNotes, makeHoursSlice, updateClipNames, etc.) go here...
 func (h *Handlers) ProcessNotes(notes []datastore.Note, selectedDate string, minConfidenceNormalized float64) ([]NoteWithIndex, error) {
+	return h.processNotes(h.DS, notes, selectedDate, minConfidenceNormalized)
+}
+
+// processNotes adds the hourly occurrences and total detections read from ds to the notes.
+func (h *Handlers) processNotes(ds datastore.Interface, notes []datastore.Note, selectedDate string, minConfidenceNormalized float64) ([]NoteWithIndex, error) {
 	startTime := time.Now()
 	notesWithIndex := make([]NoteWithIndex, 0, len(notes))
 	for i := range notes {
-		hourlyCounts, err := h.DS.GetHourlyOccurrences(selectedDate, notes[i].CommonName, minConfidenceNormalized)
+		hourlyCounts, err := ds.GetHourlyOccurrences(selectedDate, notes[i].CommonName, minConfidenceNormalized)
 		if err != nil {
 			return nil, err // Return error to be handled by the caller
 		}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -14,6 +15,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification"
 	"github.com/tphakala/birdnet-go/internal/weather"
 	"gorm.io/gorm"
 )
@@ -179,7 +181,7 @@ func (h *Handlers) DetectionDetails(c echo.Context) error {
 	const width = 1000 // pixels
 
 	// Generate the spectrogram path for the note
-	spectrogramPath, err := h.getSpectrogramPath(note.ClipName, width)
+	spectrogramPath, err := h.getSpectrogramPath(note.ClipName, h.spectrogramOptions(width), h.Settings.Realtime.Dashboard.Spectrogram.Renderer == "sox")
 	if err != nil {
 		h.logError(&HandlerError{Err: err, Message: fmt.Sprintf("Error generating spectrogram for %s", note.ClipName), Code: http.StatusInternalServerError})
 		spectrogramPath = "" // Set to empty string to avoid breaking the template
@@ -210,7 +212,7 @@ func (h *Handlers) RecentDetections(c echo.Context) error {
 	numDetections := parseNumDetections(c.QueryParam("numDetections"), 10)
 	h.Debug("RecentDetections: Fetching %d detections", numDetections)
 
-	notes, err := h.DS.GetLastDetections(numDetections)
+	notes, err := h.nodeStore(c).GetLastDetections(numDetections)
 	if err != nil {
 		h.Debug("RecentDetections: Error fetching detections: %v", err)
 		return h.NewHandlerError(err, "Failed to fetch recent detections", http.StatusInternalServerError)
@@ -315,7 +317,7 @@ func (h *Handlers) DeleteDetection(c echo.Context) error {
 	id := c.QueryParam("id")
 
 	if id == "" {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: "Missing detection ID",
 			Type:    "error",
 		})
@@ -326,7 +328,7 @@ func (h *Handlers) DeleteDetection(c echo.Context) error {
 	clipPath, err := h.DS.GetNoteClipPath(id)
 	if err != nil {
 		h.Debug("Failed to get clip path: %v", err)
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed to get clip path: %v", err),
 			Type:    "error",
 		})
@@ -336,7 +338,7 @@ func (h *Handlers) DeleteDetection(c echo.Context) error {
 	// Delete the note from the database
 	if err := h.DS.Delete(id); err != nil {
 		h.Debug("Failed to delete note %s: %v", id, err)
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed to delete note: %v", err),
 			Type:    "error",
 		})
@@ -344,25 +346,13 @@ func (h *Handlers) DeleteDetection(c echo.Context) error {
 	}
 
 	// If there was a clip associated, delete the audio file and spectrogram
-	if clipPath != "" {
-		// Delete audio file
-		audioPath := fmt.Sprintf("%s/%s", h.Settings.Realtime.Audio.Export.Path, clipPath)
-		if err := os.Remove(audioPath); err != nil && !os.IsNotExist(err) {
-			h.Debug("Failed to delete audio file %s: %v", audioPath, err)
-		}
-
-		// Delete spectrogram file
-		spectrogramPath := fmt.Sprintf("%s/%s.png", h.Settings.Realtime.Audio.Export.Path, strings.TrimSuffix(clipPath, ".wav"))
-		if err := os.Remove(spectrogramPath); err != nil && !os.IsNotExist(err) {
-			h.Debug("Failed to delete spectrogram file %s: %v", spectrogramPath, err)
-		}
-	}
+	h.deleteClipFiles(clipPath)
 
 	// Log the successful deletion
 	h.Debug("Successfully deleted detection %s", id)
 
 	// Send success notification
-	h.SSE.SendNotification(Notification{
+	h.SSE.SendNotification(notification.Notification{
 		Message: "Detection deleted successfully",
 		Type:    "success",
 	})
@@ -373,6 +363,30 @@ func (h *Handlers) DeleteDetection(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+// deleteClipFiles removes the audio clip and its spectrogram from the export directory
+func (h *Handlers) deleteClipFiles(clipPath string) {
+	if clipPath == "" {
+		return
+	}
+
+	// Delete audio file
+	audioPath := fmt.Sprintf("%s/%s", h.Settings.Realtime.Audio.Export.Path, clipPath)
+	if err := os.Remove(audioPath); err != nil && !os.IsNotExist(err) {
+		h.Debug("Failed to delete audio file %s: %v", audioPath, err)
+	}
+
+	// Delete spectrogram files, spectrograms of each size are cached next to the clip
+	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+	for _, pattern := range []string{base + ".png", base + "_*.png", base + "_*.webp"} {
+		spectrogramPaths, _ := filepath.Glob(pattern)
+		for _, spectrogramPath := range spectrogramPaths {
+			if err := os.Remove(spectrogramPath); err != nil && !os.IsNotExist(err) {
+				h.Debug("Failed to delete spectrogram file %s: %v", spectrogramPath, err)
+			}
+		}
+	}
+}
+
 // handleSpeciesExclusion handles the logic for managing species in the exclusion list
 func (h *Handlers) handleSpeciesExclusion(note *datastore.Note, verified, ignoreSpecies string) error {
 	settings := conf.Setting()
@@ -391,7 +405,7 @@ func (h *Handlers) handleSpeciesExclusion(note *datastore.Note, verified, ignore
 			return fmt.Errorf("failed to save settings: %w", err)
 		}
 
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("%s added to ignore list", ignoreSpecies),
 			Type:    "success",
 		})
@@ -399,7 +413,7 @@ func (h *Handlers) handleSpeciesExclusion(note *datastore.Note, verified, ignore
 		// Check if species is in exclude list
 		for _, s := range settings.Realtime.Species.Exclude {
 			if s == note.CommonName {
-				h.SSE.SendNotification(Notification{
+				h.SSE.SendNotification(notification.Notification{
 					Message: fmt.Sprintf("%s is currently in ignore list. You may want to remove it from Settings.", note.CommonName),
 					Type:    "warning",
 				})
@@ -418,7 +432,7 @@ func (h *Handlers) processComment(noteID uint, comment string, maxRetries int, b
 
 	// Validate comment
 	if len(comment) > 1000 {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: "Comment exceeds maximum length of 1000 characters",
 			Type:    "error",
 		})
@@ -608,7 +622,7 @@ func (h *Handlers) ReviewDetection(c echo.Context) error {
 	// Handle comment first (this doesn't require review status)
 	if err := h.processComment(uint(noteID), comment, maxRetries, baseDelay); err != nil {
 		h.Debug("ReviewDetection: Failed to process comment: %v", err)
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed to process comment: %v", err),
 			Type:    "error",
 		})
@@ -620,7 +634,7 @@ func (h *Handlers) ReviewDetection(c echo.Context) error {
 		h.Debug("ReviewDetection: Processing species exclusion for %s", ignoreSpecies)
 		if err := h.handleSpeciesExclusion(nil, verified, ignoreSpecies); err != nil {
 			h.Debug("ReviewDetection: Failed to handle species exclusion: %v", err)
-			h.SSE.SendNotification(Notification{
+			h.SSE.SendNotification(notification.Notification{
 				Message: fmt.Sprintf("Failed to handle species exclusion: %v", err),
 				Type:    "error",
 			})
@@ -631,7 +645,7 @@ func (h *Handlers) ReviewDetection(c echo.Context) error {
 	// Handle review status if provided
 	if err := h.processReview(uint(noteID), verified, lockDetection, maxRetries, baseDelay); err != nil {
 		h.Debug("ReviewDetection: Failed to process review: %v", err)
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed to process review: %v", err),
 			Type:    "error",
 		})
@@ -640,7 +654,7 @@ func (h *Handlers) ReviewDetection(c echo.Context) error {
 
 	if verified != "" {
 		// Send success notification
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: "Review saved successfully",
 			Type:    "success",
 		})
@@ -654,7 +668,7 @@ func (h *Handlers) ReviewDetection(c echo.Context) error {
 func (h *Handlers) LockDetection(c echo.Context) error {
 	id := c.QueryParam("id")
 	if id == "" {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: "Missing detection ID",
 			Type:    "error",
 		})
@@ -664,7 +678,7 @@ func (h *Handlers) LockDetection(c echo.Context) error {
 	// Get the current note first to have the species name for messages
 	note, err := h.DS.Get(id)
 	if err != nil {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed to get detection: %v", err),
 			Type:    "error",
 		})
@@ -681,7 +695,7 @@ func (h *Handlers) LockDetection(c echo.Context) error {
 	// Check current lock status first
 	isLocked, err := h.DS.IsNoteLocked(id)
 	if err != nil {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed to check lock status: %v", err),
 			Type:    "error",
 		})
@@ -711,7 +725,7 @@ func (h *Handlers) LockDetection(c echo.Context) error {
 				lastErr = err
 				continue
 			}
-			h.SSE.SendNotification(Notification{
+			h.SSE.SendNotification(notification.Notification{
 				Message: err.Error(),
 				Type:    "error",
 			})
@@ -724,7 +738,7 @@ func (h *Handlers) LockDetection(c echo.Context) error {
 
 	// Check if all retries failed
 	if lastErr != nil {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed after %d attempts: %v", maxRetries, lastErr),
 			Type:    "error",
 		})
@@ -732,7 +746,7 @@ func (h *Handlers) LockDetection(c echo.Context) error {
 	}
 
 	// Send success notification
-	h.SSE.SendNotification(Notification{
+	h.SSE.SendNotification(notification.Notification{
 		Message: message,
 		Type:    "success",
 	})
@@ -0,0 +1,360 @@
+// File: internal/httpcontroller/handlers/detectionstream.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+const (
+	detectionReplaySize    = 100              // events kept for clients resuming with Last-Event-ID
+	detectionClientBuffer  = 32               // events buffered per client before it is disconnected
+	detectionStreamPing    = 30 * time.Second // heartbeat interval of stream connections
+	detectionWriteTimeout  = 10 * time.Second // write deadline of WebSocket messages
+	detectionWeatherMaxAge = 3 * time.Hour    // older weather observations are not attached to events
+)
+
+// DetectionEvent is a saved detection delivered to live stream clients.
+type DetectionEvent struct {
+	ID           uint64              `json:"id"`
+	Detection    Detection           `json:"detection"`
+	ThumbnailURL string              `json:"thumbnailUrl,omitempty"`
+	ClipURL      string              `json:"clipUrl,omitempty"`
+	Weather      *HourlyWeatherEntry `json:"weather,omitempty"`
+}
+
+// DetectionStream broadcasts detection events to connected clients and keeps the most
+// recent events in a ring buffer so reconnecting clients can replay what they missed.
+type DetectionStream struct {
+	mu      sync.Mutex
+	lastID  uint64           // seeded with the start time so that IDs keep increasing across restarts
+	replay  []DetectionEvent // ring buffer of the most recent events
+	next    int              // index of the next write in replay
+	full    bool             // replay has wrapped around
+	clients map[chan DetectionEvent]struct{}
+}
+
+// NewDetectionStream creates a DetectionStream that keeps replaySize events for replay.
+// Event IDs start from the current time in milliseconds, so a client resuming with the ID
+// of an event published before a restart gets the events of the new run replayed instead
+// of missing them. This holds as long as a run publishes less than one event per
+// millisecond on average, far above any detection rate.
+func NewDetectionStream(replaySize int) *DetectionStream {
+	return &DetectionStream{
+		lastID:  uint64(time.Now().UnixMilli()),
+		replay:  make([]DetectionEvent, replaySize),
+		clients: make(map[chan DetectionEvent]struct{}),
+	}
+}
+
+// Publish assigns the next event ID, stores the event for replay and delivers it to all
+// clients. Clients that are not keeping up are disconnected so they can resume from the
+// replay buffer instead of silently missing events.
+func (s *DetectionStream) Publish(event DetectionEvent) DetectionEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	event.ID = s.lastID
+
+	if len(s.replay) > 0 {
+		s.replay[s.next] = event
+		s.next = (s.next + 1) % len(s.replay)
+		if s.next == 0 {
+			s.full = true
+		}
+	}
+
+	for client := range s.clients {
+		select {
+		case client <- event:
+		default:
+			delete(s.clients, client)
+			close(client)
+		}
+	}
+	return event
+}
+
+// Subscribe registers a new client and returns its event channel together with the buffered
+// events published after lastEventID. The channel is closed when the client falls behind.
+func (s *DetectionStream) Subscribe(lastEventID uint64) (client chan DetectionEvent, missed []DetectionEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lastEventID > 0 && lastEventID < s.lastID {
+		for _, event := range s.buffered() {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	client = make(chan DetectionEvent, detectionClientBuffer)
+	s.clients[client] = struct{}{}
+	return client, missed
+}
+
+// Unsubscribe removes a client registered with Subscribe.
+func (s *DetectionStream) Unsubscribe(client chan DetectionEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[client]; ok {
+		delete(s.clients, client)
+		close(client)
+	}
+}
+
+// buffered returns the events of the replay buffer from oldest to newest.
+func (s *DetectionStream) buffered() []DetectionEvent {
+	if s.full {
+		return append(append([]DetectionEvent{}, s.replay[s.next:]...), s.replay[:s.next]...)
+	}
+	return s.replay[:s.next]
+}
+
+// detectionFilter selects the events sent to a stream client.
+type detectionFilter struct {
+	nodes   map[string]bool // source nodes, empty matches all
+	sources map[string]bool // audio sources, empty matches all
+	species map[string]bool // lower case common names, scientific names or species codes, empty matches all
+}
+
+// parseDetectionFilter reads the node, source and species query parameters. All accept repeated
+// parameters and comma separated lists.
+func parseDetectionFilter(c echo.Context) detectionFilter {
+	params := c.QueryParams()
+	return detectionFilter{
+		nodes:   queryValueSet(params["node"], false),
+		sources: queryValueSet(params["source"], false),
+		species: queryValueSet(params["species"], true),
+	}
+}
+
+// queryValueSet splits comma separated query values into a set.
+func queryValueSet(values []string, lowerCase bool) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if lowerCase {
+				item = strings.ToLower(item)
+			}
+			if item != "" {
+				set[item] = true
+			}
+		}
+	}
+	return set
+}
+
+// matches reports whether the event passes the filter.
+func (f detectionFilter) matches(event *DetectionEvent) bool {
+	if len(f.nodes) > 0 && !f.nodes[event.Detection.SourceNode] {
+		return false
+	}
+	if len(f.sources) > 0 && !f.sources[event.Detection.Source] {
+		return false
+	}
+	if len(f.species) > 0 &&
+		!f.species[strings.ToLower(event.Detection.CommonName)] &&
+		!f.species[strings.ToLower(event.Detection.ScientificName)] &&
+		!f.species[strings.ToLower(event.Detection.SpeciesCode)] {
+		return false
+	}
+	return true
+}
+
+// parseLastEventID returns the ID of the last event received by a resuming client, read from
+// the Last-Event-ID header sent by EventSource or from the last_event_id query parameter.
+func parseLastEventID(c echo.Context) uint64 {
+	value := c.Request().Header.Get("Last-Event-ID")
+	if value == "" {
+		value = c.QueryParam("last_event_id")
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// newDetectionEvent builds a stream event for a saved note, attaching the thumbnail, the
+// audio clip and the latest weather observation when available.
+func (h *Handlers) newDetectionEvent(note *datastore.Note) DetectionEvent {
+	event := DetectionEvent{
+		Detection:    newDetection(note),
+		ThumbnailURL: h.Thumbnail(note.ScientificName),
+	}
+	if note.ClipName != "" {
+		event.ClipURL = "/media/audio?clip=" + url.QueryEscape(note.ClipName)
+	}
+
+	if h.DS != nil {
+		w, err := h.DS.LatestHourlyWeather()
+		if err == nil && w != nil && time.Since(w.Time) < detectionWeatherMaxAge {
+			event.Weather = &HourlyWeatherEntry{
+				Time:        w.Time,
+				Temperature: w.Temperature,
+				FeelsLike:   w.FeelsLike,
+				TempMin:     w.TempMin,
+				TempMax:     w.TempMax,
+				Pressure:    w.Pressure,
+				Humidity:    w.Humidity,
+				Visibility:  w.Visibility,
+				WindSpeed:   w.WindSpeed,
+				WindDeg:     w.WindDeg,
+				WindGust:    w.WindGust,
+				Clouds:      w.Clouds,
+				WeatherMain: w.WeatherMain,
+				WeatherDesc: w.WeatherDesc,
+				WeatherIcon: w.WeatherIcon,
+			}
+		}
+	}
+	return event
+}
+
+// APIDetectionStream streams saved detections as Server-Sent Events. Each event carries its
+// ID so EventSource clients resume after a reconnect through the Last-Event-ID header.
+func (h *Handlers) APIDetectionStream(c echo.Context) error {
+	filter := parseDetectionFilter(c)
+	client, missed := h.DetectionStream.Subscribe(parseLastEventID(c))
+	defer h.DetectionStream.Unsubscribe(client)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	send := func(event *DetectionEvent) error {
+		if !filter.matches(event) {
+			return nil
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(res, "id: %d\nevent: detection\ndata: %s\n\n", event.ID, data); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	}
+
+	for i := range missed {
+		if err := send(&missed[i]); err != nil {
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(detectionStreamPing)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case event, ok := <-client:
+			if !ok {
+				// Dropped for falling behind, the client reconnects and replays
+				return nil
+			}
+			if err := send(&event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ":\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// detectionUpgrader upgrades detection stream requests to WebSocket connections. The stream
+// carries the same public data as the read-only REST API, so any origin is accepted to allow
+// external displays and kiosks.
+var detectionUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// APIDetectionWebSocket streams saved detections as JSON messages over a WebSocket. Clients
+// resume after a reconnect with the last_event_id query parameter.
+func (h *Handlers) APIDetectionWebSocket(c echo.Context) error {
+	filter := parseDetectionFilter(c)
+	lastEventID := parseLastEventID(c)
+
+	conn, err := detectionUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		return nil
+	}
+	defer conn.Close()
+
+	client, missed := h.DetectionStream.Subscribe(lastEventID)
+	defer h.DetectionStream.Unsubscribe(client)
+
+	// Read until the client goes away, messages from the client are ignored
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event *DetectionEvent) error {
+		if !filter.matches(event) {
+			return nil
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(detectionWriteTimeout))
+		return conn.WriteJSON(event)
+	}
+
+	for i := range missed {
+		if err := send(&missed[i]); err != nil {
+			return nil
+		}
+	}
+
+	ping := time.NewTicker(detectionStreamPing)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case event, ok := <-client:
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client fell behind"),
+					time.Now().Add(detectionWriteTimeout))
+				return nil
+			}
+			if err := send(&event); err != nil {
+				return nil
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(detectionWriteTimeout)); err != nil {
+				return nil
+			}
+		}
+	}
+}
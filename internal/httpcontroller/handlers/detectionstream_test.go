@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDetectionStreamReplay(t *testing.T) {
+	stream := NewDetectionStream(3)
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, stream.Publish(DetectionEvent{Detection: Detection{CommonName: "Robin"}}).ID)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] != ids[i-1]+1 {
+			t.Fatalf("Expected consecutive event IDs, got %v", ids)
+		}
+	}
+
+	// Only the last three events are buffered
+	client, missed := stream.Subscribe(ids[0])
+	if len(missed) != 3 || missed[0].ID != ids[2] || missed[2].ID != ids[4] {
+		t.Fatalf("Unexpected replay %+v", missed)
+	}
+	stream.Unsubscribe(client)
+
+	client, missed = stream.Subscribe(ids[3])
+	if len(missed) != 1 || missed[0].ID != ids[4] {
+		t.Fatalf("Unexpected replay after event %d: %+v", ids[3], missed)
+	}
+
+	// New clients without Last-Event-ID get no replay
+	other, missed := stream.Subscribe(0)
+	if len(missed) != 0 {
+		t.Fatalf("Expected no replay for new client, got %d events", len(missed))
+	}
+	stream.Unsubscribe(other)
+
+	stream.Publish(DetectionEvent{})
+	if event := <-client; event.ID != ids[4]+1 {
+		t.Errorf("Expected live event %d, got %d", ids[4]+1, event.ID)
+	}
+	stream.Unsubscribe(client)
+}
+
+func TestDetectionStreamRestart(t *testing.T) {
+	before := NewDetectionStream(3)
+	var lastEventID uint64
+	for i := 0; i < 5; i++ {
+		lastEventID = before.Publish(DetectionEvent{}).ID
+	}
+
+	// A client resuming with an event ID of the previous run gets every event of the new run
+	time.Sleep(10 * time.Millisecond)
+	after := NewDetectionStream(3)
+	first := after.Publish(DetectionEvent{})
+	if first.ID <= lastEventID {
+		t.Fatalf("Expected event IDs to increase across restarts, got %d after %d", first.ID, lastEventID)
+	}
+	client, missed := after.Subscribe(lastEventID)
+	if len(missed) != 1 || missed[0].ID != first.ID {
+		t.Errorf("Expected the event of the new run to be replayed, got %+v", missed)
+	}
+	after.Unsubscribe(client)
+}
+
+func TestDetectionStreamDropsSlowClients(t *testing.T) {
+	stream := NewDetectionStream(detectionReplaySize)
+	client, _ := stream.Subscribe(0)
+
+	for i := 0; i <= detectionClientBuffer; i++ {
+		stream.Publish(DetectionEvent{})
+	}
+
+	received := 0
+	for range client {
+		received++
+	}
+	if received != detectionClientBuffer {
+		t.Errorf("Expected %d buffered events before disconnect, got %d", detectionClientBuffer, received)
+	}
+
+	// Unsubscribing a dropped client is safe
+	stream.Unsubscribe(client)
+}
+
+func TestDetectionFilter(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest("GET", "/api/v1/detections/stream?node=hub&node=backyard&source=yard&species=Turdus%20merula,eurrob1", nil)
+	filter := parseDetectionFilter(e.NewContext(req, httptest.NewRecorder()))
+
+	tests := []struct {
+		detection Detection
+		want      bool
+	}{
+		{Detection{SourceNode: "hub", Source: "yard", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"}, true},
+		{Detection{SourceNode: "backyard", Source: "yard", SpeciesCode: "eurrob1", CommonName: "European Robin"}, true},
+		{Detection{SourceNode: "forest", Source: "yard", ScientificName: "Turdus merula"}, false},
+		{Detection{SourceNode: "hub", Source: "garden", ScientificName: "Turdus merula"}, false},
+		{Detection{SourceNode: "hub", Source: "yard", ScientificName: "Parus major"}, false},
+	}
+
+	for _, tt := range tests {
+		event := DetectionEvent{Detection: tt.detection}
+		if got := filter.matches(&event); got != tt.want {
+			t.Errorf("matches(%+v) = %v, want %v", tt.detection, got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -15,6 +14,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/notification"
 	"github.com/tphakala/birdnet-go/internal/security"
 	"github.com/tphakala/birdnet-go/internal/suncalc"
 )
@@ -29,11 +29,14 @@ type Handlers struct {
 	DashboardSettings *conf.Dashboard
 	BirdImageCache    *imageprovider.BirdImageCache
 	SSE               *SSEHandler                 // Server Side Events handler
+	DetectionStream   *DetectionStream            // Live stream of saved detections
 	SunCalc           *suncalc.SunCalc            // SunCalc instance for calculating sun event times
 	AudioLevelChan    chan myaudio.AudioLevelData // Channel for audio level updates
+	AudioLevels       *AudioLevelBroadcaster      // Fan-out of audio levels to SSE clients
+	DynamicThresholds DynamicThresholdManager     // Dynamic thresholds of the detection processor, may be nil
 	OAuth2Server      *security.OAuth2Server
 	controlChan       chan string
-	notificationChan  chan Notification
+	notificationChan  chan notification.Notification
 	CloudflareAccess  *security.CloudflareAccess
 	debug             bool
 	Server            interface{ IsAccessAllowed(c echo.Context) bool }
@@ -79,12 +82,12 @@ func (bh *baseHandler) logInfo(message string) {
 }
 
 // New creates a new Handlers instance with the given dependencies.
-func New(ds datastore.Interface, settings *conf.Settings, dashboardSettings *conf.Dashboard, birdImageCache *imageprovider.BirdImageCache, logger *log.Logger, sunCalc *suncalc.SunCalc, audioLevelChan chan myaudio.AudioLevelData, oauth2Server *security.OAuth2Server, controlChan chan string, notificationChan chan Notification, server interface{ IsAccessAllowed(c echo.Context) bool }) *Handlers {
+func New(ds datastore.Interface, settings *conf.Settings, dashboardSettings *conf.Dashboard, birdImageCache *imageprovider.BirdImageCache, logger *log.Logger, sunCalc *suncalc.SunCalc, audioLevelChan chan myaudio.AudioLevelData, oauth2Server *security.OAuth2Server, controlChan chan string, notificationChan chan notification.Notification, detectionChan chan datastore.Note, server interface{ IsAccessAllowed(c echo.Context) bool }) *Handlers {
 	if logger == nil {
 		logger = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
 	}
 
-	return &Handlers{
+	h := &Handlers{
 		baseHandler: baseHandler{
 			errorHandler: defaultErrorHandler,
 			logger:       logger,
@@ -94,8 +97,10 @@ func New(ds datastore.Interface, settings *conf.Settings, dashboardSettings *con
 		DashboardSettings: dashboardSettings,
 		BirdImageCache:    birdImageCache,
 		SSE:               NewSSEHandler(),
+		DetectionStream:   NewDetectionStream(detectionReplaySize),
 		SunCalc:           sunCalc,
 		AudioLevelChan:    audioLevelChan,
+		AudioLevels:       NewAudioLevelBroadcaster(),
 		OAuth2Server:      oauth2Server,
 		controlChan:       controlChan,
 		notificationChan:  notificationChan,
@@ -103,6 +108,35 @@ func New(ds datastore.Interface, settings *conf.Settings, dashboardSettings *con
 		debug:             settings.Debug,
 		Server:            server,
 	}
+
+	// Deliver notifications from other components to SSE clients
+	if notificationChan != nil {
+		go func() {
+			for msg := range notificationChan {
+				h.SSE.SendNotification(msg)
+			}
+		}()
+	}
+
+	// Deliver audio levels of all sources to SSE clients
+	if audioLevelChan != nil {
+		go func() {
+			for level := range audioLevelChan {
+				h.AudioLevels.Publish(level)
+			}
+		}()
+	}
+
+	// Publish saved detections to live stream clients
+	if detectionChan != nil {
+		go func() {
+			for note := range detectionChan {
+				h.DetectionStream.Publish(h.newDetectionEvent(&note))
+			}
+		}()
+	}
+
+	return h
 }
 
 // defaultErrorHandler is the default implementation of error handling.
@@ -196,46 +230,6 @@ func (h *Handlers) WithErrorHandling(fn func(echo.Context) error) echo.HandlerFu
 	}
 }
 
-// AudioLevelSSE handles Server-Sent Events for real-time audio level updates
-func (h *Handlers) AudioLevelSSE(c echo.Context) error {
-	// Set headers for SSE
-	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
-	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
-	c.Response().Header().Set(echo.HeaderConnection, "keep-alive")
-	c.Response().WriteHeader(http.StatusOK)
-
-	for {
-		select {
-		case <-c.Request().Context().Done():
-			// Client disconnected
-			return nil
-		case audioData := <-h.AudioLevelChan:
-			// Prepare data structure for JSON encoding
-			data := struct {
-				Level    int  `json:"level"`
-				Clipping bool `json:"clipping"`
-			}{
-				Level:    audioData.Level,
-				Clipping: audioData.Clipping,
-			}
-
-			// Marshal data to JSON
-			jsonData, err := json.Marshal(data)
-			if err != nil {
-				return err
-			}
-
-			// Write SSE formatted data
-			if _, err := fmt.Fprintf(c.Response(), "data: %s\n\n", jsonData); err != nil {
-				return err
-			}
-
-			// Flush the response writer buffer
-			c.Response().Flush()
-		}
-	}
-}
-
 // GetLabels returns the list of all available species labels
 func (h *Handlers) GetLabels() []string {
 	return h.Settings.BirdNET.Labels
@@ -0,0 +1,90 @@
+// hub.go: API handlers of the multi-node hub
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/hub"
+)
+
+// hubMaxSubmissionSize limits the size of a detection pushed by a node, including its clip
+const hubMaxSubmissionSize = 16 << 20
+
+// NodeSummary is the API representation of the detections of a node.
+type NodeSummary struct {
+	Node       string    `json:"node"`
+	Detections int64     `json:"detections"`
+	Species    int64     `json:"species"`
+	LastSeen   time.Time `json:"lastSeen"`
+}
+
+// nodeStore returns the datastore limited to the node given in the node query parameter, or
+// the full datastore if no node is given.
+func (h *Handlers) nodeStore(c echo.Context) datastore.Interface {
+	if node := c.QueryParam("node"); node != "" {
+		return h.DS.ForNode(node)
+	}
+	return h.DS
+}
+
+// APIHubIngest stores a detection pushed by a remote node. Nodes authenticate with the bearer
+// token configured for them on the hub, and their detections are stored under that node name.
+func (h *Handlers) APIHubIngest(c echo.Context) error {
+	if !h.Settings.Realtime.Hub.Server.Enabled {
+		return h.apiError(c, fmt.Errorf("hub is disabled"), "Hub is not enabled", http.StatusNotFound)
+	}
+
+	node, ok := hub.Authenticate(h.Settings, c.Request().Header.Get(echo.HeaderAuthorization))
+	if !ok {
+		return h.apiError(c, fmt.Errorf("invalid hub token from %s", c.RealIP()), "Invalid node token", http.StatusUnauthorized)
+	}
+
+	var sub hub.Submission
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, hubMaxSubmissionSize)
+	if err := json.NewDecoder(body).Decode(&sub); err != nil {
+		return h.apiError(c, err, "Invalid detection", http.StatusBadRequest)
+	}
+
+	note, duplicate, err := hub.Ingest(h.DS, h.Settings, node, &sub)
+	if err != nil {
+		if errors.Is(err, hub.ErrInvalidSubmission) {
+			return h.apiError(c, err, err.Error(), http.StatusBadRequest)
+		}
+		return h.apiError(c, err, "Failed to store detection", http.StatusInternalServerError)
+	}
+	if duplicate {
+		return c.JSON(http.StatusOK, hub.IngestResponse{Duplicate: true})
+	}
+
+	// Remote detections appear in the live detection stream of the hub
+	if h.DetectionStream != nil {
+		h.DetectionStream.Publish(h.newDetectionEvent(note))
+	}
+
+	return c.JSON(http.StatusCreated, hub.IngestResponse{ID: note.ID})
+}
+
+// APIListNodes returns the detection statistics of each node whose detections are stored.
+func (h *Handlers) APIListNodes(c echo.Context) error {
+	stats, err := h.DS.GetNodeStatistics()
+	if err != nil {
+		return h.apiError(c, err, "Failed to get node statistics", http.StatusInternalServerError)
+	}
+
+	nodes := make([]NodeSummary, 0, len(stats))
+	for i := range stats {
+		nodes = append(nodes, NodeSummary{
+			Node:       stats[i].Node,
+			Detections: stats[i].Detections,
+			Species:    stats[i].Species,
+			LastSeen:   stats[i].LastSeen,
+		})
+	}
+	return c.JSON(http.StatusOK, nodes)
+}
@@ -14,11 +14,18 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/frequencyband"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/observation"
 )
 
 // MaxClipNameLength is the maximum allowed length for a clip name
@@ -219,10 +226,184 @@ func (h *Handlers) ThumbnailAttribution(scientificName string) template.HTML {
 	return template.HTML(attribution)
 }
 
-// ServeSpectrogram serves or generates a spectrogram for a given clip.
+// Spectrogram sizes accepted by the spectrogram endpoint
+const (
+	defaultSpectrogramWidth = 400
+	minSpectrogramWidth     = 100
+	maxSpectrogramWidth     = 2000
+	minSpectrogramHeight    = 50
+	maxSpectrogramHeight    = 1000
+)
+
+// cachedSpectrogramWidths are the spectrogram widths used by the web UI. Spectrograms are
+// cached next to their clip only at these widths with the configured height and frequency
+// range, other sizes are rendered for each request so that requests cannot fill the disk.
+var cachedSpectrogramWidths = []int{defaultSpectrogramWidth, 1000}
+
+// spectrogramOptions returns the configured spectrogram options for an image of the given
+// width, the height defaults to half the width.
+func (h *Handlers) spectrogramOptions(width int) myaudio.SpectrogramOptions {
+	settings := h.Settings.Realtime.Dashboard.Spectrogram
+	return myaudio.SpectrogramOptions{
+		Width:        width,
+		Height:       width / 2,
+		FFTSize:      settings.FFTSize,
+		MinFreq:      settings.MinFreq,
+		MaxFreq:      settings.MaxFreq,
+		ColorMap:     settings.ColorMap,
+		DynamicRange: settings.DynamicRange,
+		Format:       settings.Format,
+	}
+}
+
+// parseSpectrogramOptions returns the spectrogram options of a request. The width, height,
+// minfreq and maxfreq query parameters override the configured defaults.
+func (h *Handlers) parseSpectrogramOptions(c echo.Context) (myaudio.SpectrogramOptions, error) {
+	opts := h.spectrogramOptions(defaultSpectrogramWidth)
+	opts.Height = 0
+
+	params := []struct {
+		name     string
+		value    *int
+		min, max int
+	}{
+		{"width", &opts.Width, minSpectrogramWidth, maxSpectrogramWidth},
+		{"height", &opts.Height, minSpectrogramHeight, maxSpectrogramHeight},
+		{"minfreq", &opts.MinFreq, 0, conf.SampleRate / 2},
+		{"maxfreq", &opts.MaxFreq, 0, conf.SampleRate / 2},
+	}
+	for _, p := range params {
+		raw := c.QueryParam(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < p.min || v > p.max {
+			return opts, fmt.Errorf("%s must be between %d and %d", p.name, p.min, p.max)
+		}
+		*p.value = v
+	}
+
+	if opts.Height == 0 {
+		opts.Height = max(minSpectrogramHeight, opts.Width/2)
+	}
+	if opts.MinFreq >= opts.MaxFreq {
+		return opts, fmt.Errorf("minfreq must be lower than maxfreq")
+	}
+	return opts, nil
+}
+
+// isCachedSpectrogram reports whether a spectrogram with the given options is cached.
+func (h *Handlers) isCachedSpectrogram(opts *myaudio.SpectrogramOptions) bool {
+	if !slices.Contains(cachedSpectrogramWidths, opts.Width) {
+		return false
+	}
+	defaults := h.spectrogramOptions(opts.Width)
+	return opts.Height == defaults.Height && opts.MinFreq == defaults.MinFreq && opts.MaxFreq == defaults.MaxFreq
+}
+
+// parseAnnotationFlags returns whether the species frequency band and the prediction windows
+// are marked on a spectrogram. The band and windows query parameters override the configured
+// defaults.
+func (h *Handlers) parseAnnotationFlags(c echo.Context) (band, windows bool, err error) {
+	settings := h.Settings.Realtime.Dashboard.Spectrogram
+	band, windows = settings.ShowBand, settings.ShowWindows
+
+	params := []struct {
+		name  string
+		value *bool
+	}{
+		{"band", &band},
+		{"windows", &windows},
+	}
+	for _, p := range params {
+		raw := c.QueryParam(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, false, fmt.Errorf("%s must be true or false", p.name)
+		}
+		*p.value = v
+	}
+	return band, windows, nil
+}
+
+// spectrogramAnnotation returns the annotation of the detection saved in a clip, or nil if
+// the clip has no detection or its species has no known frequency band and windows are not
+// shown.
+func (h *Handlers) spectrogramAnnotation(clipName string, band, windows bool) *myaudio.SpectrogramAnnotation {
+	if !band && !windows {
+		return nil
+	}
+
+	note, err := h.DS.GetNoteByClipName(clipName)
+	if err != nil {
+		h.Debug("spectrogramAnnotation: No detection found for clip %s: %v", clipName, err)
+		return nil
+	}
+
+	annotation := &myaudio.SpectrogramAnnotation{}
+	if band {
+		if b, ok := frequencyband.Lookup(note.ScientificName); ok {
+			annotation.BandMinFreq, annotation.BandMaxFreq = b.MinHz, b.MaxHz
+		}
+	}
+	if windows {
+		annotation.Windows = predictionWindows(&note)
+	}
+	if annotation.BandMaxFreq == 0 && len(annotation.Windows) == 0 {
+		return nil
+	}
+	return annotation
+}
+
+// predictionWindows returns the prediction windows in which the species of a detection was
+// predicted, in seconds from the start of its clip. Windows are taken from the start times of
+// the stored results of the species, detections saved before these were recorded have none.
+func predictionWindows(note *datastore.Note) []myaudio.SpectrogramWindow {
+	clipLength := note.EndTime.Sub(note.BeginTime).Seconds()
+	if clipLength <= 0 {
+		return nil
+	}
+
+	var windows []myaudio.SpectrogramWindow
+	seen := make(map[time.Time]bool)
+	for _, result := range note.Results {
+		scientificName, _, _ := observation.ParseSpeciesString(result.Species)
+		if result.StartTime.IsZero() || seen[result.StartTime] || !strings.EqualFold(scientificName, note.ScientificName) {
+			continue
+		}
+		seen[result.StartTime] = true
+
+		start := result.StartTime.Sub(note.BeginTime).Seconds()
+		end := min(start+conf.CaptureLength, clipLength)
+		if start = max(start, 0); start < end {
+			windows = append(windows, myaudio.SpectrogramWindow{Start: start, End: end})
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start < windows[j].Start })
+	return windows
+}
+
+// ServeSpectrogram serves or generates a spectrogram for a given clip. The image size and
+// frequency range can be set with the width, height, minfreq and maxfreq query parameters,
+// the band and windows parameters mark the expected frequency band of the detected species
+// and the prediction windows of the detection. Only spectrograms of the sizes used by the web
+// UI are cached.
 func (h *Handlers) ServeSpectrogram(c echo.Context) error {
 	h.Debug("ServeSpectrogram: Handler called with URL: %s", c.Request().URL.String())
 
+	opts, err := h.parseSpectrogramOptions(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	showBand, showWindows, err := h.parseAnnotationFlags(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
 	// Extract clip name from the query parameters
 	clipName := c.QueryParam("clip")
 	h.Debug("ServeSpectrogram: Raw clip name from query: %s", clipName)
@@ -251,8 +432,18 @@ func (h *Handlers) ServeSpectrogram(c echo.Context) error {
 	}
 	h.Debug("ServeSpectrogram: Audio file exists at: %s", fullPath)
 
+	// Annotations are drawn by the native renderer only, SoX renders PNG images only
+	opts.Annotation = h.spectrogramAnnotation(sanitizedClipName, showBand, showWindows)
+	if !h.isCachedSpectrogram(&opts) {
+		return h.serveUncachedSpectrogram(c, fullPath, opts)
+	}
+	useSoX := h.Settings.Realtime.Dashboard.Spectrogram.Renderer == "sox" && opts.Annotation == nil
+	if useSoX {
+		opts.Format = "png"
+	}
+
 	// Construct the path to the spectrogram image
-	spectrogramPath, err := h.getSpectrogramPath(fullPath, 400) // Assuming 400px width
+	spectrogramPath, err := h.getSpectrogramPath(fullPath, opts, useSoX)
 	if err != nil {
 		h.Debug("ServeSpectrogram: Error getting spectrogram path: %v", err)
 		return c.File("assets/images/spectrogram-placeholder.svg")
@@ -267,10 +458,20 @@ func (h *Handlers) ServeSpectrogram(c echo.Context) error {
 	}
 	if !exists {
 		h.Debug("ServeSpectrogram: Spectrogram file not found, attempting to create it")
-		// Try to create the spectrogram
-		if err := createSpectrogramWithSoX(fullPath, spectrogramPath, 400); err != nil {
-			h.Debug("ServeSpectrogram: Failed to create spectrogram: %v", err)
-			return c.File("assets/images/spectrogram-placeholder.svg")
+		// Try to create the spectrogram, SoX falls back to the native renderer
+		created := false
+		if useSoX {
+			if err := createSpectrogramWithSoX(fullPath, spectrogramPath, opts.Width, opts.Height); err != nil {
+				h.Debug("ServeSpectrogram: Failed to create spectrogram with SoX: %v", err)
+			} else {
+				created = true
+			}
+		}
+		if !created {
+			if err := myaudio.CreateSpectrogram(fullPath, spectrogramPath, opts); err != nil {
+				h.Debug("ServeSpectrogram: Failed to create spectrogram: %v", err)
+				return c.File("assets/images/spectrogram-placeholder.svg")
+			}
 		}
 		h.Debug("ServeSpectrogram: Successfully created spectrogram at: %s", spectrogramPath)
 	}
@@ -286,8 +487,31 @@ func (h *Handlers) ServeSpectrogram(c echo.Context) error {
 	return c.File(spectrogramPath)
 }
 
-// getSpectrogramPath generates the path to the spectrogram image file for a given audio file
-func (h *Handlers) getSpectrogramPath(audioFileName string, width int) (string, error) {
+// serveUncachedSpectrogram renders a spectrogram with the native renderer and serves it
+// without saving it.
+func (h *Handlers) serveUncachedSpectrogram(c echo.Context, audioPath string, opts myaudio.SpectrogramOptions) error {
+	samples, err := myaudio.ReadAudioFile(audioPath)
+	if err != nil {
+		h.Debug("serveUncachedSpectrogram: Failed to read audio file: %v", err)
+		return c.File("assets/images/spectrogram-placeholder.svg")
+	}
+	img, err := myaudio.RenderSpectrogram(samples, conf.SampleRate, opts)
+	if err != nil {
+		h.Debug("serveUncachedSpectrogram: Failed to render spectrogram: %v", err)
+		return c.File("assets/images/spectrogram-placeholder.svg")
+	}
+
+	var buf bytes.Buffer
+	if err := myaudio.EncodeSpectrogram(&buf, img, opts.Format); err != nil {
+		h.Debug("serveUncachedSpectrogram: Failed to encode spectrogram: %v", err)
+		return c.File("assets/images/spectrogram-placeholder.svg")
+	}
+	return c.Blob(http.StatusOK, "image/"+opts.Format, buf.Bytes())
+}
+
+// getSpectrogramPath generates the path to the spectrogram image file for a given audio file.
+// Images of the cached sizes, annotations and renderers are cached separately.
+func (h *Handlers) getSpectrogramPath(audioFileName string, opts myaudio.SpectrogramOptions, sox bool) (string, error) {
 	// Clean the audio file path first
 	audioFileName = filepath.Clean(audioFileName)
 	h.Debug("getSpectrogramPath: Input audio path: %s", audioFileName)
@@ -314,7 +538,19 @@ func (h *Handlers) getSpectrogramPath(audioFileName string, width int) (string,
 	baseNameWithoutExt := strings.TrimSuffix(filepath.Base(audioFileName), filepath.Ext(audioFileName))
 	h.Debug("getSpectrogramPath: Base name without extension: %s", baseNameWithoutExt)
 
-	spectrogramFileName := fmt.Sprintf("%s_%dpx.png", baseNameWithoutExt, width)
+	var annotationSuffix string
+	if a := opts.Annotation; a != nil {
+		if a.BandMaxFreq > 0 {
+			annotationSuffix += "_band"
+		}
+		if len(a.Windows) > 0 {
+			annotationSuffix += "_windows"
+		}
+	}
+	spectrogramFileName := fmt.Sprintf("%s_%dx%d_%d-%dhz%s.%s", baseNameWithoutExt, opts.Width, opts.Height, opts.MinFreq, opts.MaxFreq, annotationSuffix, opts.Format)
+	if sox {
+		spectrogramFileName = fmt.Sprintf("%s_%dx%d_sox.png", baseNameWithoutExt, opts.Width, opts.Height)
+	}
 	h.Debug("getSpectrogramPath: Spectrogram filename: %s", spectrogramFileName)
 
 	// Join paths using OS-specific separators and clean the result
@@ -362,7 +598,7 @@ func fileExists(filename string) (bool, error) {
 
 // createSpectrogramWithSoX generates a spectrogram for an audio file using ffmpeg and SoX.
 // It supports various audio formats by using ffmpeg to pipe the audio to SoX when necessary.
-func createSpectrogramWithSoX(audioClipPath, spectrogramPath string, width int) error {
+func createSpectrogramWithSoX(audioClipPath, spectrogramPath string, width, height int) error {
 	// Get ffmpeg and sox paths from settings
 	ffmpegBinary := conf.Setting().Realtime.Audio.FfmpegPath
 	soxBinary := conf.Setting().Realtime.Audio.SoxPath
@@ -375,8 +611,7 @@ func createSpectrogramWithSoX(audioClipPath, spectrogramPath string, width int)
 		return fmt.Errorf("SoX path not set in settings")
 	}
 
-	// Set height based on width
-	heightStr := strconv.Itoa(width / 2)
+	heightStr := strconv.Itoa(height)
 	widthStr := strconv.Itoa(width)
 
 	// Determine if we need to use ffmpeg based on file extension
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+func TestPredictionWindows(t *testing.T) {
+	// Detected in chunks starting at 0, 1.5 and 3 s, clip spans 2 s before and after. The
+	// full results of the best chunk include other species.
+	begin := time.Date(2024, 5, 12, 6, 15, 0, 0, time.UTC)
+	first := begin.Add(2 * time.Second)
+	note := &datastore.Note{
+		ScientificName: "Parus major",
+		BeginTime:      begin,
+		EndTime:        begin.Add(10 * time.Second),
+		Results: []datastore.Results{
+			{Species: "Parus major_Great Tit_gretit1", Confidence: 0.9, StartTime: first.Add(1500 * time.Millisecond)},
+			{Species: "Cyanistes caeruleus_Eurasian Blue Tit_blutit", Confidence: 0.2, StartTime: first.Add(1500 * time.Millisecond)},
+			{Species: "Parus major_Great Tit_gretit1", Confidence: 0.8, StartTime: first.Add(3 * time.Second)},
+			{Species: "Parus major_Great Tit_gretit1", Confidence: 0.7, StartTime: first},
+		},
+	}
+
+	windows := predictionWindows(note)
+	want := []float64{2, 3.5, 5}
+	if len(windows) != len(want) {
+		t.Fatalf("expected %d windows, got %+v", len(want), windows)
+	}
+	for i, w := range windows {
+		if w.Start != want[i] || w.End != want[i]+conf.CaptureLength {
+			t.Errorf("window %d = %+v, want start %v", i, w, want[i])
+		}
+	}
+
+	// Windows are cut to the clip, e.g. when the clip was cut to its maximum length
+	note.EndTime = begin.Add(6 * time.Second)
+	windows = predictionWindows(note)
+	if last := windows[len(windows)-1]; last.End != 6 {
+		t.Errorf("expected last window to end at 6 s, got %+v", last)
+	}
+
+	// Results saved before windows were recorded have no start time
+	note.EndTime = begin.Add(10 * time.Second)
+	note.Results = []datastore.Results{{Species: "Parus major_Great Tit_gretit1", Confidence: 0.9}}
+	if windows := predictionWindows(note); windows != nil {
+		t.Errorf("expected no windows without result timing, got %+v", windows)
+	}
+}
+
+func TestIsCachedSpectrogram(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.Dashboard.Spectrogram.MinFreq = 0
+	settings.Realtime.Dashboard.Spectrogram.MaxFreq = 15000
+	h := &Handlers{Settings: settings}
+
+	cached := []func(o *myaudio.SpectrogramOptions){
+		func(o *myaudio.SpectrogramOptions) {},
+		func(o *myaudio.SpectrogramOptions) { *o = h.spectrogramOptions(1000) },
+	}
+	uncached := []func(o *myaudio.SpectrogramOptions){
+		func(o *myaudio.SpectrogramOptions) { *o = h.spectrogramOptions(401) },
+		func(o *myaudio.SpectrogramOptions) { o.Height = 300 },
+		func(o *myaudio.SpectrogramOptions) { o.MinFreq = 1000 },
+		func(o *myaudio.SpectrogramOptions) { o.MaxFreq = 12000 },
+	}
+	for i, modify := range append(cached, uncached...) {
+		opts := h.spectrogramOptions(defaultSpectrogramWidth)
+		modify(&opts)
+		if got, want := h.isCachedSpectrogram(&opts), i < len(cached); got != want {
+			t.Errorf("case %d: isCachedSpectrogram(%dx%d, %d-%d Hz) = %v, want %v",
+				i, opts.Width, opts.Height, opts.MinFreq, opts.MaxFreq, got, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// rangefilter.go: API handlers of the range filter species list and its history
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// Number of weeks of range filter history returned by default and at most
+const (
+	rangeFilterDefaultWeeks = 12
+	rangeFilterMaxWeeks     = 52
+)
+
+// RangeFilterSpecies is the API representation of a species included by the range filter.
+type RangeFilterSpecies struct {
+	Label          string  `json:"label"`
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	Score          float64 `json:"score"`
+	Reason         string  `json:"reason"`
+}
+
+// RangeFilterList is the species list the range filter currently allows.
+type RangeFilterList struct {
+	Updated   time.Time            `json:"updated"`
+	Week      int                  `json:"week"`
+	Threshold float32              `json:"threshold"`
+	Count     int                  `json:"count"`
+	Species   []RangeFilterSpecies `json:"species"`
+}
+
+// RangeFilterChange is the change of the range filter species list from the previous week.
+type RangeFilterChange struct {
+	Date    string   `json:"date"`
+	Week    int      `json:"week"`
+	Count   int64    `json:"count"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// APIRangeFilterSpecies returns the species the range filter currently allows, with their
+// occurrence scores and the reason each species is included.
+func (h *Handlers) APIRangeFilterSpecies(c echo.Context) error {
+	scores, week, updated := h.Settings.GetRangeFilterScores()
+
+	species := make([]RangeFilterSpecies, 0, len(scores))
+	for _, s := range scores {
+		scientificName, commonName := splitSpeciesLabel(s.Label)
+		species = append(species, RangeFilterSpecies{
+			Label:          s.Label,
+			ScientificName: scientificName,
+			CommonName:     commonName,
+			Score:          s.Score,
+			Reason:         s.Reason,
+		})
+	}
+
+	return c.JSON(http.StatusOK, RangeFilterList{
+		Updated:   updated,
+		Week:      week,
+		Threshold: h.Settings.BirdNET.RangeFilter.Threshold,
+		Count:     len(species),
+		Species:   species,
+	})
+}
+
+// APIRangeFilterHistory returns how the range filter species list changed from week to week,
+// newest week first. Each week is represented by the last species list computed in it.
+func (h *Handlers) APIRangeFilterHistory(c echo.Context) error {
+	weeks := rangeFilterDefaultWeeks
+	if value := c.QueryParam("weeks"); value != "" {
+		var err error
+		weeks, err = strconv.Atoi(value)
+		if err != nil || weeks < 1 || weeks > rangeFilterMaxWeeks {
+			return h.apiError(c, fmt.Errorf("invalid weeks: %s", value),
+				fmt.Sprintf("weeks must be between 1 and %d", rangeFilterMaxWeeks), http.StatusBadRequest)
+		}
+	}
+
+	snapshots, err := h.DS.GetRangeFilterSnapshots()
+	if err != nil {
+		return h.apiError(c, err, "Failed to get range filter history", http.StatusInternalServerError)
+	}
+
+	// Keep the newest snapshot of each week, plus one more week to compare the oldest with
+	var weekly []datastore.RangeFilterSnapshot
+	for _, s := range snapshots {
+		if len(weekly) > weeks {
+			break
+		}
+		if len(weekly) > 0 && weekly[len(weekly)-1].Week == s.Week {
+			continue
+		}
+		weekly = append(weekly, s)
+	}
+
+	labels := make([]map[string]bool, len(weekly))
+	for i, s := range weekly {
+		scores, err := h.DS.GetRangeFilterScores(s.Date)
+		if err != nil {
+			return h.apiError(c, err, "Failed to get range filter history", http.StatusInternalServerError)
+		}
+		labels[i] = make(map[string]bool, len(scores))
+		for _, score := range scores {
+			labels[i][score.Label] = true
+		}
+	}
+
+	changes := make([]RangeFilterChange, 0, weeks)
+	for i := 0; i < len(weekly) && i < weeks; i++ {
+		change := RangeFilterChange{
+			Date:    weekly[i].Date,
+			Week:    weekly[i].Week,
+			Count:   weekly[i].Species,
+			Added:   []string{},
+			Removed: []string{},
+		}
+		if i+1 < len(weekly) {
+			change.Added = labelDifference(labels[i], labels[i+1])
+			change.Removed = labelDifference(labels[i+1], labels[i])
+		}
+		changes = append(changes, change)
+	}
+
+	return c.JSON(http.StatusOK, changes)
+}
+
+// labelDifference returns the sorted labels of a that are not in b.
+func labelDifference(a, b map[string]bool) []string {
+	diff := []string{}
+	for label := range a {
+		if !b[label] {
+			diff = append(diff, label)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// splitSpeciesLabel splits a BirdNET label into scientific and common name.
+func splitSpeciesLabel(label string) (scientificName, commonName string) {
+	parts := strings.Split(label, "_")
+	scientificName = parts[0]
+	if len(parts) > 1 {
+		commonName = parts[1]
+	}
+	return scientificName, commonName
+}
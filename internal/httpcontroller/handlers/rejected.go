@@ -0,0 +1,122 @@
+// rejected.go: API handlers of the rejected detections log
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"gorm.io/gorm"
+)
+
+// rejectReasons are the rejection reasons accepted by the reason filter.
+var rejectReasons = []string{
+	datastore.RejectReasonThreshold,
+	datastore.RejectReasonRangeFilter,
+	datastore.RejectReasonMatches,
+	datastore.RejectReasonPrivacy,
+	datastore.RejectReasonDogBark,
+	datastore.RejectReasonTimeOfDay,
+}
+
+// RejectedDetection is the API representation of a datastore.RejectedDetection.
+type RejectedDetection struct {
+	ID             uint      `json:"id"`
+	Source         string    `json:"source,omitempty"`
+	Date           string    `json:"date"`
+	Time           string    `json:"time"`
+	Timestamp      time.Time `json:"timestamp"`
+	ScientificName string    `json:"scientificName"`
+	CommonName     string    `json:"commonName"`
+	Confidence     float64   `json:"confidence"`
+	Threshold      float64   `json:"threshold"`
+	Reason         string    `json:"reason"`
+	Matches        int       `json:"matches"`
+	NoteID         uint      `json:"noteId,omitempty"`
+}
+
+// RejectedDetectionListResponse is the paginated response of the rejected detections endpoint.
+type RejectedDetectionListResponse struct {
+	Data       []RejectedDetection `json:"data"`
+	Pagination Pagination          `json:"pagination"`
+}
+
+// APIListRejectedDetections returns detections discarded by filters, newest first,
+// optionally filtered by rejection reason.
+func (h *Handlers) APIListRejectedDetections(c echo.Context) error {
+	reason := c.QueryParam("reason")
+	if reason != "" && !slices.Contains(rejectReasons, reason) && !h.isSuppressorRule(reason) {
+		return h.apiError(c, fmt.Errorf("invalid reason: %s", reason), "Unknown rejection reason", http.StatusBadRequest)
+	}
+	limit, offset := parsePagination(c)
+
+	rejected, err := h.DS.GetRejectedDetections(reason, limit, offset)
+	if err != nil {
+		return h.apiError(c, err, "Failed to get rejected detections", http.StatusInternalServerError)
+	}
+	total, err := h.DS.CountRejectedDetections(reason)
+	if err != nil {
+		return h.apiError(c, err, "Failed to count rejected detections", http.StatusInternalServerError)
+	}
+
+	data := make([]RejectedDetection, 0, len(rejected))
+	for i := range rejected {
+		r := &rejected[i]
+		data = append(data, RejectedDetection{
+			ID:             r.ID,
+			Source:         r.Source,
+			Date:           r.Date,
+			Time:           r.Time,
+			Timestamp:      r.Timestamp,
+			ScientificName: r.ScientificName,
+			CommonName:     r.CommonName,
+			Confidence:     r.Confidence,
+			Threshold:      r.Threshold,
+			Reason:         r.Reason,
+			Matches:        r.Matches,
+			NoteID:         r.NoteID,
+		})
+	}
+
+	return c.JSON(http.StatusOK, RejectedDetectionListResponse{
+		Data:       data,
+		Pagination: Pagination{Limit: limit, Offset: offset, Total: total},
+	})
+}
+
+// isSuppressorRule reports whether name is the name of a configured suppressor rule.
+func (h *Handlers) isSuppressorRule(name string) bool {
+	for _, rule := range h.Settings.Realtime.Suppressors {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// APIPromoteRejectedDetection saves a rejected detection as a detection. Rejected detections
+// have no audio clip, so neither does the promoted detection.
+func (h *Handlers) APIPromoteRejectedDetection(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return h.apiError(c, err, "Invalid rejected detection ID", http.StatusBadRequest)
+	}
+
+	note, err := h.DS.PromoteRejectedDetection(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return h.apiError(c, err, "Rejected detection not found", http.StatusNotFound)
+		case errors.Is(err, datastore.ErrAlreadyPromoted):
+			return h.apiError(c, err, "Rejected detection was already promoted", http.StatusConflict)
+		}
+		return h.apiError(c, err, "Failed to promote rejected detection", http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, newDetection(&note))
+}
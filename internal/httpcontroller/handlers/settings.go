@@ -14,6 +14,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/notification"
 )
 
 // fieldsToSkip is a map of fields that should not be updated from the form
@@ -52,7 +53,7 @@ func (h *Handlers) SaveSettings(c echo.Context) error {
 	}
 
 	// Store old settings for comparison
-	oldSettings := *settings
+	oldSettings := settings.Snapshot()
 
 	formParams, err := c.FormParams()
 	if err != nil {
@@ -67,7 +68,7 @@ func (h *Handlers) SaveSettings(c echo.Context) error {
 
 	// Check if BirdNET settings have changed
 	if birdnetSettingsChanged(&oldSettings, settings) {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: "Reloading BirdNET model...",
 			Type:    "info",
 		})
@@ -77,17 +78,17 @@ func (h *Handlers) SaveSettings(c echo.Context) error {
 
 	// Check if range filter related settings have changed
 	if rangeFilterSettingsChanged(&oldSettings, settings) {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: "Rebuilding range filter...",
 			Type:    "info",
 		})
 		h.controlChan <- "rebuild_range_filter"
 	}
 
-	// Check if RTSP settings have changed
-	if rtspSettingsChanged(&oldSettings, settings) {
-		h.SSE.SendNotification(Notification{
-			Message: "Reconfiguring RTSP sources...",
+	// Check if RTSP or named audio source settings have changed
+	if audioSourcesChanged(&oldSettings, settings) {
+		h.SSE.SendNotification(notification.Notification{
+			Message: "Reconfiguring audio sources...",
 			Type:    "info",
 		})
 		h.controlChan <- "reconfigure_rtsp_sources"
@@ -97,9 +98,10 @@ func (h *Handlers) SaveSettings(c echo.Context) error {
 	h.updateAuthenticationSettings(settings)
 
 	// Check if audio equalizer settings have changed
-	if equalizerSettingsChanged(oldSettings.Realtime.Audio.Equalizer, settings.Realtime.Audio.Equalizer) {
+	if equalizerSettingsChanged(oldSettings.Realtime.Audio.Equalizer, settings.Realtime.Audio.Equalizer) ||
+		!reflect.DeepEqual(oldSettings.Realtime.Audio.Sources, settings.Realtime.Audio.Sources) {
 		if err := myaudio.UpdateFilterChain(settings); err != nil {
-			h.SSE.SendNotification(Notification{
+			h.SSE.SendNotification(notification.Notification{
 				Message: fmt.Sprintf("Error updating audio EQ filters: %v", err),
 				Type:    "error",
 			})
@@ -109,7 +111,7 @@ func (h *Handlers) SaveSettings(c echo.Context) error {
 
 	// Save settings to YAML file
 	if err := conf.SaveSettings(); err != nil {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Error saving settings: %v", err),
 			Type:    "error",
 		})
@@ -117,7 +119,7 @@ func (h *Handlers) SaveSettings(c echo.Context) error {
 	}
 
 	// Send success notification for applying and saving settings
-	h.SSE.SendNotification(Notification{
+	h.SSE.SendNotification(notification.Notification{
 		Message: "Settings saved and applied",
 		Type:    "success",
 	})
@@ -155,7 +157,7 @@ func (h *Handlers) updateAuthenticationSettings(settings *conf.Settings) {
 	// Format and validate the host address
 	host, err := formatAndValidateHost(settings.Security.Host, settings.Security.RedirectToHTTPS)
 	if err != nil {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: err.Error(),
 			Type:    "error",
 		})
@@ -190,10 +192,38 @@ func (h *Handlers) updateAuthenticationSettings(settings *conf.Settings) {
 	h.OAuth2Server.UpdateProviders()
 }
 
+// thresholdFields are the form fields of the settings that remote commands can change at
+// runtime, they are updated while holding the lock of those settings
+var thresholdFields = map[string]bool{
+	"birdnet.threshold":       true,
+	"realtime.species.config": true,
+	"realtime.audio.sources":  true,
+}
+
 // updateSettingsFromForm updates the settings based on form values
 func updateSettingsFromForm(settings *conf.Settings, formValues map[string][]string) error {
+	lockedValues := make(map[string][]string)
+	otherValues := make(map[string][]string, len(formValues))
+	for key, value := range formValues {
+		if thresholdFields[key] {
+			lockedValues[key] = value
+		} else {
+			otherValues[key] = value
+		}
+	}
+
 	// Delegate the update process to updateStructFromForm
-	return updateStructFromForm(reflect.ValueOf(settings).Elem(), formValues, "")
+	if err := updateStructFromForm(reflect.ValueOf(settings).Elem(), otherValues, ""); err != nil {
+		return err
+	}
+	if len(lockedValues) == 0 {
+		return nil
+	}
+	var err error
+	conf.UpdateThresholds(func() {
+		err = updateStructFromForm(reflect.ValueOf(settings).Elem(), lockedValues, "")
+	})
+	return err
 }
 
 // updateStructFromForm recursively updates a struct's fields from form values
@@ -308,6 +338,15 @@ func updateStructFromForm(v reflect.Value, formValues map[string][]string, prefi
 					}
 					field.Set(reflect.ValueOf(stringSlice))
 				}
+			} else if fieldType.Type.Elem().Kind() == reflect.Struct {
+				// Handle struct slices (e.g., dynamic threshold levels) sent as a JSON array
+				if len(formValue) > 0 {
+					newSlice := reflect.New(fieldType.Type)
+					if err := json.Unmarshal([]byte(formValue[0]), newSlice.Interface()); err != nil {
+						return fmt.Errorf("error unmarshaling JSON for %s: %w", fullName, err)
+					}
+					field.Set(newSlice.Elem())
+				}
 			} else {
 				// Handle other slice types
 				if err := updateSliceFromForm(field, formValue); err != nil {
@@ -636,8 +675,13 @@ func birdnetSettingsChanged(oldSettings, currentSettings *conf.Settings) bool {
 	return false
 }
 
-// rtspSettingsChanged checks if RTSP settings have been modified
-func rtspSettingsChanged(oldSettings, currentSettings *conf.Settings) bool {
+// audioSourcesChanged checks if RTSP or named audio source settings have been modified
+func audioSourcesChanged(oldSettings, currentSettings *conf.Settings) bool {
+	// Check for changes in named audio sources
+	if !reflect.DeepEqual(oldSettings.Realtime.Audio.Sources, currentSettings.Realtime.Audio.Sources) {
+		return true
+	}
+
 	// Check for changes in RTSP transport protocol
 	if oldSettings.Realtime.RTSP.Transport != currentSettings.Realtime.RTSP.Transport {
 		return true
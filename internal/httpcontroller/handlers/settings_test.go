@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestUpdateSettingsFromFormThresholds verifies that a form updates the settings changed by
+// remote commands while remote commands change them concurrently.
+func TestUpdateSettingsFromFormThresholds(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.BirdNET.Threshold = 0.8
+	settings.Realtime.Audio.Sources = []conf.AudioSourceSettings{{Name: "garden", Threshold: 0.5}}
+
+	form := map[string][]string{
+		"main.name":               {"backyard"},
+		"birdnet.threshold":       {"0.6"},
+		"realtime.species.config": {`{"eurasian blackbird": {"Threshold": 0.9}}`},
+	}
+
+	// Remote commands changing the thresholds while the form is applied
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			settings.SetSpeciesThreshold("great tit", 0.7)
+			settings.SetSourceThreshold("garden", 0.4)
+		}
+	}()
+	err := updateSettingsFromForm(settings, form)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("updateSettingsFromForm failed: %v", err)
+	}
+
+	if settings.Main.Name != "backyard" {
+		t.Errorf("expected node name backyard, got %q", settings.Main.Name)
+	}
+	if got := settings.GetThreshold(); got != 0.6 {
+		t.Errorf("expected threshold 0.6, got %v", got)
+	}
+	if config, ok := settings.GetSpeciesConfig("eurasian blackbird"); !ok || config.Threshold != 0.9 {
+		t.Errorf("expected species threshold 0.9 from the form, got %+v", config)
+	}
+	if sources := settings.AudioSources(); len(sources) != 1 || sources[0].Threshold != 0.4 {
+		t.Errorf("expected source threshold set by the remote command to be kept, got %+v", sources)
+	}
+}
@@ -6,13 +6,14 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/notification"
 )
 
 // IgnoreSpecies adds or removes a species from the excluded species list
 func (h *Handlers) IgnoreSpecies(c echo.Context) error {
 	commonName := c.QueryParam("common_name")
 	if commonName == "" {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: "Missing species name",
 			Type:    "error",
 		})
@@ -47,7 +48,7 @@ func (h *Handlers) IgnoreSpecies(c echo.Context) error {
 
 	// Save the settings
 	if err := conf.SaveSettings(); err != nil {
-		h.SSE.SendNotification(Notification{
+		h.SSE.SendNotification(notification.Notification{
 			Message: fmt.Sprintf("Failed to save settings: %v", err),
 			Type:    "error",
 		})
@@ -56,7 +57,7 @@ func (h *Handlers) IgnoreSpecies(c echo.Context) error {
 
 	// Send success notification
 	message := fmt.Sprintf("%s %s excluded species list", commonName, map[bool]string{true: "removed from", false: "added to"}[isExcluded])
-	h.SSE.SendNotification(Notification{
+	h.SSE.SendNotification(notification.Notification{
 		Message: message,
 		Type:    "success",
 	})
@@ -13,22 +13,18 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/notification"
 )
 
-type Notification struct {
-	Message string `json:"message"`
-	Type    string `json:"type"`
-}
-
 type SSEHandler struct {
-	clients    map[chan Notification]bool
+	clients    map[chan notification.Notification]bool
 	clientsMux sync.Mutex
 	debug      bool
 }
 
 func NewSSEHandler() *SSEHandler {
 	return &SSEHandler{
-		clients: make(map[chan Notification]bool),
+		clients: make(map[chan notification.Notification]bool),
 		debug:   conf.Setting().WebServer.Debug,
 	}
 }
@@ -41,7 +37,7 @@ func (h *SSEHandler) ServeSSE(c echo.Context) error {
 	c.Response().Header().Set("Connection", "keep-alive")
 	c.Response().WriteHeader(http.StatusOK)
 
-	clientChan := make(chan Notification, 100)
+	clientChan := make(chan notification.Notification, 100)
 	h.addClient(clientChan)
 
 	// Use a context with cancel for cleanup
@@ -61,8 +57,8 @@ func (h *SSEHandler) ServeSSE(c echo.Context) error {
 		case <-ctx.Done():
 			h.Debug("SSE: Context cancelled for %s", c.Request().RemoteAddr)
 			return nil
-		case notification := <-clientChan:
-			data, err := json.Marshal(notification)
+		case msg := <-clientChan:
+			data, err := json.Marshal(msg)
 			if err != nil {
 				h.Debug("SSE: Error marshaling notification: %v", err)
 				continue
@@ -84,14 +80,14 @@ func (h *SSEHandler) ServeSSE(c echo.Context) error {
 	}
 }
 
-func (h *SSEHandler) SendNotification(notification Notification) {
+func (h *SSEHandler) SendNotification(msg notification.Notification) {
 	h.clientsMux.Lock()
 	clientCount := len(h.clients)
 	log.Printf("SSE: Starting to broadcast notification to %d clients", clientCount)
 
 	for clientChan := range h.clients {
 		select {
-		case clientChan <- notification:
+		case clientChan <- msg:
 			h.Debug("SSE: Successfully sent notification to client channel")
 		default:
 			h.Debug("SSE: Warning - Client channel is blocked, skipping notification")
@@ -103,14 +99,14 @@ func (h *SSEHandler) SendNotification(notification Notification) {
 	h.Debug("SSE: Finished broadcasting notification to all clients")
 }
 
-func (h *SSEHandler) addClient(clientChan chan Notification) {
+func (h *SSEHandler) addClient(clientChan chan notification.Notification) {
 	h.clientsMux.Lock()
 	defer h.clientsMux.Unlock()
 	h.clients[clientChan] = true
 	h.Debug("SSE: New client connected. Channel buffer remaining: %d. Total clients: %d", cap(clientChan)-len(clientChan), len(h.clients))
 }
 
-func (h *SSEHandler) removeClient(clientChan chan Notification) {
+func (h *SSEHandler) removeClient(clientChan chan notification.Notification) {
 	h.clientsMux.Lock()
 	delete(h.clients, clientChan)
 	close(clientChan)
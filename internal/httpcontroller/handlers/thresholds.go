@@ -0,0 +1,65 @@
+// thresholds.go: API handlers of the dynamic confidence thresholds
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// DynamicThresholdManager exposes the dynamic thresholds of the detection processor.
+type DynamicThresholdManager interface {
+	DynamicThresholdStates() []datastore.DynamicThreshold
+	ResetDynamicThreshold(species string) bool
+}
+
+// DynamicThresholdState is the API representation of the dynamic threshold of a species.
+type DynamicThresholdState struct {
+	Species             string    `json:"species"`
+	Level               int       `json:"level"`
+	Threshold           float64   `json:"threshold"`
+	HighConfidenceCount int       `json:"highConfidenceCount"`
+	ExpiresAt           time.Time `json:"expiresAt"`
+}
+
+// APIListDynamicThresholds returns the effective dynamic threshold, level and expiry of each
+// species tracked by the detection processor.
+func (h *Handlers) APIListDynamicThresholds(c echo.Context) error {
+	if h.DynamicThresholds == nil {
+		return h.apiError(c, fmt.Errorf("detection processor is not running"), "Dynamic thresholds are not available", http.StatusServiceUnavailable)
+	}
+
+	states := h.DynamicThresholds.DynamicThresholdStates()
+	result := make([]DynamicThresholdState, 0, len(states))
+	for _, s := range states {
+		result = append(result, DynamicThresholdState{
+			Species:             s.Species,
+			Level:               s.Level,
+			Threshold:           s.CurrentValue,
+			HighConfidenceCount: s.HighConfCount,
+			ExpiresAt:           s.ExpiresAt,
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// APIResetDynamicThreshold reverts a species to its base threshold, or all species when no
+// species is given.
+func (h *Handlers) APIResetDynamicThreshold(c echo.Context) error {
+	if h.DynamicThresholds == nil {
+		return h.apiError(c, fmt.Errorf("detection processor is not running"), "Dynamic thresholds are not available", http.StatusServiceUnavailable)
+	}
+
+	species, err := url.PathUnescape(c.Param("species"))
+	if err != nil {
+		return h.apiError(c, err, "Invalid species", http.StatusBadRequest)
+	}
+	if !h.DynamicThresholds.ResetDynamicThreshold(species) && species != "" {
+		return h.apiError(c, fmt.Errorf("no dynamic threshold for %s", species), "Species has no dynamic threshold", http.StatusNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
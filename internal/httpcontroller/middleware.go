@@ -9,6 +9,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/tphakala/birdnet-go/internal/httpcontroller/handlers"
 )
 
 // configureMiddleware sets up middleware for the server.
@@ -18,6 +19,10 @@ func (s *Server) configureMiddleware() {
 	s.Echo.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 		Level:     6,
 		MinLength: 2048,
+		Skipper: func(c echo.Context) bool {
+			// Streams are flushed event by event
+			return isStreamRoute(c.Path())
+		},
 	}))
 	// Apply the Cache Control Middleware
 	s.Echo.Use(s.CacheControlMiddleware())
@@ -94,7 +99,7 @@ func VaryHeaderMiddleware() echo.MiddlewareFunc {
 
 func (s *Server) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		if isProtectedRoute(c.Path()) {
+		if isProtectedRoute(c.Path()) || isProtectedAPIRequest(c) {
 			// Check for Cloudflare bypass
 			if s.CloudflareAccess.IsEnabled(c) {
 				return next(c)
@@ -103,6 +108,14 @@ func (s *Server) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			// Check if authentication is required for this IP
 			if s.OAuth2Server.IsAuthenticationEnabled(s.RealIP(c)) {
 				if !s.IsAccessAllowed(c) {
+					// API clients get a JSON error instead of a login redirect
+					if isAPIRoute(c.Path()) {
+						return c.JSON(http.StatusUnauthorized, handlers.APIError{
+							Error:   http.StatusText(http.StatusUnauthorized),
+							Message: "Authentication required",
+							Code:    http.StatusUnauthorized,
+						})
+					}
 					redirectPath := url.QueryEscape(c.Request().URL.Path)
 					// Validate redirect path against whitelist
 					if !isValidRedirect(redirectPath) {
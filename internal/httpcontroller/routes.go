@@ -59,12 +59,17 @@ func (s *Server) initRoutes() {
 	// Initialize OAuth2 routes
 	s.initAuthRoutes()
 
+	// Initialize JSON REST API routes
+	s.initAPIRoutes()
+
 	// Full page routes
 	s.pageRoutes = map[string]PageRouteConfig{
-		"/":          {Path: "/", TemplateName: "dashboard", Title: "Dashboard"},
-		"/dashboard": {Path: "/dashboard", TemplateName: "dashboard", Title: "Dashboard"},
-		"/logs":      {Path: "/logs", TemplateName: "logs", Title: "Logs"},
-		"/stats":     {Path: "/stats", TemplateName: "stats", Title: "Statistics"},
+		"/":            {Path: "/", TemplateName: "dashboard", Title: "Dashboard"},
+		"/dashboard":   {Path: "/dashboard", TemplateName: "dashboard", Title: "Dashboard"},
+		"/logs":        {Path: "/logs", TemplateName: "logs", Title: "Logs"},
+		"/stats":       {Path: "/stats", TemplateName: "stats", Title: "Statistics"},
+		"/rangefilter": {Path: "/rangefilter", TemplateName: "rangeFilter", Title: "Range Filter"},
+		"/rejected":    {Path: "/rejected", TemplateName: "rejectedDetections", Title: "Rejected Detections"},
 		// Settings Routes are managed by settingsBase template
 		"/settings/main":             {Path: "/settings/main", TemplateName: "settingsBase", Title: "Main Settings", Authorized: true},
 		"/settings/audio":            {Path: "/settings/audio", TemplateName: "settingsBase", Title: "Audio Settings", Authorized: true},
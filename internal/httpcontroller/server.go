@@ -15,6 +15,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
 	"github.com/tphakala/birdnet-go/internal/logger"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/notification"
 	"github.com/tphakala/birdnet-go/internal/security"
 	"github.com/tphakala/birdnet-go/internal/suncalc"
 	"golang.org/x/crypto/acme/autocert"
@@ -34,7 +35,8 @@ type Server struct {
 	SunCalc           *suncalc.SunCalc
 	AudioLevelChan    chan myaudio.AudioLevelData
 	controlChan       chan string
-	notificationChan  chan handlers.Notification
+	notificationChan  chan notification.Notification
+	detectionChan     chan datastore.Note
 
 	// Page and partial routes
 	pageRoutes    map[string]PageRouteConfig
@@ -42,7 +44,7 @@ type Server struct {
 }
 
 // New initializes a new HTTP server with given context and datastore.
-func New(settings *conf.Settings, dataStore datastore.Interface, birdImageCache *imageprovider.BirdImageCache, audioLevelChan chan myaudio.AudioLevelData, controlChan chan string) *Server {
+func New(settings *conf.Settings, dataStore datastore.Interface, birdImageCache *imageprovider.BirdImageCache, audioLevelChan chan myaudio.AudioLevelData, controlChan chan string, notificationChan chan notification.Notification, detectionChan chan datastore.Note) *Server {
 	configureDefaultSettings(settings)
 
 	s := &Server{
@@ -55,7 +57,8 @@ func New(settings *conf.Settings, dataStore datastore.Interface, birdImageCache
 		OAuth2Server:      security.NewOAuth2Server(),
 		CloudflareAccess:  security.NewCloudflareAccess(),
 		controlChan:       controlChan,
-		notificationChan:  make(chan handlers.Notification, 10),
+		notificationChan:  notificationChan,
+		detectionChan:     detectionChan,
 	}
 
 	// Configure an IP extractor
@@ -65,7 +68,7 @@ func New(settings *conf.Settings, dataStore datastore.Interface, birdImageCache
 	s.SunCalc = suncalc.NewSunCalc(settings.BirdNET.Latitude, settings.BirdNET.Longitude)
 
 	// Initialize handlers
-	s.Handlers = handlers.New(s.DS, s.Settings, s.DashboardSettings, s.BirdImageCache, nil, s.SunCalc, s.AudioLevelChan, s.OAuth2Server, s.controlChan, s.notificationChan, s)
+	s.Handlers = handlers.New(s.DS, s.Settings, s.DashboardSettings, s.BirdImageCache, nil, s.SunCalc, s.AudioLevelChan, s.OAuth2Server, s.controlChan, s.notificationChan, s.detectionChan, s)
 
 	s.initializeServer()
 	return s
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/httpcontroller/handlers"
 	"github.com/tphakala/birdnet-go/internal/observation"
 	"golang.org/x/text/cases"
@@ -55,6 +56,7 @@ func (s *Server) GetTemplateFunctions() template.FuncMap {
 		"weatherDescription":    s.Handlers.GetWeatherDescriptionFunc(),
 		"getAllSpecies":         s.GetAllSpecies,
 		"getIncludedSpecies":    s.GetIncludedSpecies,
+		"detectionEventLabel":   detectionEventLabel,
 		"isSpeciesExcluded": func(commonName string) bool {
 			settings := conf.Setting()
 			for _, s := range settings.Realtime.Species.Exclude {
@@ -67,6 +69,22 @@ func (s *Server) GetTemplateFunctions() template.FuncMap {
 	}
 }
 
+// detectionEventLabel returns the display label of a detection event.
+func detectionEventLabel(event string) string {
+	switch event {
+	case datastore.EventLifer:
+		return "new species"
+	case datastore.EventFirstOfYear:
+		return "first of year"
+	case datastore.EventFirstOfSeason:
+		return "first of season"
+	case datastore.EventReturning:
+		return "returning"
+	default:
+		return event
+	}
+}
+
 // addFunc calculates the sum of the input integers.
 // Parameters:
 //   - numbers: Variadic list of integers or strings representing integers
@@ -0,0 +1,219 @@
+// client.go: pushes the detections of a node to the hub
+package hub
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// spoolReplayInterval is the interval at which spooled submissions are pushed again
+const spoolReplayInterval = time.Minute
+
+// HTTPError is returned when the hub responds with an error status.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("status code: %d, response: %s", e.StatusCode, e.Body)
+}
+
+// Client pushes detections to the hub. Submissions that fail because the hub is unreachable
+// are spooled on disk and pushed again in detection order once the hub is back.
+type Client struct {
+	Settings    *conf.Settings
+	HTTPClient  *http.Client
+	Spool       *Spool // submissions not yet accepted by the hub, nil if spooling is disabled
+	endpoint    string
+	replayNudge chan struct{}
+}
+
+// NewClient creates a hub client and opens its spool.
+func NewClient(settings *conf.Settings) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(settings.Realtime.Hub.Client.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hub URL: %w", err)
+	}
+
+	c := &Client{
+		Settings:    settings,
+		HTTPClient:  &http.Client{Timeout: 45 * time.Second},
+		endpoint:    base.String() + IngestPath,
+		replayNudge: make(chan struct{}, 1),
+	}
+
+	if path := settings.Realtime.Hub.Client.SpoolPath; path != "" {
+		c.Spool, err = NewSpool(path, settings.Realtime.Hub.Client.SpoolMaxEntries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// isTemporary reports whether a failed push may succeed later, e.g. because the network or the
+// hub was unavailable. Such submissions are spooled and pushed again. Errors that persist until
+// the configuration changes, such as an invalid hub URL or a certificate that does not verify,
+// are not temporary.
+func isTemporary(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCertErr x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidCertErr) || errors.As(err, &recordHeaderErr) {
+		return false
+	}
+
+	// A host name that does not exist is a configuration error, other lookup failures are not
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+
+	// url.Error implements net.Error itself, so look for the network errors it wraps
+	var opErr *net.OpError
+	if errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Push sends a detection to the hub. Detections are spooled instead while earlier detections
+// are waiting in the spool, or when the hub is unreachable. Errors are returned for detections
+// the hub rejects.
+func (c *Client) Push(sub *Submission) error {
+	if c.Spool == nil {
+		return c.post(sub)
+	}
+
+	// Queue behind earlier spooled submissions so that detections arrive in order
+	if c.Spool.Len() > 0 {
+		if err := c.Spool.Add(sub); err != nil {
+			return fmt.Errorf("failed to spool hub submission: %w", err)
+		}
+		c.nudgeReplay()
+		return nil
+	}
+
+	if err := c.post(sub); err != nil {
+		if !isTemporary(err) {
+			return err
+		}
+		log.Printf("Hub is unreachable, spooling detection for later: %v\n", err)
+		if err := c.Spool.Add(sub); err != nil {
+			return fmt.Errorf("failed to spool hub submission: %w", err)
+		}
+	}
+	return nil
+}
+
+// post sends a submission to the ingest endpoint of the hub.
+func (c *Client) post(sub *Submission) error {
+	body, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to encode hub submission: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create hub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Settings.Realtime.Hub.Client.Token)
+	req.Header.Set("User-Agent", "BirdNET-Go")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push detection to hub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return fmt.Errorf("failed to read hub response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("hub rejected detection: %w", &HTTPError{StatusCode: resp.StatusCode, Body: string(respBody)})
+	}
+	return nil
+}
+
+// StartSpoolReplay starts pushing spooled submissions in the background.
+func (c *Client) StartSpoolReplay() {
+	if c.Spool == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(spoolReplayInterval)
+		defer ticker.Stop()
+
+		for {
+			c.replaySpool()
+			select {
+			case <-ticker.C:
+			case <-c.replayNudge:
+			}
+		}
+	}()
+}
+
+// nudgeReplay wakes up the spool replay.
+func (c *Client) nudgeReplay() {
+	select {
+	case c.replayNudge <- struct{}{}:
+	default:
+	}
+}
+
+// replaySpool pushes spooled submissions oldest first. It stops at the first submission that
+// cannot be pushed, and drops submissions that are rejected by the hub.
+func (c *Client) replaySpool() {
+	for {
+		name, sub, err := c.Spool.Oldest()
+		if name == "" {
+			return
+		}
+		if err != nil {
+			log.Printf("Dropping unreadable hub submission: %v\n", err)
+			c.Spool.Remove(name)
+			continue
+		}
+
+		if err := c.post(sub); err != nil {
+			if isTemporary(err) {
+				return
+			}
+			// Keep the spool when the hub cannot be reached until the configuration is fixed
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				log.Printf("Failed to push spooled detections to hub: %v\n", err)
+				return
+			}
+			log.Printf("Hub rejected spooled detection of %s at %s, dropping it: %v\n", sub.Note.CommonName, sub.Note.Timestamp, err)
+		}
+		c.Spool.Remove(name)
+	}
+}
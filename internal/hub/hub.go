@@ -0,0 +1,141 @@
+// hub.go: multi-node aggregation, nodes push their detections to a central hub
+package hub
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// IngestPath is the path of the hub API endpoint that receives detections from nodes.
+const IngestPath = "/api/v1/hub/detections"
+
+// NodeClipDir is the directory below the clip export path holding the clips of remote nodes.
+const NodeClipDir = "nodes"
+
+// ErrInvalidSubmission is returned for submissions that cannot be stored.
+var ErrInvalidSubmission = errors.New("invalid submission")
+
+// pathUnsafePattern matches characters not used in clip directory and file names
+var pathUnsafePattern = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Submission is an approved detection pushed from a node to the hub.
+type Submission struct {
+	Note    datastore.Note      `json:"note"`
+	Results []datastore.Results `json:"results,omitempty"`
+	Clip    []byte              `json:"clip,omitempty"` // audio clip of the detection as WAV
+}
+
+// IngestResponse is the response of the hub to a submission.
+type IngestResponse struct {
+	ID        uint `json:"id"`        // ID of the stored note on the hub
+	Duplicate bool `json:"duplicate"` // true if the detection had already been received
+}
+
+// Authenticate returns the name of the node whose token matches the bearer token of an
+// Authorization header.
+func Authenticate(settings *conf.Settings, authorization string) (node string, ok bool) {
+	token, found := strings.CutPrefix(authorization, "Bearer ")
+	if !found || token == "" {
+		return "", false
+	}
+	for _, n := range settings.Realtime.Hub.Server.Nodes {
+		if n.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(n.Token)) == 1 {
+			return n.Name, true
+		}
+	}
+	return "", false
+}
+
+// Ingest stores a detection pushed by an authenticated node. The note is stored with the node
+// as its source node and its clip is saved below the nodes directory of the clip export path.
+// Detections that have already been received are not stored again, duplicate is then set and
+// no note is returned.
+func Ingest(ds datastore.Interface, settings *conf.Settings, node string, sub *Submission) (note *datastore.Note, duplicate bool, err error) {
+	if sub.Note.ScientificName == "" || sub.Note.Timestamp.IsZero() {
+		return nil, false, fmt.Errorf("%w: scientific name and timestamp are required", ErrInvalidSubmission)
+	}
+
+	exists, err := ds.NoteExists(node, sub.Note.Source, sub.Note.ScientificName, sub.Note.Timestamp)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists {
+		return nil, true, nil
+	}
+
+	// Only the detection itself is taken over, reviews and locks belong to the hub
+	n := sub.Note
+	n.ID = 0
+	n.SourceNode = node
+	n.Results = nil
+	n.Review = nil
+	n.Comments = nil
+	n.Lock = nil
+	n.Verified = ""
+	n.Locked = false
+	n.ClipName = ""
+
+	results := make([]datastore.Results, 0, len(sub.Results))
+	for _, r := range sub.Results {
+		results = append(results, datastore.Results{Species: r.Species, Confidence: r.Confidence, StartTime: r.StartTime})
+	}
+
+	var clipPath string
+	if len(sub.Clip) > 0 {
+		if !isWAV(sub.Clip) {
+			return nil, false, fmt.Errorf("%w: clip is not a WAV file", ErrInvalidSubmission)
+		}
+		n.ClipName = clipName(node, &sub.Note)
+		clipPath = filepath.Join(settings.Realtime.Audio.Export.Path, n.ClipName)
+		if err := os.MkdirAll(filepath.Dir(clipPath), 0o755); err != nil {
+			return nil, false, fmt.Errorf("failed to create clip directory: %w", err)
+		}
+		if err := os.WriteFile(clipPath, sub.Clip, 0o644); err != nil {
+			return nil, false, fmt.Errorf("failed to save clip: %w", err)
+		}
+	}
+
+	if err := ds.Save(&n, results); err != nil {
+		if clipPath != "" {
+			os.Remove(clipPath)
+		}
+		return nil, false, fmt.Errorf("failed to save detection of node %s: %w", node, err)
+	}
+	return &n, false, nil
+}
+
+// clipName returns the clip path of a remote detection relative to the clip export path, e.g.
+// nodes/backyard/2024/05/parus_major_80p_20240512T061502Z.wav.
+func clipName(node string, note *datastore.Note) string {
+	ts := note.Timestamp.UTC()
+	base := fmt.Sprintf("%s_%.0fp_%s", strings.ToLower(note.ScientificName), note.Confidence*100, ts.Format("20060102T150405Z"))
+	if note.ClipName != "" {
+		name := path.Base(strings.ReplaceAll(note.ClipName, "\\", "/"))
+		base = strings.TrimSuffix(name, path.Ext(name))
+	}
+	return filepath.Join(NodeClipDir, pathSafe(node), ts.Format("2006"), ts.Format("01"), pathSafe(base)+".wav")
+}
+
+// pathSafe converts a name to a single path element without separators.
+func pathSafe(name string) string {
+	name = strings.Trim(pathUnsafePattern.ReplaceAllString(name, "_"), "_.")
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// isWAV reports whether data starts with a RIFF WAVE header.
+func isWAV(data []byte) bool {
+	return len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE"))
+}
@@ -0,0 +1,223 @@
+package hub
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// testWAV is the smallest data accepted as a WAV clip.
+var testWAV = []byte("RIFF\x24\x00\x00\x00WAVEfmt ")
+
+func newTestStore(t *testing.T, settings *conf.Settings) datastore.Interface {
+	t.Helper()
+	settings.Output.SQLite.Enabled = true
+	settings.Output.SQLite.Path = filepath.Join(t.TempDir(), "hub.db")
+	ds := datastore.New(settings)
+	if err := ds.Open(); err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { ds.Close() })
+	return ds
+}
+
+func TestAuthenticate(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.Hub.Server.Nodes = []conf.HubNode{
+		{Name: "backyard", Token: "secret1"},
+		{Name: "forest", Token: "secret2"},
+	}
+
+	if node, ok := Authenticate(settings, "Bearer secret2"); !ok || node != "forest" {
+		t.Errorf("expected forest, got %q (%v)", node, ok)
+	}
+	for _, header := range []string{"", "Bearer ", "Bearer wrong", "secret1", "Basic secret1"} {
+		if node, ok := Authenticate(settings, header); ok {
+			t.Errorf("header %q authenticated as %s", header, node)
+		}
+	}
+}
+
+func TestIngest(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.Audio.Export.Path = t.TempDir()
+	ds := newTestStore(t, settings)
+
+	sub := &Submission{
+		Note: datastore.Note{
+			ID:             42,
+			SourceNode:     "spoofed",
+			Source:         "garden",
+			ScientificName: "Parus major",
+			CommonName:     "Great Tit",
+			Confidence:     0.8,
+			Timestamp:      time.Date(2024, 5, 12, 6, 15, 2, 0, time.UTC),
+			ClipName:       "2024/05/../../parus_major_80p_20240512T061502Z.flac",
+		},
+		Results: []datastore.Results{{ID: 7, NoteID: 42, Species: "Parus major_Great Tit", Confidence: 0.8}},
+		Clip:    testWAV,
+	}
+
+	note, duplicate, err := Ingest(ds, settings, "backyard", sub)
+	if err != nil || duplicate {
+		t.Fatalf("Ingest failed: %v (duplicate %v)", err, duplicate)
+	}
+	if note.SourceNode != "backyard" || note.ID == 42 {
+		t.Errorf("expected note stored as a new note of the node, got %+v", note)
+	}
+	wantClip := filepath.Join("nodes", "backyard", "2024", "05", "parus_major_80p_20240512T061502Z.wav")
+	if note.ClipName != wantClip {
+		t.Errorf("clip name = %s, want %s", note.ClipName, wantClip)
+	}
+	if _, err := os.Stat(filepath.Join(settings.Realtime.Audio.Export.Path, wantClip)); err != nil {
+		t.Errorf("clip was not saved: %v", err)
+	}
+
+	// Pushing the same detection again does not store it twice
+	if _, duplicate, err := Ingest(ds, settings, "backyard", sub); err != nil || !duplicate {
+		t.Errorf("expected duplicate, got %v (%v)", duplicate, err)
+	}
+	// The same detection from another node is a separate detection
+	if _, duplicate, err := Ingest(ds, settings, "forest", sub); err != nil || duplicate {
+		t.Errorf("expected detection of another node to be stored, got %v (%v)", duplicate, err)
+	}
+
+	sub.Clip = []byte("#!/bin/sh")
+	sub.Note.Timestamp = sub.Note.Timestamp.Add(time.Minute)
+	if _, _, err := Ingest(ds, settings, "backyard", sub); err == nil {
+		t.Error("expected clip that is not a WAV file to be rejected")
+	}
+}
+
+func TestClientSpoolsWhileHubIsDown(t *testing.T) {
+	var mu sync.Mutex
+	online := false
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != IngestPath || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var sub Submission
+		if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, sub.Note.CommonName)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	settings := &conf.Settings{}
+	settings.Realtime.Hub.Client.URL = server.URL + "/"
+	settings.Realtime.Hub.Client.Token = "secret"
+	settings.Realtime.Hub.Client.SpoolPath = t.TempDir()
+	client, err := NewClient(settings)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	base := time.Now()
+	for i, name := range []string{"first", "second"} {
+		sub := &Submission{Note: datastore.Note{CommonName: name, Timestamp: base.Add(time.Duration(i) * time.Second)}}
+		if err := client.Push(sub); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+	if client.Spool.Len() != 2 {
+		t.Fatalf("expected 2 spooled detections, got %d", client.Spool.Len())
+	}
+
+	mu.Lock()
+	online = true
+	mu.Unlock()
+
+	// A new detection is queued behind the spooled ones
+	if err := client.Push(&Submission{Note: datastore.Note{CommonName: "third", Timestamp: base.Add(2 * time.Second)}}); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	client.replaySpool()
+
+	mu.Lock()
+	got := strings.Join(received, ",")
+	mu.Unlock()
+	if got != "first,second,third" {
+		t.Errorf("received %s, want first,second,third", got)
+	}
+	if client.Spool.Len() != 0 {
+		t.Errorf("expected empty spool, got %d", client.Spool.Len())
+	}
+
+	// Rejected detections are not spooled
+	settings.Realtime.Hub.Client.Token = "wrong"
+	if err := client.Push(&Submission{Note: datastore.Note{CommonName: "rejected", Timestamp: base}}); err == nil {
+		t.Error("expected rejected detection to return an error")
+	}
+}
+
+func TestIsTemporary(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer untrusted.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{"connection refused", closed.URL, true},
+		{"timeout", slow.URL, true},
+		{"untrusted certificate", untrusted.URL, false},
+		{"unsupported scheme", "ftp://hub.example", false},
+	}
+	for _, tt := range tests {
+		settings := &conf.Settings{}
+		settings.Realtime.Hub.Client.URL = tt.url
+		settings.Realtime.Hub.Client.SpoolPath = t.TempDir()
+		client, err := NewClient(settings)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		client.HTTPClient.Timeout = 50 * time.Millisecond
+
+		err = client.post(&Submission{})
+		if err == nil {
+			t.Fatalf("%s: expected push to fail", tt.name)
+		}
+		if got := isTemporary(err); got != tt.want {
+			t.Errorf("%s: isTemporary(%v) = %v, want %v", tt.name, err, got, tt.want)
+		}
+
+		// Only temporary failures are spooled, others are returned
+		err = client.Push(&Submission{})
+		if spooled := client.Spool.Len() > 0; spooled != tt.want || (err == nil) != tt.want {
+			t.Errorf("%s: spooled %v with error %v, want spooled %v", tt.name, spooled, err, tt.want)
+		}
+	}
+
+	for status, want := range map[int]bool{http.StatusTooManyRequests: true, http.StatusBadGateway: true, http.StatusBadRequest: false} {
+		if got := isTemporary(&HTTPError{StatusCode: status}); got != want {
+			t.Errorf("isTemporary(status %d) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// spool.go: submissions kept on disk while the hub is unreachable
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Spool keeps submissions that could not be pushed to the hub on disk, in detection order.
+// Each submission is stored as a JSON file named by its detection time.
+type Spool struct {
+	dir        string
+	maxEntries int
+	mu         sync.Mutex
+	pending    []string // base names of spooled submissions, oldest first
+}
+
+// NewSpool opens the spool directory, creating it if needed.
+func NewSpool(dir string, maxEntries int) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create hub spool directory: %w", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spooled submissions: %w", err)
+	}
+
+	s := &Spool{dir: dir, maxEntries: maxEntries}
+	for _, file := range files {
+		s.pending = append(s.pending, strings.TrimSuffix(filepath.Base(file), ".json"))
+	}
+	sort.Strings(s.pending)
+	return s, nil
+}
+
+// Len returns the number of spooled submissions.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Add stores a submission at the end of the spool. The oldest submissions are dropped when
+// the spool is full.
+func (s *Spool) Add(sub *Submission) error {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to encode spooled submission: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Name entries by detection time so that they sort in detection order
+	detectionTime := sub.Note.Timestamp
+	if detectionTime.IsZero() {
+		detectionTime = time.Now()
+	}
+	name := fmt.Sprintf("%019d", detectionTime.UnixNano())
+	for s.indexOf(name) >= 0 {
+		name += "a"
+	}
+
+	path := filepath.Join(s.dir, name+".json")
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("failed to write spooled submission: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("failed to write spooled submission: %w", err)
+	}
+
+	s.pending = append(s.pending, name)
+	sort.Strings(s.pending)
+
+	for s.maxEntries > 0 && len(s.pending) > s.maxEntries {
+		log.Printf("Hub spool is full, dropping oldest submission %s\n", s.pending[0])
+		s.removeFile(s.pending[0])
+		s.pending = s.pending[1:]
+	}
+	return nil
+}
+
+// Oldest returns the oldest spooled submission, or an empty name if the spool is empty.
+func (s *Spool) Oldest() (name string, sub *Submission, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		return "", nil, nil
+	}
+	name = s.pending[0]
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".json"))
+	if err != nil {
+		return name, nil, fmt.Errorf("failed to read spooled submission %s: %w", name, err)
+	}
+	sub = &Submission{}
+	if err := json.Unmarshal(data, sub); err != nil {
+		return name, nil, fmt.Errorf("failed to decode spooled submission %s: %w", name, err)
+	}
+	return name, sub, nil
+}
+
+// Remove deletes a spooled submission.
+func (s *Spool) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.indexOf(name); i >= 0 {
+		s.pending = append(s.pending[:i], s.pending[i+1:]...)
+	}
+	s.removeFile(name)
+}
+
+// indexOf returns the index of a spooled submission, or -1.
+func (s *Spool) indexOf(name string) int {
+	for i, pending := range s.pending {
+		if pending == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeFile deletes the file of a spooled submission.
+func (s *Spool) removeFile(name string) {
+	if err := os.Remove(filepath.Join(s.dir, name+".json")); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove spooled hub submission: %v\n", err)
+	}
+}
@@ -133,6 +133,10 @@ func (m *mockStore) SpeciesDetections(species, date, hour string, duration int,
 }
 func (m *mockStore) GetLastDetections(num int) ([]datastore.Note, error) { return nil, nil }
 func (m *mockStore) GetAllDetectedSpecies() ([]datastore.Note, error)    { return nil, nil }
+func (m *mockStore) GetDetectionsPage(limit, offset int) ([]datastore.Note, error) {
+	return nil, nil
+}
+func (m *mockStore) CountDetections() (int64, error) { return 0, nil }
 func (m *mockStore) SearchNotes(query string, asc bool, limit, offset int) ([]datastore.Note, error) {
 	return nil, nil
 }
@@ -157,6 +161,62 @@ func (m *mockStore) LatestHourlyWeather() (*datastore.HourlyWeather, error)
 func (m *mockStore) GetHourlyDetections(date, hour string, duration int) ([]datastore.Note, error) {
 	return nil, nil
 }
+func (m *mockStore) GetHourlyDetectionsPage(date, hour string, duration, limit, offset int) ([]datastore.Note, error) {
+	return nil, nil
+}
+func (m *mockStore) CountHourlyDetections(date, hour string, duration int) (int64, error) {
+	return 0, nil
+}
+func (m *mockStore) CountSpeciesDetectionsBetween(scientificName string, start, end time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *mockStore) GetNotesBetween(start, end time.Time) ([]datastore.Note, error) {
+	return nil, nil
+}
+func (m *mockStore) GetSpeciesLastSeen(scientificName string, before time.Time) (time.Time, error) {
+	return time.Time{}, nil
+}
+func (m *mockStore) NoteExists(sourceNode, source, scientificName string, timestamp time.Time) (bool, error) {
+	return false, nil
+}
+func (m *mockStore) GetNoteByClipName(clipName string) (datastore.Note, error) {
+	return datastore.Note{}, nil
+}
+func (m *mockStore) GetDynamicThresholds() ([]datastore.DynamicThreshold, error) {
+	return nil, nil
+}
+func (m *mockStore) SaveDynamicThresholds(thresholds []datastore.DynamicThreshold) error {
+	return nil
+}
+func (m *mockStore) DeleteDynamicThreshold(species string) error { return nil }
+func (m *mockStore) SaveRangeFilterScores(date string, scores []datastore.RangeFilterScore) error {
+	return nil
+}
+func (m *mockStore) GetRangeFilterScores(date string) ([]datastore.RangeFilterScore, error) {
+	return nil, nil
+}
+func (m *mockStore) GetRangeFilterSnapshots() ([]datastore.RangeFilterSnapshot, error) {
+	return nil, nil
+}
+func (m *mockStore) SaveRejectedDetection(rejected *datastore.RejectedDetection) error {
+	return nil
+}
+func (m *mockStore) GetRejectedDetections(reason string, limit, offset int) ([]datastore.RejectedDetection, error) {
+	return nil, nil
+}
+func (m *mockStore) CountRejectedDetections(reason string) (int64, error) {
+	return 0, nil
+}
+func (m *mockStore) DeleteRejectedDetectionsBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *mockStore) PromoteRejectedDetection(id string) (datastore.Note, error) {
+	return datastore.Note{}, nil
+}
+func (m *mockStore) GetNodeStatistics() ([]datastore.NodeStatistics, error) {
+	return nil, nil
+}
+func (m *mockStore) ForNode(node string) datastore.Interface { return m }
 func (m *mockStore) CountSpeciesDetections(species, date, hour string, duration int) (int64, error) {
 	return 0, nil
 }
@@ -30,20 +30,44 @@ type client struct {
 	reconnectTimer  *time.Timer
 	reconnectStop   chan struct{}
 	metrics         *metrics.MQTTMetrics
+	onConnectMu     sync.Mutex
+	onConnectFunc   func()
+	subscriptions   map[string]MessageHandler // protected by onConnectMu
 }
 
 // NewClient creates a new MQTT client with the provided configuration.
 func NewClient(settings *conf.Settings, metrics *telemetry.Metrics) (Client, error) {
+	// Topic templates are validated here as the placeholders are defined by this package
+	if err := ValidateTopicTemplate(settings.Realtime.MQTT.Topic); err != nil {
+		return nil, err
+	}
+	for species, config := range settings.GetSpeciesConfigs() {
+		if config.MQTTTopic == "" {
+			continue
+		}
+		if err := ValidateTopicTemplate(config.MQTTTopic); err != nil {
+			return nil, fmt.Errorf("invalid MQTT topic of species %s: %w", species, err)
+		}
+	}
+
 	config := DefaultConfig()
 	config.Broker = settings.Realtime.MQTT.Broker
 	config.ClientID = settings.Main.Name
 	config.Username = settings.Realtime.MQTT.Username
 	config.Password = settings.Realtime.MQTT.Password
+	config.QoS = byte(settings.Realtime.MQTT.QoS)
+
+	// Home Assistant marks entities unavailable when the node goes offline
+	if settings.Realtime.MQTT.HomeAssistant.Enabled {
+		config.WillTopic = AvailabilityTopic(settings)
+		config.WillPayload = PayloadOffline
+	}
 
 	return &client{
 		config:        config,
 		reconnectStop: make(chan struct{}),
 		metrics:       metrics.MQTT,
+		subscriptions: make(map[string]MessageHandler),
 	}, nil
 }
 
@@ -104,6 +128,9 @@ func (c *client) Connect(ctx context.Context) error {
 	opts.SetPingTimeout(10 * time.Second)
 	opts.SetWriteTimeout(10 * time.Second)
 	opts.SetConnectTimeout(c.config.ConnectTimeout)
+	if c.config.WillTopic != "" {
+		opts.SetWill(c.config.WillTopic, c.config.WillPayload, c.config.QoS, true)
+	}
 
 	c.internalClient = mqtt.NewClient(opts)
 
@@ -152,6 +179,16 @@ func (c *client) Connect(ctx context.Context) error {
 
 // Publish sends a message to the specified topic on the MQTT broker.
 func (c *client) Publish(ctx context.Context, topic, payload string) error {
+	return c.publish(ctx, topic, payload, false)
+}
+
+// PublishRetained sends a retained message to the specified topic on the MQTT broker.
+func (c *client) PublishRetained(ctx context.Context, topic, payload string) error {
+	return c.publish(ctx, topic, payload, true)
+}
+
+// publish sends a message to the specified topic on the MQTT broker.
+func (c *client) publish(ctx context.Context, topic, payload string, retained bool) error {
 	// Check context before acquiring lock
 	if err := ctx.Err(); err != nil {
 		return err
@@ -173,7 +210,7 @@ func (c *client) Publish(ctx context.Context, topic, payload string) error {
 
 	go func() {
 		close(publishStarted)
-		token := c.internalClient.Publish(topic, defaultQoS, false, payload)
+		token := c.internalClient.Publish(topic, c.config.QoS, retained, payload)
 		if !token.WaitTimeout(c.config.PublishTimeout) {
 			done <- fmt.Errorf("publish timeout after %v", c.config.PublishTimeout)
 			return
@@ -216,14 +253,77 @@ func (c *client) Disconnect() {
 	}
 
 	if c.internalClient != nil && c.internalClient.IsConnected() {
+		// The broker does not publish the last will on a clean disconnect
+		if c.config.WillTopic != "" {
+			token := c.internalClient.Publish(c.config.WillTopic, c.config.QoS, true, c.config.WillPayload)
+			token.WaitTimeout(c.config.PublishTimeout)
+		}
 		c.internalClient.Disconnect(uint(c.config.DisconnectTimeout.Milliseconds()))
 		c.metrics.UpdateConnectionStatus(false)
 	}
 }
 
+// Subscribe registers a handler for messages received on the topic and subscribes to it if
+// the client is connected.
+func (c *client) Subscribe(topic string, handler MessageHandler) error {
+	c.onConnectMu.Lock()
+	c.subscriptions[topic] = handler
+	c.onConnectMu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.IsConnected() {
+		// Subscribed when the client connects
+		return nil
+	}
+	return c.subscribe(c.internalClient, topic, handler)
+}
+
+// subscribe subscribes to a topic on the MQTT broker.
+func (c *client) subscribe(internalClient mqtt.Client, topic string, handler MessageHandler) error {
+	token := internalClient.Subscribe(topic, c.config.QoS, func(_ mqtt.Client, msg mqtt.Message) {
+		handler(msg.Topic(), msg.Payload())
+	})
+	if !token.WaitTimeout(c.config.PublishTimeout) {
+		return fmt.Errorf("subscribe timeout after %v", c.config.PublishTimeout)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("subscribe error: %w", err)
+	}
+	return nil
+}
+
+// SetOnConnectHandler sets a function that is called each time the client has connected.
+func (c *client) SetOnConnectHandler(handler func()) {
+	c.onConnectMu.Lock()
+	defer c.onConnectMu.Unlock()
+	c.onConnectFunc = handler
+}
+
 func (c *client) onConnect(client mqtt.Client) {
 	fmt.Printf("Connected to MQTT broker: %s\n", c.config.Broker)
 	c.metrics.UpdateConnectionStatus(true)
+
+	c.onConnectMu.Lock()
+	handler := c.onConnectFunc
+	subscriptions := make(map[string]MessageHandler, len(c.subscriptions))
+	for topic, messageHandler := range c.subscriptions {
+		subscriptions[topic] = messageHandler
+	}
+	c.onConnectMu.Unlock()
+
+	// Connect holds the client lock until it returns, so the handlers must not block it
+	go func() {
+		// Sessions are not persisted, subscriptions are restored on every connect
+		for topic, messageHandler := range subscriptions {
+			if err := c.subscribe(client, topic, messageHandler); err != nil {
+				fmt.Printf("Failed to subscribe to MQTT topic %s: %v\n", topic, err)
+			}
+		}
+		if handler != nil {
+			handler()
+		}
+	}()
 }
 
 func (c *client) onConnectionLost(client mqtt.Client, err error) {
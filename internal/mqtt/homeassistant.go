@@ -0,0 +1,367 @@
+// homeassistant.go: Home Assistant MQTT discovery and sensor states
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// Availability payloads published to the availability topic
+const (
+	PayloadOnline  = "online"
+	PayloadOffline = "offline"
+)
+
+// haPublishTimeout limits how long a discovery or state publish may take
+const haPublishTimeout = 10 * time.Second
+
+// haIDPattern matches characters not allowed in Home Assistant node and object IDs
+var haIDPattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// AvailabilityTopic returns the topic that tells whether the node is online.
+func AvailabilityTopic(settings *conf.Settings) string {
+	return BaseTopic(settings.Realtime.MQTT.Topic) + "/status"
+}
+
+// haID converts a name to a Home Assistant node or object ID.
+func haID(name string) string {
+	id := strings.Trim(haIDPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if id == "" {
+		return "default"
+	}
+	return id
+}
+
+// haDevice describes the node as a Home Assistant device.
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// haSensorConfig is the discovery config of a Home Assistant sensor entity.
+type haSensorConfig struct {
+	Name                string   `json:"name"`
+	UniqueID            string   `json:"unique_id"`
+	ObjectID            string   `json:"object_id"`
+	StateTopic          string   `json:"state_topic"`
+	ValueTemplate       string   `json:"value_template,omitempty"`
+	JSONAttributesTopic string   `json:"json_attributes_topic,omitempty"`
+	UnitOfMeasurement   string   `json:"unit_of_measurement,omitempty"`
+	StateClass          string   `json:"state_class,omitempty"`
+	Icon                string   `json:"icon,omitempty"`
+	AvailabilityTopic   string   `json:"availability_topic"`
+	Device              haDevice `json:"device"`
+}
+
+// haLastDetection is the state of the last detected species sensor.
+type haLastDetection struct {
+	CommonName     string  `json:"common_name"`
+	ScientificName string  `json:"scientific_name"`
+	Confidence     float64 `json:"confidence"`
+	Source         string  `json:"source"`
+	Time           string  `json:"time"`
+
+	detected time.Time // detection time, compared instead of Time whose UTC offset changes with DST
+}
+
+// HomeAssistant publishes Home Assistant MQTT discovery configs for a last detected species
+// sensor, a daily species count sensor and daily detection counters per audio source, and keeps
+// their retained states up to date. Configs and states are published again after every
+// (re)connect to the broker.
+type HomeAssistant struct {
+	client    Client
+	settings  *conf.Settings
+	nodeID    string
+	baseTopic string
+
+	mu           sync.Mutex
+	day          string          // local date the daily counters belong to
+	sourceCounts map[string]int  // detections today per audio source
+	species      map[string]bool // species detected today
+	last         *haLastDetection
+	announced    map[string]bool // sources whose counter config was published since the last connect
+	update       chan struct{}   // signals that states have changed
+	connected    chan struct{}   // signals that the client has (re)connected
+}
+
+// NewHomeAssistant creates the Home Assistant integration and starts publishing through the
+// client. It sets the on-connect handler of the client.
+func NewHomeAssistant(client Client, settings *conf.Settings) *HomeAssistant {
+	h := &HomeAssistant{
+		client:       client,
+		settings:     settings,
+		nodeID:       haID(settings.Main.Name),
+		baseTopic:    BaseTopic(settings.Realtime.MQTT.Topic),
+		day:          time.Now().Format("2006-01-02"),
+		sourceCounts: make(map[string]int),
+		species:      make(map[string]bool),
+		announced:    make(map[string]bool),
+		update:       make(chan struct{}, 1),
+		connected:    make(chan struct{}, 1),
+	}
+
+	// Sources are announced before their first detection so their counters start at zero
+	for _, source := range settings.EnabledAudioSources() {
+		for _, name := range source.AnalysisSources() {
+			h.sourceCounts[name] = 0
+		}
+	}
+
+	client.SetOnConnectHandler(h.onConnect)
+
+	go h.run()
+
+	// The client may have connected before the handler was set
+	if client.IsConnected() {
+		h.onConnect()
+	}
+	return h
+}
+
+// onConnect wakes up the publisher to announce the node after a connect.
+func (h *HomeAssistant) onConnect() {
+	select {
+	case h.connected <- struct{}{}:
+	default:
+	}
+}
+
+// Seed initializes the daily counters and the last detected species from detections made
+// earlier today, e.g. before a restart.
+func (h *HomeAssistant) Seed(notes []datastore.Note) {
+	h.mu.Lock()
+	for i := range notes {
+		h.record(&notes[i])
+	}
+	h.mu.Unlock()
+	h.signal()
+}
+
+// RecordDetection updates the sensor states with a new detection.
+func (h *HomeAssistant) RecordDetection(note *datastore.Note) {
+	h.mu.Lock()
+	h.record(note)
+	h.mu.Unlock()
+	h.signal()
+}
+
+// record updates the sensor states, the caller must hold the lock.
+func (h *HomeAssistant) record(note *datastore.Note) {
+	detected := note.Timestamp
+	if detected.IsZero() {
+		detected = time.Now()
+	}
+	detected = detected.Local()
+
+	h.rollover()
+	if detected.Format("2006-01-02") != h.day {
+		return
+	}
+
+	h.sourceCounts[note.Source]++
+	h.species[note.ScientificName] = true
+	if h.last == nil || !detected.Before(h.last.detected) {
+		h.last = &haLastDetection{
+			CommonName:     note.CommonName,
+			ScientificName: note.ScientificName,
+			Confidence:     note.Confidence,
+			Source:         note.Source,
+			Time:           detected.Format(time.RFC3339),
+			detected:       detected,
+		}
+	}
+}
+
+// rollover resets the daily counters at midnight, the caller must hold the lock. It reports
+// whether the counters were reset.
+func (h *HomeAssistant) rollover() bool {
+	today := time.Now().Format("2006-01-02")
+	if today == h.day {
+		return false
+	}
+	h.day = today
+	for source := range h.sourceCounts {
+		h.sourceCounts[source] = 0
+	}
+	h.species = make(map[string]bool)
+	return true
+}
+
+// signal wakes up the publisher.
+func (h *HomeAssistant) signal() {
+	select {
+	case h.update <- struct{}{}:
+	default:
+	}
+}
+
+// run publishes discovery configs after each connect and states whenever they change. It also
+// resets the daily counters at midnight.
+func (h *HomeAssistant) run() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.connected:
+			h.mu.Lock()
+			h.announced = make(map[string]bool)
+			h.mu.Unlock()
+			if err := h.publishDiscovery(); err != nil {
+				log.Printf("Failed to publish Home Assistant discovery: %v\n", err)
+				continue
+			}
+		case <-h.update:
+		case <-ticker.C:
+			h.mu.Lock()
+			reset := h.rollover()
+			h.mu.Unlock()
+			if !reset {
+				continue
+			}
+		}
+
+		if !h.client.IsConnected() {
+			continue
+		}
+		if err := h.publishStates(); err != nil {
+			log.Printf("Failed to publish Home Assistant sensor states: %v\n", err)
+		}
+	}
+}
+
+// device returns the Home Assistant device of the node.
+func (h *HomeAssistant) device() haDevice {
+	return haDevice{
+		Identifiers:  []string{"birdnet-go_" + h.nodeID},
+		Name:         "BirdNET-Go " + h.settings.Main.Name,
+		Manufacturer: "BirdNET-Go",
+		Model:        "BirdNET-Go",
+	}
+}
+
+// configTopic returns the discovery topic of a sensor.
+func (h *HomeAssistant) configTopic(objectID string) string {
+	return fmt.Sprintf("%s/sensor/%s/%s/config", h.settings.Realtime.MQTT.HomeAssistant.DiscoveryPrefix, h.nodeID, objectID)
+}
+
+// sourceTopic returns the state topic of the detection counter of a source.
+func (h *HomeAssistant) sourceTopic(source string) string {
+	return fmt.Sprintf("%s/sources/%s/detections_today", h.baseTopic, haID(source))
+}
+
+// publishDiscovery marks the node online and publishes the discovery configs of all sensors.
+func (h *HomeAssistant) publishDiscovery() error {
+	if err := h.publish(AvailabilityTopic(h.settings), PayloadOnline); err != nil {
+		return err
+	}
+
+	configs := map[string]haSensorConfig{
+		"last_species": {
+			Name:                "Last detected species",
+			StateTopic:          h.baseTopic + "/last_species",
+			ValueTemplate:       "{{ value_json.common_name }}",
+			JSONAttributesTopic: h.baseTopic + "/last_species",
+			Icon:                "mdi:bird",
+		},
+		"species_today": {
+			Name:              "Species today",
+			StateTopic:        h.baseTopic + "/species_today",
+			UnitOfMeasurement: "species",
+			StateClass:        "measurement",
+			Icon:              "mdi:counter",
+		},
+	}
+
+	for objectID, config := range configs {
+		if err := h.publishConfig(objectID, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishConfig publishes the discovery config of a sensor.
+func (h *HomeAssistant) publishConfig(objectID string, config haSensorConfig) error {
+	config.ObjectID = h.nodeID + "_" + objectID
+	config.UniqueID = "birdnet-go_" + h.nodeID + "_" + objectID
+	config.AvailabilityTopic = AvailabilityTopic(h.settings)
+	config.Device = h.device()
+
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode discovery config: %w", err)
+	}
+	return h.publish(h.configTopic(objectID), string(payload))
+}
+
+// publishStates publishes the counter configs of newly seen sources and the state of all sensors.
+func (h *HomeAssistant) publishStates() error {
+	h.mu.Lock()
+	var last []byte
+	if h.last != nil {
+		last, _ = json.Marshal(h.last)
+	}
+	speciesToday := len(h.species)
+	counts := make(map[string]int, len(h.sourceCounts))
+	var newSources []string
+	for source, count := range h.sourceCounts {
+		counts[source] = count
+		if !h.announced[source] {
+			newSources = append(newSources, source)
+		}
+	}
+	h.mu.Unlock()
+
+	sort.Strings(newSources)
+	for _, source := range newSources {
+		config := haSensorConfig{
+			Name:              "Detections today " + source,
+			StateTopic:        h.sourceTopic(source),
+			UnitOfMeasurement: "detections",
+			StateClass:        "total_increasing",
+			Icon:              "mdi:counter",
+		}
+		if err := h.publishConfig("detections_"+haID(source), config); err != nil {
+			return err
+		}
+		h.mu.Lock()
+		h.announced[source] = true
+		h.mu.Unlock()
+	}
+
+	if last != nil {
+		if err := h.publish(h.baseTopic+"/last_species", string(last)); err != nil {
+			return err
+		}
+	}
+	if err := h.publish(h.baseTopic+"/species_today", strconv.Itoa(speciesToday)); err != nil {
+		return err
+	}
+	for source, count := range counts {
+		if err := h.publish(h.sourceTopic(source), strconv.Itoa(count)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publish publishes a retained message.
+func (h *HomeAssistant) publish(topic, payload string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), haPublishTimeout)
+	defer cancel()
+	return h.client.PublishRetained(ctx, topic, payload)
+}
@@ -0,0 +1,157 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakeClient records retained messages instead of sending them to a broker.
+type fakeClient struct {
+	mu        sync.Mutex
+	connected bool
+	retained  map[string]string
+	onConnect func()
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{retained: make(map[string]string)}
+}
+
+func (f *fakeClient) Connect(ctx context.Context) error {
+	f.mu.Lock()
+	f.connected = true
+	handler := f.onConnect
+	f.mu.Unlock()
+	if handler != nil {
+		go handler()
+	}
+	return nil
+}
+
+func (f *fakeClient) Publish(ctx context.Context, topic, payload string) error { return nil }
+
+func (f *fakeClient) PublishRetained(ctx context.Context, topic, payload string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retained[topic] = payload
+	return nil
+}
+
+func (f *fakeClient) Subscribe(topic string, handler MessageHandler) error { return nil }
+
+func (f *fakeClient) IsConnected() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeClient) Disconnect() {}
+
+func (f *fakeClient) SetOnConnectHandler(handler func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onConnect = handler
+}
+
+// waitFor polls the retained messages until topic has the expected payload.
+func (f *fakeClient) waitFor(t *testing.T, topic, want string) string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		f.mu.Lock()
+		got, ok := f.retained[topic]
+		f.mu.Unlock()
+		if ok && (want == "" || got == want) {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("topic %s: got %q, want %q", topic, got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHomeAssistantDiscoveryAndStates(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Main.Name = "Back Yard"
+	settings.Realtime.MQTT.Topic = "birdnet"
+	settings.Realtime.MQTT.HomeAssistant.Enabled = true
+	settings.Realtime.MQTT.HomeAssistant.DiscoveryPrefix = "homeassistant"
+
+	client := newFakeClient()
+	ha := NewHomeAssistant(client, settings)
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	client.waitFor(t, "birdnet/status", PayloadOnline)
+	config := client.waitFor(t, "homeassistant/sensor/back_yard/last_species/config", "")
+
+	var decoded haSensorConfig
+	if err := json.Unmarshal([]byte(config), &decoded); err != nil {
+		t.Fatalf("invalid discovery config: %v", err)
+	}
+	if decoded.StateTopic != "birdnet/last_species" || decoded.AvailabilityTopic != "birdnet/status" {
+		t.Errorf("unexpected discovery config: %+v", decoded)
+	}
+
+	now := time.Now()
+	ha.RecordDetection(&datastore.Note{CommonName: "Great Tit", ScientificName: "Parus major", Source: "garden", Timestamp: now})
+	ha.RecordDetection(&datastore.Note{CommonName: "Great Tit", ScientificName: "Parus major", Source: "garden", Timestamp: now})
+	ha.RecordDetection(&datastore.Note{CommonName: "Blue Tit", ScientificName: "Cyanistes caeruleus", Source: "pond", Timestamp: now})
+
+	client.waitFor(t, "birdnet/species_today", "2")
+	client.waitFor(t, "birdnet/sources/garden/detections_today", "2")
+	client.waitFor(t, "birdnet/sources/pond/detections_today", "1")
+	client.waitFor(t, "homeassistant/sensor/back_yard/detections_pond/config", "")
+
+	var last haLastDetection
+	if err := json.Unmarshal([]byte(client.waitFor(t, "birdnet/last_species", "")), &last); err != nil {
+		t.Fatalf("invalid last species state: %v", err)
+	}
+	if last.CommonName != "Blue Tit" || last.Source != "pond" {
+		t.Errorf("unexpected last species state: %+v", last)
+	}
+}
+
+func TestHomeAssistantIgnoresEarlierDays(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.MQTT.Topic = "birdnet"
+
+	ha := NewHomeAssistant(newFakeClient(), settings)
+	ha.Seed([]datastore.Note{
+		{ScientificName: "Parus major", Source: "garden", Timestamp: time.Now().AddDate(0, 0, -1)},
+		{ScientificName: "Parus major", Source: "garden", Timestamp: time.Now()},
+	})
+
+	ha.mu.Lock()
+	defer ha.mu.Unlock()
+	if ha.sourceCounts["garden"] != 1 || len(ha.species) != 1 {
+		t.Errorf("expected only today's detection to be counted, got %v", ha.sourceCounts)
+	}
+}
+
+func TestHomeAssistantLastDetectionOrder(t *testing.T) {
+	settings := &conf.Settings{}
+	settings.Realtime.MQTT.Topic = "birdnet"
+
+	// Detections may be recorded out of order, e.g. when seeding or from several sources
+	now := time.Now()
+	ha := NewHomeAssistant(newFakeClient(), settings)
+	ha.Seed([]datastore.Note{
+		{CommonName: "Blue Tit", Source: "pond", Timestamp: now},
+		{CommonName: "Great Tit", Source: "garden", Timestamp: now.Add(-time.Second)},
+	})
+
+	ha.mu.Lock()
+	defer ha.mu.Unlock()
+	if ha.last == nil || ha.last.CommonName != "Blue Tit" || !ha.last.detected.Equal(now.Local()) {
+		t.Errorf("expected the latest detection to be the last detection, got %+v", ha.last)
+	}
+}
@@ -16,13 +16,28 @@ type Client interface {
 	// It returns an error if the publish operation fails.
 	Publish(ctx context.Context, topic string, payload string) error
 
+	// PublishRetained sends a message that the broker retains and delivers to new subscribers
+	// of the topic.
+	PublishRetained(ctx context.Context, topic string, payload string) error
+
+	// Subscribe registers a handler for messages received on the topic. Subscriptions are
+	// restored after every reconnect to the MQTT broker.
+	Subscribe(topic string, handler MessageHandler) error
+
 	// IsConnected returns true if the client is currently connected to the MQTT broker.
 	IsConnected() bool
 
 	// Disconnect closes the connection to the MQTT broker.
 	Disconnect()
+
+	// SetOnConnectHandler sets a function that is called in its own goroutine each time the
+	// client has connected or reconnected to the MQTT broker.
+	SetOnConnectHandler(handler func())
 }
 
+// MessageHandler handles a message received on a subscribed topic.
+type MessageHandler func(topic string, payload []byte)
+
 // Config holds the configuration for the MQTT client.
 type Config struct {
 	Broker            string
@@ -35,6 +50,12 @@ type Config struct {
 	ConnectTimeout    time.Duration
 	PublishTimeout    time.Duration
 	DisconnectTimeout time.Duration
+	// Quality of service level of published messages
+	QoS byte
+	// Last will published by the broker as a retained message when the client disconnects
+	// unexpectedly, and by the client before a clean disconnect. Disabled if WillTopic is empty.
+	WillTopic   string
+	WillPayload string
 }
 
 // DefaultConfig returns a Config with reasonable default values
@@ -45,5 +66,6 @@ func DefaultConfig() Config {
 		ConnectTimeout:    30 * time.Second,
 		PublishTimeout:    10 * time.Second,
 		DisconnectTimeout: 250 * time.Millisecond,
+		QoS:               defaultQoS,
 	}
 }
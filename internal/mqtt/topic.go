@@ -0,0 +1,93 @@
+// topic.go: MQTT topic templates
+package mqtt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// topicPlaceholderPattern matches placeholders in topic templates, e.g. {species_code}
+var topicPlaceholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// topicFields returns the values of the placeholders available in topic templates.
+func topicFields(note *datastore.Note) map[string]string {
+	return map[string]string{
+		"source":          note.Source,
+		"channel":         strconv.Itoa(note.Channel),
+		"node":            note.SourceNode,
+		"species_code":    note.SpeciesCode,
+		"common_name":     note.CommonName,
+		"scientific_name": note.ScientificName,
+		"event":           note.DetectionEvent,
+	}
+}
+
+// ValidateTopicTemplate checks that a topic template only uses known placeholders and does not
+// contain wildcards.
+func ValidateTopicTemplate(template string) error {
+	if strings.ContainsAny(template, "+#") {
+		return fmt.Errorf("topic %q must not contain MQTT wildcards", template)
+	}
+
+	fields := topicFields(&datastore.Note{})
+	for _, match := range topicPlaceholderPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := fields[match[1]]; !ok {
+			return fmt.Errorf("topic %q uses unknown placeholder {%s}", template, match[1])
+		}
+	}
+
+	if rest := topicPlaceholderPattern.ReplaceAllString(template, ""); strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("topic %q has an invalid placeholder", template)
+	}
+	return nil
+}
+
+// ExpandTopic replaces the placeholders of a topic template with the fields of a note. Values
+// are made safe for use as a topic level, empty values are replaced with "unknown".
+func ExpandTopic(template string, note *datastore.Note) string {
+	if !strings.Contains(template, "{") {
+		return template
+	}
+
+	fields := topicFields(note)
+	return topicPlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value, ok := fields[placeholder[1:len(placeholder)-1]]
+		if !ok {
+			return placeholder
+		}
+		return topicLevel(value)
+	})
+}
+
+// topicLevel converts a value to a single topic level without wildcards or separators.
+func topicLevel(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#', ' ':
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// BaseTopic returns the static part of a topic template before its first placeholder, used as
+// the root of topics that do not depend on a detection.
+func BaseTopic(template string) string {
+	base := template
+	if i := strings.Index(base, "{"); i >= 0 {
+		base = base[:i]
+	}
+	base = strings.TrimRight(base, "/")
+	if base == "" {
+		return "birdnet"
+	}
+	return base
+}
@@ -0,0 +1,61 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestExpandTopic(t *testing.T) {
+	note := &datastore.Note{
+		Source:         "rtsp://cam/stream",
+		SpeciesCode:    "gretit1",
+		CommonName:     "Great Tit",
+		ScientificName: "Parus major",
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"birdnet", "birdnet"},
+		{"birdnet/{source}/{species_code}", "birdnet/rtsp:__cam_stream/gretit1"},
+		{"birds/{common_name}/{event}", "birds/Great_Tit/unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandTopic(tt.template, note); got != tt.want {
+			t.Errorf("ExpandTopic(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTopicTemplate(t *testing.T) {
+	valid := []string{"birdnet", "birdnet/{source}/{species_code}", "{node}/{scientific_name}"}
+	for _, template := range valid {
+		if err := ValidateTopicTemplate(template); err != nil {
+			t.Errorf("ValidateTopicTemplate(%q) returned error: %v", template, err)
+		}
+	}
+
+	invalid := []string{"birdnet/#", "birdnet/+/x", "birdnet/{species}", "birdnet/{Source}", "birdnet/{source"}
+	for _, template := range invalid {
+		if err := ValidateTopicTemplate(template); err == nil {
+			t.Errorf("ValidateTopicTemplate(%q) expected error", template)
+		}
+	}
+}
+
+func TestBaseTopic(t *testing.T) {
+	tests := map[string]string{
+		"birdnet":                   "birdnet",
+		"birdnet/{source}/{common}": "birdnet",
+		"home/birds/{source}":       "home/birds",
+		"{source}":                  "birdnet",
+	}
+	for template, want := range tests {
+		if got := BaseTopic(template); got != want {
+			t.Errorf("BaseTopic(%q) = %q, want %q", template, got, want)
+		}
+	}
+}
@@ -30,8 +30,26 @@ var (
 	prevData        map[string][]byte                 // prevData is a map to store the previous data for each audio source
 	abMutex         sync.RWMutex                      // Mutex to protect access to the analysisBuffers and prevData maps
 	warningCounter  map[string]int
+	pausedSources   sync.Map // analysis sources whose audio is discarded instead of analyzed
 )
 
+// PauseSource stops analysis of an analysis source until it is resumed. Audio is still captured
+// so that clips and audio levels remain available.
+func PauseSource(source string) {
+	pausedSources.Store(source, true)
+}
+
+// ResumeSource resumes analysis of a paused analysis source.
+func ResumeSource(source string) {
+	pausedSources.Delete(source)
+}
+
+// IsSourcePaused reports whether analysis of an analysis source is paused.
+func IsSourcePaused(source string) bool {
+	_, paused := pausedSources.Load(source)
+	return paused
+}
+
 // init initializes the warningCounter map
 func init() {
 	warningCounter = make(map[string]int)
@@ -276,8 +294,9 @@ func ReadFromAnalysisBuffer(stream string) ([]byte, error) {
 
 // AnalysisBufferMonitor monitors the buffer and processes audio data when enough data is present.
 func AnalysisBufferMonitor(wg *sync.WaitGroup, bn *birdnet.BirdNET, quitChan chan struct{}, source string) {
-	// preRecordingTime is the time to subtract from the current time to get the start time of the detection
-	const preRecordingTime = -5000 * time.Millisecond
+	// chunkDuration is the length of the analyzed audio, which ends at the time it is read from
+	// the buffer. Clip pre-roll is added when the detection is approved.
+	const chunkDuration = conf.CaptureLength * time.Second
 
 	defer wg.Done()
 
@@ -298,15 +317,15 @@ func AnalysisBufferMonitor(wg *sync.WaitGroup, bn *birdnet.BirdNET, quitChan cha
 				time.Sleep(1 * time.Second) // Wait for 1 second before trying again
 				continue
 			}
-			// if buffer has 3 seconds of data, process it
-			if len(data) == conf.BufferSize {
+			// if buffer has 3 seconds of data, process it unless the source is paused
+			if len(data) == conf.BufferSize && !IsSourcePaused(source) {
 
 				/*if err := validatePCMData(data); err != nil {
 					log.Printf("Invalid PCM data for source %s: %v", source, err)
 					continue
 				}*/
 
-				startTime := time.Now().Add(preRecordingTime)
+				startTime := time.Now().Add(-chunkDuration)
 				// DEBUG
 				//log.Printf("Processing data for source %s", source)
 				err := ProcessData(bn, data, startTime, source)
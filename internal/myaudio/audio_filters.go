@@ -9,78 +9,71 @@ import (
 	"github.com/tphakala/birdnet-go/internal/myaudio/equalizer"
 )
 
-// Global variables for filter chain and mutex
+// Global variables for filter chains and mutex
 var (
-	filterChain *equalizer.FilterChain
-	filterMutex sync.RWMutex
+	filterChains map[string]*equalizer.FilterChain // equalizer filter chain of each audio source
+	filterMutex  sync.RWMutex
 )
 
-// InitializeFilterChain sets up the initial filter chain based on settings
+// InitializeFilterChain sets up the filter chains of all audio sources based on settings
 func InitializeFilterChain(settings *conf.Settings) error {
-	filterMutex.Lock()
-	defer filterMutex.Unlock()
-
-	// Create a new filter chain
-	filterChain = equalizer.NewFilterChain()
+	return UpdateFilterChain(settings)
+}
 
-	// If equalizer is enabled in settings, add filters
-	if settings.Realtime.Audio.Equalizer.Enabled {
-		for _, filterConfig := range settings.Realtime.Audio.Equalizer.Filters {
-			// Create and add each filter
-			filter, err := createFilter(filterConfig, float64(conf.SampleRate))
+// UpdateFilterChain rebuilds the filter chains of all analysis sources based on new settings
+func UpdateFilterChain(settings *conf.Settings) error {
+	// Build the new chains before taking the lock so capture is not blocked
+	newChains := make(map[string]*equalizer.FilterChain)
+	for _, source := range settings.AudioSources() {
+		// Each channel of a split source keeps its own filter state
+		for _, name := range source.AnalysisSources() {
+			chain, err := newFilterChain(source.Equalizer)
 			if err != nil {
-				return err
-			}
-			if filter != nil {
-				if err := filterChain.AddFilter(filter); err != nil {
-					return fmt.Errorf("failed to add audio EQ filter: %w", err)
-				}
+				return fmt.Errorf("audio source %s: %w", source.Name, err)
 			}
+			newChains[name] = chain
 		}
 	}
 
-	return nil
-}
-
-// UpdateFilterChain updates the filter chain based on new settings
-func UpdateFilterChain(settings *conf.Settings) error {
-	// Lock the mutex to ensure thread-safety
+	// Replace the old filter chains with the new ones
 	filterMutex.Lock()
 	defer filterMutex.Unlock()
+	filterChains = newChains
+	return nil
+}
 
-	// Create a new filter chain
-	newChain := equalizer.NewFilterChain()
+// newFilterChain creates a filter chain from equalizer settings
+func newFilterChain(eq conf.EqualizerSettings) (*equalizer.FilterChain, error) {
+	chain := equalizer.NewFilterChain()
 
 	// If equalizer is enabled in settings, add filters
-	if settings.Realtime.Audio.Equalizer.Enabled {
+	if eq.Enabled {
 		// Iterate through each filter configuration
-		for _, filterConfig := range settings.Realtime.Audio.Equalizer.Filters {
+		for _, filterConfig := range eq.Filters {
 			// Create a new filter based on the configuration
 			filter, err := createFilter(filterConfig, float64(conf.SampleRate))
 			if err != nil {
-				return fmt.Errorf("failed to create audio EQ filter: %w", err)
+				return nil, fmt.Errorf("failed to create audio EQ filter: %w", err)
 			}
 			// If filter was successfully created, add it to the new chain
 			if filter != nil {
-				if err := newChain.AddFilter(filter); err != nil {
-					return fmt.Errorf("failed to add audio EQ filter: %w", err)
+				if err := chain.AddFilter(filter); err != nil {
+					return nil, fmt.Errorf("failed to add audio EQ filter: %w", err)
 				}
 			}
 		}
 	}
 
-	// Replace the old filter chain with the new one
-	filterChain = newChain
-	return nil
+	return chain, nil
 }
 
-// ResetFilters resets the state of all filters in the current filter chain
+// ResetFilters resets the state of all filters in the current filter chains
 func ResetFilters() {
 	filterMutex.Lock()
 	defer filterMutex.Unlock()
 
-	if filterChain != nil {
-		filterChain.Reset()
+	for _, chain := range filterChains {
+		chain.Reset()
 	}
 }
 
@@ -114,8 +107,8 @@ func createFilter(config conf.EqualizerFilter, sampleRate float64) (*equalizer.F
 	}
 }
 
-// ApplyFilters applies the current filter chain to a byte slice of audio samples
-func ApplyFilters(samples []byte) error {
+// ApplyFilters applies the filter chain of an audio source to a byte slice of audio samples
+func ApplyFilters(source string, samples []byte) error {
 	if len(samples)%2 != 0 {
 		return fmt.Errorf("invalid sample length: must be even")
 	}
@@ -124,7 +117,8 @@ func ApplyFilters(samples []byte) error {
 	defer filterMutex.RUnlock()
 
 	// If no filters, return early
-	if filterChain.Length() == 0 {
+	filterChain := filterChains[source]
+	if filterChain == nil || filterChain.Length() == 0 {
 		return nil // No filters to apply
 	}
 
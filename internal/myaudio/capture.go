@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"os"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -34,8 +34,9 @@ type AudioDeviceInfo struct {
 
 // AudioLevelData holds audio level data
 type AudioLevelData struct {
-	Level    int  // 0-100
-	Clipping bool // true if clipping is detected
+	Level    int    // 0-100
+	Clipping bool   // true if clipping is detected
+	Source   string // name of the audio source
 }
 
 // activeStreams keeps track of currently active RTSP streams
@@ -150,205 +151,250 @@ func SetAudioDevice(deviceName string) (string, error) {
 	return infos[index].Name(), nil
 }
 
-// ReconfigureRTSPStreams handles dynamic reconfiguration of RTSP streams
-func ReconfigureRTSPStreams(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
-	// Initialize FFmpeg monitor if not already running
-	if ffmpegMonitor == nil {
-		ffmpegMonitor = NewFFmpegMonitor()
-		ffmpegMonitor.Start()
+// allocateSourceBuffers allocates the analysis and capture buffers of an audio source
+// unless they already exist.
+func allocateSourceBuffers(source string) error {
+	// Check if analysis buffer exists
+	abMutex.RLock()
+	_, abExists := analysisBuffers[source]
+	abMutex.RUnlock()
+
+	// Check if capture buffer exists
+	cbMutex.RLock()
+	_, cbExists := captureBuffers[source]
+	cbMutex.RUnlock()
+
+	// Initialize analysis buffer if it doesn't exist
+	if !abExists {
+		if err := AllocateAnalysisBuffer(conf.BufferSize*3, source); err != nil {
+			return fmt.Errorf("failed to initialize analysis buffer: %w", err)
+		}
 	}
 
-	// Get current active streams
-	currentStreams := make(map[string]bool)
-	activeStreams.Range(func(key, value interface{}) bool {
-		currentStreams[key.(string)] = true
-		return true
-	})
-
-	// Stop streams that are no longer in settings
-	for url := range currentStreams {
-		found := false
-		for _, newURL := range settings.Realtime.RTSP.URLs {
-			if url == newURL {
-				found = true
-				break
-			}
-		}
-		if !found {
-			// Stream is no longer in settings, stop it
-			if process, exists := ffmpegProcesses.Load(url); exists {
-				if p, ok := process.(*FFmpegProcess); ok {
-					// Stop the FFmpeg process first
-					p.Cleanup(url)
-					// Wait a short time for the process to fully stop
-					time.Sleep(100 * time.Millisecond)
+	// Initialize capture buffer if it doesn't exist
+	if !cbExists {
+		if err := AllocateCaptureBuffer(60, conf.SampleRate, conf.BitDepth/8, source); err != nil {
+			// Clean up the analysis buffer if we just created it
+			if !abExists {
+				if err := RemoveAnalysisBuffer(source); err != nil {
+					log.Printf("❌ Failed to remove analysis buffer for %s: %v", source, err)
 				}
 			}
+			return fmt.Errorf("failed to initialize capture buffer: %w", err)
+		}
+	}
 
-			// Mark stream as inactive before removing buffers
-			activeStreams.Delete(url)
-			log.Printf("⬇️ Stream %s removed", url)
-			// Wait a short time for any in-flight writes to complete
-			time.Sleep(100 * time.Millisecond)
+	return nil
+}
 
-			// Now it's safe to remove the buffers
-			if err := RemoveAnalysisBuffer(url); err != nil {
-				log.Printf("❌ Warning: failed to remove analysis buffer for %s: %v", url, err)
-			}
-			if err := RemoveCaptureBuffer(url); err != nil {
-				log.Printf("❌ Warning: failed to remove capture buffer for %s: %v", url, err)
-			}
+// newFFmpegConfig creates the FFmpeg configuration of a stream source
+func newFFmpegConfig(source *conf.AudioSourceSettings) FFmpegConfig {
+	return FFmpegConfig{
+		Source:      source.Name,
+		Type:        source.Type,
+		URL:         source.URL,
+		Transport:   source.Transport,
+		Channels:    source.CaptureChannels(),
+		ChannelMode: source.ChannelMode,
+	}
+}
+
+// analysisSources returns the names of the analysis sources of the stream
+func (config *FFmpegConfig) analysisSources() []string {
+	source := conf.AudioSourceSettings{Name: config.Source, Channels: config.Channels, ChannelMode: config.ChannelMode}
+	return source.AnalysisSources()
+}
+
+// allocateAnalysisSources allocates the buffers of all analysis sources of an audio source.
+func allocateAnalysisSources(names []string) error {
+	for _, name := range names {
+		if err := allocateSourceBuffers(name); err != nil {
+			return fmt.Errorf("source %s: %w", name, err)
 		}
 	}
+	return nil
+}
 
-	// Start new streams
-	for _, url := range settings.Realtime.RTSP.URLs {
-		// Check if stream is already active
-		if _, exists := activeStreams.Load(url); exists {
-			continue
+// removeAnalysisSources removes the buffers of all analysis sources of an audio source.
+func removeAnalysisSources(names []string) {
+	for _, name := range names {
+		if err := RemoveAnalysisBuffer(name); err != nil {
+			log.Printf("❌ Warning: failed to remove analysis buffer for %s: %v", name, err)
 		}
+		if err := RemoveCaptureBuffer(name); err != nil {
+			log.Printf("❌ Warning: failed to remove capture buffer for %s: %v", name, err)
+		}
+	}
+}
 
-		abExists, cbExists := false, false //nolint:wastedassign // Need to initialize variables
-		// Check if analysis buffer exists
-		abMutex.RLock()
-		_, abExists = analysisBuffers[url]
-		abMutex.RUnlock()
+// soundCardCapture tracks a running sound card capture.
+type soundCardCapture struct {
+	device   string        // configured device name or ID
+	channels int           // number of captured channels
+	split    bool          // true if channels are analyzed separately
+	sources  []string      // analysis sources of the sound card
+	stop     chan struct{} // closed to stop the capture
+	done     chan struct{} // closed when the capture has stopped
+}
 
-		// Check if capture buffer exists
-		cbMutex.RLock()
-		_, cbExists = captureBuffers[url]
-		cbMutex.RUnlock()
+// activeSoundCards keeps track of currently captured sound cards by source name
+var activeSoundCards sync.Map
+
+// reconfigureMutex serializes reconfiguration of the audio sources
+var reconfigureMutex sync.Mutex
+
+// ReconfigureSources starts, stops and restarts audio sources to match the settings. Sources
+// that are unchanged keep running.
+func ReconfigureSources(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
+	reconfigureMutex.Lock()
+	defer reconfigureMutex.Unlock()
+
+	streams := make(map[string]FFmpegConfig)
+	soundCards := make(map[string]conf.AudioSourceSettings)
+	for _, source := range settings.EnabledAudioSources() {
+		switch {
+		case source.IsStream():
+			streams[source.Name] = newFFmpegConfig(&source)
+		case source.Type == conf.SourceTypeSoundCard:
+			soundCards[source.Name] = source
+		}
+	}
 
-		// Initialize analysis buffer if it doesn't exist
-		if !abExists {
-			if err := AllocateAnalysisBuffer(conf.BufferSize*3, url); err != nil {
-				log.Printf("❌ Failed to initialize analysis buffer for %s: %v", url, err)
-				continue
-			}
+	reconfigureSoundCards(settings, soundCards, wg, quitChan, audioLevelChan)
+	reconfigureStreams(streams, wg, quitChan, restartChan, audioLevelChan)
+}
+
+// reconfigureSoundCards stops sound cards that were removed or changed and starts new ones.
+func reconfigureSoundCards(settings *conf.Settings, configured map[string]conf.AudioSourceSettings, wg *sync.WaitGroup, quitChan chan struct{}, audioLevelChan chan AudioLevelData) {
+	// Stop sound cards that are no longer in settings or whose device changed
+	activeSoundCards.Range(func(key, value interface{}) bool {
+		name := key.(string)
+		capture := value.(*soundCardCapture)
+		if source, found := configured[name]; found && source.Device == capture.device &&
+			source.CaptureChannels() == capture.channels && source.IsSplit() == capture.split {
+			return true
 		}
 
-		// Initialize capture buffer if it doesn't exist
-		if !cbExists {
-			if err := AllocateCaptureBuffer(60, conf.SampleRate, conf.BitDepth/8, url); err != nil {
-				// Clean up the ring buffer if audio buffer init fails and we just created it
-				if !abExists {
-					err := RemoveCaptureBuffer(url)
-					if err != nil {
-						log.Printf("❌ Failed to remove capture buffer for %s: %v", url, err)
-					}
-				}
-				log.Printf("❌ Failed to initialize capture buffer for %s: %v", url, err)
-				continue
-			}
+		close(capture.stop)
+		select {
+		case <-capture.done:
+		case <-time.After(5 * time.Second):
+			log.Printf("⚠️ Sound card %s did not stop in time", name)
 		}
+		activeSoundCards.Delete(name)
+		log.Printf("⬇️ Sound card %s removed", name)
+
+		removeAnalysisSources(capture.sources)
+		return true
+	})
+
+	// Start new sound cards
+	for name, source := range configured {
+		if _, exists := activeSoundCards.Load(name); exists {
+			continue
+		}
+
+		sources := source.AnalysisSources()
+		if err := allocateAnalysisSources(sources); err != nil {
+			log.Printf("❌ Failed to initialize buffers for %s: %v", name, err)
+			continue
+		}
+
+		capture := &soundCardCapture{
+			device:   source.Device,
+			channels: source.CaptureChannels(),
+			split:    source.IsSplit(),
+			sources:  sources,
+			stop:     make(chan struct{}),
+			done:     make(chan struct{}),
+		}
+		activeSoundCards.Store(name, capture)
 
-		// New stream, start it
 		wg.Add(1)
-		activeStreams.Store(url, true)
-		go CaptureAudioRTSP(url, settings.Realtime.RTSP.Transport, wg, quitChan, restartChan, audioLevelChan)
+		go captureAudioMalgo(settings, source, wg, quitChan, capture, audioLevelChan)
 	}
+}
 
-	// If no more RTSP streams are configured, stop the FFmpeg monitor
-	if len(settings.Realtime.RTSP.URLs) == 0 && ffmpegMonitor != nil {
-		ffmpegMonitor.Stop()
-		ffmpegMonitor = nil
+// reconfigureStreams stops streams that were removed or changed and starts new ones.
+func reconfigureStreams(configured map[string]FFmpegConfig, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
+	// Initialize FFmpeg monitor if streams are configured and it is not already running
+	if len(configured) > 0 && ffmpegMonitor == nil {
+		ffmpegMonitor = NewFFmpegMonitor()
+		ffmpegMonitor.Start()
 	}
-}
 
-func CaptureAudio(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
-	// If no RTSP URLs and no audio device configured, log a friendly message and return
-	if len(settings.Realtime.RTSP.URLs) == 0 && settings.Realtime.Audio.Source == "" {
-		return
-	}
-
-	// Initialize buffers for each audio source
-	if len(settings.Realtime.RTSP.URLs) > 0 {
-		for _, url := range settings.Realtime.RTSP.URLs {
-			abExists, cbExists := false, false //nolint:wastedassign // Need to initialize variables
-			// Check if analysis buffer already exists
-			abMutex.RLock()
-			_, abExists = analysisBuffers[url]
-			abMutex.RUnlock()
-
-			// Check if capture buffer exists
-			cbMutex.RLock()
-			_, cbExists = captureBuffers[url]
-			cbMutex.RUnlock()
-
-			// Initialize analysis buffer if it doesn't exist
-			if !abExists {
-				if err := AllocateAnalysisBuffer(conf.BufferSize*3, url); err != nil {
-					log.Printf("❌ Failed to initialize analysis buffer for %s: %v", url, err)
-					continue
-				}
-			}
+	// Get current active streams
+	currentStreams := make(map[string]FFmpegConfig)
+	activeStreams.Range(func(key, value interface{}) bool {
+		currentStreams[key.(string)] = value.(FFmpegConfig)
+		return true
+	})
 
-			// Initialize capture buffer if it doesn't exist
-			if !cbExists {
-				if err := AllocateCaptureBuffer(60, conf.SampleRate, conf.BitDepth/8, url); err != nil {
-					// Clean up the ring buffer if audio buffer init fails and we just created it
-					if !cbExists {
-						err := RemoveCaptureBuffer(url)
-						if err != nil {
-							log.Printf("❌ Failed to remove capture buffer for %s: %v", url, err)
-						}
-					}
-					log.Printf("❌ Failed to initialize capture buffer for %s: %v", url, err)
-					continue
-				}
-			}
+	// Stop streams that are no longer in settings or whose configuration changed
+	for name, current := range currentStreams {
+		if config, found := configured[name]; found && config == current {
+			continue
+		}
 
-			wg.Add(1)
-			activeStreams.Store(url, true)
-			go CaptureAudioRTSP(url, settings.Realtime.RTSP.Transport, wg, quitChan, restartChan, audioLevelChan)
+		if process, exists := ffmpegProcesses.Load(name); exists {
+			if p, ok := process.(*FFmpegProcess); ok {
+				// Stop the FFmpeg process first
+				p.Cleanup(name)
+				// Wait a short time for the process to fully stop
+				time.Sleep(100 * time.Millisecond)
+			}
 		}
-	}
 
-	if settings.Realtime.Audio.Source != "" {
-		abExists, cbExists := false, false //nolint:wastedassign // Need to initialize variables
-		// Check if analysis buffer exists
-		abMutex.RLock()
-		_, abExists = analysisBuffers["malgo"]
-		abMutex.RUnlock()
+		// Mark stream as inactive before removing buffers
+		activeStreams.Delete(name)
+		log.Printf("⬇️ Stream %s removed", name)
+		// Wait a short time for any in-flight writes to complete
+		time.Sleep(100 * time.Millisecond)
 
-		// Check if capture buffer exists
-		cbMutex.RLock()
-		_, cbExists = captureBuffers["malgo"]
-		cbMutex.RUnlock()
+		// Now it's safe to remove the buffers
+		removeAnalysisSources(current.analysisSources())
+	}
 
-		// Initialize analysis buffer if it doesn't exist
-		if !abExists {
-			if err := AllocateAnalysisBuffer(conf.BufferSize*3, "malgo"); err != nil {
-				log.Printf("❌ Failed to initialize analysis buffer for device capture: %v", err)
-				return
-			}
+	// Start new streams, streams restart themselves so running ones are skipped
+	for name, config := range configured {
+		if _, exists := activeStreams.Load(name); exists {
+			continue
 		}
 
-		// Initialize capture buffer if it doesn't exist
-		if !cbExists {
-			if err := AllocateCaptureBuffer(60, conf.SampleRate, conf.BitDepth/8, "malgo"); err != nil {
-				// Clean up the ring buffer if audio buffer init fails and we just created it
-				if !cbExists {
-					err := RemoveCaptureBuffer("malgo")
-					if err != nil {
-						log.Printf("❌ Failed to remove capture buffer for device capture: %v", err)
-					}
-				}
-				log.Printf("❌ Failed to initialize capture buffer for device capture: %v", err)
-				return
-			}
+		if err := allocateAnalysisSources(config.analysisSources()); err != nil {
+			log.Printf("❌ Failed to initialize buffers for %s: %v", name, err)
+			continue
 		}
 
-		// Device audio capture
+		// New stream, start it
 		wg.Add(1)
-		go captureAudioMalgo(settings, wg, quitChan, restartChan, audioLevelChan)
+		activeStreams.Store(name, config)
+		go CaptureAudioStream(config, wg, quitChan, restartChan, audioLevelChan)
+	}
+
+	// If no more streams are configured, stop the FFmpeg monitor
+	if len(configured) == 0 && ffmpegMonitor != nil {
+		ffmpegMonitor.Stop()
+		ffmpegMonitor = nil
+	}
+}
+
+// CaptureAudio starts capturing all enabled audio sources that are not already running.
+func CaptureAudio(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
+	// Initialize the filter chains of all sources
+	if err := InitializeFilterChain(settings); err != nil {
+		log.Printf("❌ Error initializing filter chain: %v", err)
 	}
+
+	ReconfigureSources(settings, wg, quitChan, restartChan, audioLevelChan)
 }
 
 // selectCaptureSource selects an appropriate capture device based on the provided settings and available device information.
-// It prints available devices and returns the selected device and any error encountered.
-func selectCaptureSource(settings *conf.Settings, infos []malgo.DeviceInfo) (captureSource, error) {
-	fmt.Println("Available Capture Sources:")
+// It prints available devices if verbose is set and returns the selected device and any error encountered.
+func selectCaptureSource(device string, infos []malgo.DeviceInfo, verbose bool) (captureSource, error) {
+	if verbose {
+		fmt.Println("Available Capture Sources:")
+	}
 
 	var selectedSource captureSource
 	var deviceFound bool
@@ -362,7 +408,9 @@ func selectCaptureSource(settings *conf.Settings, infos []malgo.DeviceInfo) (cap
 		// Decode the device ID from hexadecimal to ASCII
 		decodedID, err := hexToASCII(infos[i].ID.String())
 		if err != nil {
-			fmt.Printf("❌ Error decoding ID for device %d: %v\n", i, err)
+			if verbose {
+				fmt.Printf("❌ Error decoding ID for device %d: %v\n", i, err)
+			}
 			continue
 		}
 
@@ -373,7 +421,7 @@ func selectCaptureSource(settings *conf.Settings, infos []malgo.DeviceInfo) (cap
 		}
 
 		// Determine if the current device matches the specified settings
-		if matchesDeviceSettings(decodedID, &infos[i], settings.Realtime.Audio.Source) {
+		if matchesDeviceSettings(decodedID, &infos[i], device) {
 			selectedSource = captureSource{
 				Name:    infos[i].Name(),
 				ID:      decodedID,
@@ -382,7 +430,9 @@ func selectCaptureSource(settings *conf.Settings, infos []malgo.DeviceInfo) (cap
 			deviceFound = true
 		}
 
-		fmt.Println(output)
+		if verbose {
+			fmt.Println(output)
+		}
 	}
 
 	// Check if running in container and only null device is available
@@ -395,7 +445,7 @@ func selectCaptureSource(settings *conf.Settings, infos []malgo.DeviceInfo) (cap
 
 	// If no device was found, return error with more descriptive message
 	if !deviceFound {
-		return captureSource{}, fmt.Errorf("no suitable capture source found for device setting '%s'", settings.Realtime.Audio.Source)
+		return captureSource{}, fmt.Errorf("no suitable capture source found for device setting '%s'", device)
 	}
 
 	return selectedSource, nil
@@ -420,10 +470,52 @@ func hexToASCII(hexStr string) (string, error) {
 	return string(bytes), nil
 }
 
-func captureAudioMalgo(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
+// soundCardWatchdogTimeout is the time without audio data after which a sound card is reopened
+const soundCardWatchdogTimeout = 30 * time.Second
+
+// captureAudioMalgo captures a sound card until it is stopped. When the device fails or
+// disappears it is reopened with an increasing delay, without affecting other sources.
+func captureAudioMalgo(settings *conf.Settings, source conf.AudioSourceSettings, wg *sync.WaitGroup, quitChan chan struct{}, capture *soundCardCapture, audioLevelChan chan AudioLevelData) {
 	defer wg.Done() // Ensure this is called when the goroutine exits
-	var device *malgo.Device
+	defer close(capture.done)
+
+	const (
+		initialRetryDelay = 5 * time.Second
+		maxRetryDelay     = time.Minute
+	)
+	retryDelay := initialRetryDelay
+
+	for attempt := 0; ; attempt++ {
+		started, err := runMalgoDevice(settings, source, quitChan, capture.stop, audioLevelChan, attempt == 0)
+		if err == nil {
+			// Capture was stopped on request
+			return
+		}
+		if started {
+			// The device was working, retry quickly
+			retryDelay = initialRetryDelay
+		}
+		log.Printf("❌ Sound card %s: %v, retrying in %v", source.Name, err, retryDelay)
 
+		select {
+		case <-quitChan:
+			return
+		case <-capture.stop:
+			return
+		case <-time.After(retryDelay):
+		}
+
+		retryDelay *= 2
+		if retryDelay > maxRetryDelay {
+			retryDelay = maxRetryDelay
+		}
+	}
+}
+
+// runMalgoDevice opens the sound card of the source and captures it until a stop signal is
+// received, which returns nil, or until the device fails. started reports whether the device
+// was running before it failed.
+func runMalgoDevice(settings *conf.Settings, source conf.AudioSourceSettings, quitChan, stopChan chan struct{}, audioLevelChan chan AudioLevelData, verbose bool) (started bool, err error) {
 	if settings.Debug {
 		fmt.Println("Initializing context")
 	}
@@ -445,97 +537,51 @@ func captureAudioMalgo(settings *conf.Settings, wg *sync.WaitGroup, quitChan, re
 		}
 	})
 	if err != nil {
-		color.New(color.FgHiYellow).Fprintln(os.Stderr, "❌ context init failed:", err)
-		return
+		return false, fmt.Errorf("context init failed: %w", err)
 	}
 	defer malgoCtx.Uninit() //nolint:errcheck // This is a defer, avoid warning about error return value
 
 	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
 	deviceConfig.Capture.Format = malgo.FormatS16
-	deviceConfig.Capture.Channels = conf.NumChannels
+	deviceConfig.Capture.Channels = uint32(source.CaptureChannels())
 	deviceConfig.SampleRate = conf.SampleRate
 	deviceConfig.Alsa.NoMMap = 1
 
-	var infos []malgo.DeviceInfo
-
 	// Get list of capture sources
-	infos, err = malgoCtx.Devices(malgo.Capture)
+	infos, err := malgoCtx.Devices(malgo.Capture)
 	if err != nil {
-		color.New(color.FgHiYellow).Fprintln(os.Stderr, "❌ Error getting capture devices:", err)
-		return
+		return false, fmt.Errorf("error getting capture devices: %w", err)
 	}
 
 	// Select the capture source based on the settings
-	captureSource, err := selectCaptureSource(settings, infos)
+	captureSource, err := selectCaptureSource(source.Device, infos, verbose)
 	if err != nil {
-		color.New(color.FgHiYellow).Fprintln(os.Stderr, "❌ Error selecting capture source:", err)
-		return
+		return false, fmt.Errorf("error selecting capture source: %w", err)
 	}
 	deviceConfig.Capture.DeviceID = captureSource.Pointer
 
-	// Initialize the filter chain
-	if err := InitializeFilterChain(settings); err != nil {
-		log.Printf("❌ Error initializing filter chain: %v", err)
-	}
-
-	onReceiveFrames := func(pSample2, pSamples []byte, framecount uint32) {
-		// Apply audio EQ filters if enabled
-		if settings.Realtime.Audio.Equalizer.Enabled {
-			err := ApplyFilters(pSamples)
-			if err != nil {
-				log.Printf("❌ Error applying audio EQ filters: %v", err)
-			}
-		}
+	// Route the audio to the analysis sources of the sound card
+	writer := newSourceWriter(&source)
 
-		err = WriteToAnalysisBuffer("malgo", pSamples)
-		if err != nil {
-			log.Printf("❌ Error writing to analysis buffer: %v", err)
-		}
-		err = WriteToCaptureBuffer("malgo", pSamples)
-		if err != nil {
-			log.Printf("❌ Error writing to capture buffer: %v", err)
-		}
+	var lastData atomic.Int64 // Unix nanoseconds of the last received frames
+	lastData.Store(time.Now().UnixNano())
 
-		// Calculate audio level
-		audioLevelData := calculateAudioLevel(pSamples)
+	onReceiveFrames := func(pSample2, pSamples []byte, framecount uint32) {
+		lastData.Store(time.Now().UnixNano())
 
-		// Send level to channel (non-blocking)
-		select {
-		case audioLevelChan <- audioLevelData:
-			// Data sent successfully
-		default:
-			// Channel is full, clear the channel
-			for len(audioLevelChan) > 0 {
-				<-audioLevelChan
-			}
-			// Try to send the new data
-			audioLevelChan <- audioLevelData
+		// Write to the analysis and capture buffers of each channel
+		if err := writer.write(pSamples, audioLevelChan); err != nil {
+			log.Printf("❌ %v", err)
 		}
 	}
 
 	// onStopDevice is called when the device stops, either normally or unexpectedly
+	deviceStopped := make(chan struct{}, 1)
 	onStopDevice := func() {
-		go func() {
-			select {
-			case <-quitChan:
-				// Quit signal has been received, do not attempt to restart
-				return
-			case <-time.After(100 * time.Millisecond):
-				// Wait a bit before restarting to avoid potential rapid restart loops
-				if settings.Debug {
-					fmt.Println("🔄 Attempting to restart audio device.")
-				}
-				err := device.Start()
-				if err != nil {
-					log.Printf("❌ Failed to restart audio device: %v", err)
-					log.Println("🔄 Attempting full audio context restart in 1 second.")
-					time.Sleep(1 * time.Second)
-					restartChan <- struct{}{}
-				} else if settings.Debug {
-					fmt.Println("🔄 Audio device restarted successfully.")
-				}
-			}
-		}()
+		select {
+		case deviceStopped <- struct{}{}:
+		default:
+		}
 	}
 
 	// Device callback to assign function to call when audio data is received
@@ -545,20 +591,20 @@ func captureAudioMalgo(settings *conf.Settings, wg *sync.WaitGroup, quitChan, re
 	}
 
 	// Initialize the capture device
-	device, err = malgo.InitDevice(malgoCtx.Context, deviceConfig, deviceCallbacks)
+	device, err := malgo.InitDevice(malgoCtx.Context, deviceConfig, deviceCallbacks)
 	if err != nil {
-		color.New(color.FgHiYellow).Fprintln(os.Stderr, "❌ Device initialization failed:", err)
-		conf.PrintUserInfo()
-		return
+		if verbose {
+			conf.PrintUserInfo()
+		}
+		return false, fmt.Errorf("device initialization failed: %w", err)
 	}
+	defer device.Uninit()
 
 	if settings.Debug {
 		fmt.Println("Starting device")
 	}
-	err = device.Start()
-	if err != nil {
-		color.New(color.FgHiYellow).Fprintln(os.Stderr, "❌ Device start failed:", err)
-		return
+	if err := device.Start(); err != nil {
+		return false, fmt.Errorf("device start failed: %w", err)
 	}
 	defer device.Stop() //nolint:errcheck // This is a defer, avoid warning about error return value
 
@@ -566,11 +612,11 @@ func captureAudioMalgo(settings *conf.Settings, wg *sync.WaitGroup, quitChan, re
 		fmt.Println("Device started")
 	}
 	// print audio device we are attached to
-	color.New(color.FgHiGreen).Printf("Listening on source: %s (%s)\n", captureSource.Name, captureSource.ID)
+	color.New(color.FgHiGreen).Printf("Listening on source %s: %s (%s)\n", source.Name, captureSource.Name, captureSource.ID)
+
+	watchdog := time.NewTicker(time.Second)
+	defer watchdog.Stop()
 
-	// Now, instead of directly waiting on QuitChannel,
-	// check if it's closed in a non-blocking select.
-	// This loop will keep running until QuitChannel is closed.
 	for {
 		select {
 		case <-quitChan:
@@ -578,17 +624,38 @@ func captureAudioMalgo(settings *conf.Settings, wg *sync.WaitGroup, quitChan, re
 			if settings.Debug {
 				fmt.Println("🛑 Stopping audio capture due to quit signal.")
 			}
-			return
-		case <-restartChan:
-			// Handle restart signal
-			if settings.Debug {
-				fmt.Println("🔄 Restarting audio capture.")
+			return true, nil
+		case <-stopChan:
+			// Source was removed or changed in settings
+			return true, nil
+		case <-deviceStopped:
+			return true, fmt.Errorf("device %s stopped", captureSource.Name)
+		case <-watchdog.C:
+			if time.Since(time.Unix(0, lastData.Load())) > soundCardWatchdogTimeout {
+				return true, fmt.Errorf("no audio data from device %s for %v", captureSource.Name, soundCardWatchdogTimeout)
 			}
-			return
+		}
+	}
+}
+
+// sendAudioLevel calculates the audio level of the samples and sends it to the level channel
+// without blocking
+func sendAudioLevel(audioLevelChan chan AudioLevelData, source string, samples []byte) {
+	audioLevelData := calculateAudioLevel(samples)
+	audioLevelData.Source = source
+
+	select {
+	case audioLevelChan <- audioLevelData:
+		// Data sent successfully
+	default:
+		// Channel is full, drop the oldest level and try once more
+		select {
+		case <-audioLevelChan:
+		default:
+		}
+		select {
+		case audioLevelChan <- audioLevelData:
 		default:
-			// Do nothing and continue with the loop.
-			// This default case prevents blocking if quitChan is not closed yet.
-			time.Sleep(100 * time.Millisecond)
 		}
 	}
 }
@@ -136,8 +136,9 @@ func WriteToCaptureBuffer(source string, data []byte) error {
 	return nil
 }
 
-// ReadSegmentFromCaptureBuffer extracts a segment of audio data from the buffer for a given source.
-func ReadSegmentFromCaptureBuffer(source string, requestedStartTime time.Time, duration int) ([]byte, error) {
+// ReadSegmentFromCaptureBuffer extracts the audio data between the requested start and end time
+// from the buffer for a given source.
+func ReadSegmentFromCaptureBuffer(source string, requestedStartTime, requestedEndTime time.Time) ([]byte, error) {
 	cbMutex.RLock()
 	cb, exists := captureBuffers[source]
 	cbMutex.RUnlock()
@@ -146,7 +147,7 @@ func ReadSegmentFromCaptureBuffer(source string, requestedStartTime time.Time, d
 		return nil, fmt.Errorf("no capture buffer found for source: %s", source)
 	}
 
-	return cb.ReadSegment(requestedStartTime, duration)
+	return cb.ReadSegment(requestedStartTime, requestedEndTime)
 }
 
 // NewCaptureBuffer initializes a new CaptureBuffer with timestamp tracking
@@ -196,25 +197,29 @@ func (cb *CaptureBuffer) Write(data []byte) {
 	}
 }
 
+// byteOffset converts a time offset from the start of the buffer to a byte offset aligned to
+// whole samples.
+func (cb *CaptureBuffer) byteOffset(offset time.Duration) int {
+	return int(offset*time.Duration(cb.sampleRate)/time.Second) * cb.bytesPerSample
+}
+
 // ReadSegment extracts a segment of audio data based on precise start and end times, handling wraparounds.
 // It waits until the current time is past the requested end time.
-func (cb *CaptureBuffer) ReadSegment(requestedStartTime time.Time, duration int) ([]byte, error) {
-	requestedEndTime := requestedStartTime.Add(time.Duration(duration) * time.Second)
-
+func (cb *CaptureBuffer) ReadSegment(requestedStartTime, requestedEndTime time.Time) ([]byte, error) {
 	for {
 		cb.lock.Lock()
 
 		startOffset := requestedStartTime.Sub(cb.startTime)
 		endOffset := requestedEndTime.Sub(cb.startTime)
 
-		startIndex := int(startOffset.Seconds()) * cb.sampleRate * cb.bytesPerSample
-		endIndex := int(endOffset.Seconds()) * cb.sampleRate * cb.bytesPerSample
+		startIndex := cb.byteOffset(startOffset)
+		endIndex := cb.byteOffset(endOffset)
 
 		startIndex %= cb.bufferSize
 		endIndex %= cb.bufferSize
 
 		if startOffset < 0 {
-			if cb.writeIndex == 0 || cb.writeIndex+int(startOffset.Seconds())*cb.sampleRate*cb.bytesPerSample > cb.bufferSize {
+			if cb.writeIndex == 0 || cb.writeIndex+cb.byteOffset(startOffset) > cb.bufferSize {
 				cb.lock.Unlock()
 				return nil, errors.New("requested start time is outside the buffer's current timeframe")
 			}
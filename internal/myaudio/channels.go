@@ -0,0 +1,121 @@
+// channels.go: routing of mono and multichannel capture to analysis sources
+package myaudio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// sourceWriter writes the audio captured from a source to the buffers of its analysis sources.
+// Multichannel audio is split into one analysis source per channel or downmixed to mono.
+type sourceWriter struct {
+	channels int      // number of interleaved channels in the captured audio
+	split    bool     // true to write each channel to its own analysis source
+	names    []string // analysis source names, one per channel when split
+	applyEQ  bool     // true to apply the equalizer of each analysis source
+	pending  []byte   // partial frame left over from the previous write
+}
+
+// newSourceWriter creates a sourceWriter for an audio source.
+func newSourceWriter(source *conf.AudioSourceSettings) *sourceWriter {
+	return &sourceWriter{
+		channels: source.CaptureChannels(),
+		split:    source.IsSplit(),
+		names:    source.AnalysisSources(),
+		applyEQ:  source.Equalizer.Enabled,
+	}
+}
+
+// write splits or downmixes interleaved 16-bit samples and writes them to the analysis and
+// capture buffers, sending the audio level of each analysis source. Partial frames are kept
+// until the next write.
+func (w *sourceWriter) write(samples []byte, audioLevelChan chan AudioLevelData) error {
+	data := alignFrames(&w.pending, samples, w.channels*conf.BitDepth/8)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var outputs [][]byte
+	switch {
+	case w.channels == 1:
+		outputs = [][]byte{data}
+	case w.split:
+		outputs = splitChannels(data, w.channels)
+	default:
+		outputs = [][]byte{downmixChannels(data, w.channels)}
+	}
+
+	for i, output := range outputs {
+		name := w.names[i]
+
+		// Apply audio EQ filters if enabled
+		if w.applyEQ {
+			if err := ApplyFilters(name, output); err != nil {
+				log.Printf("❌ Error applying audio EQ filters for source %s: %v", name, err)
+			}
+		}
+
+		if err := WriteToAnalysisBuffer(name, output); err != nil {
+			return fmt.Errorf("error writing to analysis buffer for source %s: %w", name, err)
+		}
+		if err := WriteToCaptureBuffer(name, output); err != nil {
+			return fmt.Errorf("error writing to capture buffer for source %s: %w", name, err)
+		}
+
+		// Calculate and send the audio level of this source
+		sendAudioLevel(audioLevelChan, name, output)
+	}
+	return nil
+}
+
+// alignFrames prepends the bytes left over from the previous call and keeps a trailing partial
+// frame for the next call, so that the returned data always holds whole frames.
+func alignFrames(pending *[]byte, data []byte, frameSize int) []byte {
+	if len(*pending) == 0 && len(data)%frameSize == 0 {
+		return data
+	}
+
+	joined := make([]byte, 0, len(*pending)+len(data))
+	joined = append(joined, *pending...)
+	joined = append(joined, data...)
+	*pending = (*pending)[:0]
+	if rest := len(joined) % frameSize; rest != 0 {
+		*pending = append(*pending, joined[len(joined)-rest:]...)
+		joined = joined[:len(joined)-rest]
+	}
+	return joined
+}
+
+// splitChannels deinterleaves 16-bit samples into one mono slice per channel.
+func splitChannels(data []byte, channels int) [][]byte {
+	frames := len(data) / (channels * 2)
+	outputs := make([][]byte, channels)
+	for ch := range outputs {
+		outputs[ch] = make([]byte, frames*2)
+	}
+	for frame := 0; frame < frames; frame++ {
+		for ch := 0; ch < channels; ch++ {
+			offset := (frame*channels + ch) * 2
+			copy(outputs[ch][frame*2:frame*2+2], data[offset:offset+2])
+		}
+	}
+	return outputs
+}
+
+// downmixChannels averages interleaved 16-bit samples of all channels to mono.
+func downmixChannels(data []byte, channels int) []byte {
+	frames := len(data) / (channels * 2)
+	output := make([]byte, frames*2)
+	for frame := 0; frame < frames; frame++ {
+		sum := 0
+		for ch := 0; ch < channels; ch++ {
+			offset := (frame*channels + ch) * 2
+			sum += int(int16(binary.LittleEndian.Uint16(data[offset:])))
+		}
+		binary.LittleEndian.PutUint16(output[frame*2:], uint16(int16(sum/channels)))
+	}
+	return output
+}
@@ -0,0 +1,163 @@
+package myaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/smallnest/ringbuffer"
+)
+
+// pcm16 encodes 16-bit samples as little endian PCM.
+func pcm16(samples ...int16) []byte {
+	data := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(s))
+	}
+	return data
+}
+
+func TestAlignFrames(t *testing.T) {
+	// 16-bit stereo frames of 4 bytes written in pieces that split frames
+	tests := []struct {
+		name        string
+		data        []byte
+		want        []byte
+		wantPending []byte
+	}{
+		{"whole frames", []byte{1, 2, 3, 4, 5, 6, 7, 8}, []byte{1, 2, 3, 4, 5, 6, 7, 8}, nil},
+		{"partial frame kept", []byte{1, 2, 3, 4, 5, 6}, []byte{1, 2, 3, 4}, []byte{5, 6}},
+		{"partial frame completed", []byte{7, 8, 9}, []byte{5, 6, 7, 8}, []byte{9}},
+		{"less than a frame", []byte{10, 11}, nil, []byte{9, 10, 11}},
+		{"frame completed exactly", []byte{12}, []byte{9, 10, 11, 12}, nil},
+	}
+
+	var pending []byte
+	for _, tt := range tests {
+		got := alignFrames(&pending, tt.data, 4)
+		if !bytes.Equal(got, tt.want) || !bytes.Equal(pending, tt.wantPending) {
+			t.Errorf("%s: alignFrames() = %v with %v pending, want %v with %v pending",
+				tt.name, got, pending, tt.want, tt.wantPending)
+		}
+	}
+}
+
+func TestSplitChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		channels int
+		want     [][]byte
+	}{
+		{"stereo", pcm16(1, -1, 2, -2, 3, -3), 2, [][]byte{pcm16(1, 2, 3), pcm16(-1, -2, -3)}},
+		{"four channels", pcm16(1, 2, 3, 4, 5, 6, 7, 8), 4, [][]byte{pcm16(1, 5), pcm16(2, 6), pcm16(3, 7), pcm16(4, 8)}},
+		{"trailing partial frame ignored", append(pcm16(1, 2), 9), 2, [][]byte{pcm16(1), pcm16(2)}},
+	}
+
+	for _, tt := range tests {
+		got := splitChannels(tt.data, tt.channels)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d channels, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for ch := range got {
+			if !bytes.Equal(got[ch], tt.want[ch]) {
+				t.Errorf("%s: channel %d = %v, want %v", tt.name, ch+1, got[ch], tt.want[ch])
+			}
+		}
+	}
+}
+
+func TestDownmixChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		channels int
+		want     []byte
+	}{
+		{"stereo", pcm16(100, 200, -100, -300), 2, pcm16(150, -200)},
+		{"full scale does not overflow", pcm16(32767, 32767, -32768, -32768), 2, pcm16(32767, -32768)},
+		{"opposite full scale", pcm16(32767, -32768), 2, pcm16(0)},
+		{"three channels", pcm16(30000, 30000, 30000, -3, -3, -3), 3, pcm16(30000, -3)},
+	}
+
+	for _, tt := range tests {
+		if got := downmixChannels(tt.data, tt.channels); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: downmixChannels() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// allocateTestBuffers creates analysis and capture buffers for the given sources without
+// loading the settings, and returns a function that reads the analysis buffer of a source.
+func allocateTestBuffers(t *testing.T, names ...string) func(name string) []byte {
+	t.Helper()
+	abMutex.Lock()
+	if analysisBuffers == nil {
+		analysisBuffers = make(map[string]*ringbuffer.RingBuffer)
+		prevData = make(map[string][]byte)
+		warningCounter = make(map[string]int)
+	}
+	for _, name := range names {
+		analysisBuffers[name] = ringbuffer.New(1024)
+	}
+	abMutex.Unlock()
+
+	for _, name := range names {
+		if err := AllocateCaptureBuffer(1, 48000, 2, name); err != nil {
+			t.Fatalf("AllocateCaptureBuffer failed: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, name := range names {
+			RemoveAnalysisBuffer(name)
+			RemoveCaptureBuffer(name)
+		}
+	})
+
+	return func(name string) []byte {
+		abMutex.Lock()
+		defer abMutex.Unlock()
+		data := make([]byte, analysisBuffers[name].Length())
+		analysisBuffers[name].Read(data)
+		return data
+	}
+}
+
+func TestSourceWriter(t *testing.T) {
+	read := allocateTestBuffers(t, "test_ch1", "test_ch2", "test_mix")
+	levels := make(chan AudioLevelData, 10)
+
+	// A split stereo source written with a frame split across two writes
+	split := &sourceWriter{channels: 2, split: true, names: []string{"test_ch1", "test_ch2"}}
+	data := pcm16(1, -1, 2, -2, 3, -3)
+	for _, part := range [][]byte{data[:5], data[5:]} {
+		if err := split.write(part, levels); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if got := read("test_ch1"); !bytes.Equal(got, pcm16(1, 2, 3)) {
+		t.Errorf("channel 1 = %v, want %v", got, pcm16(1, 2, 3))
+	}
+	if got := read("test_ch2"); !bytes.Equal(got, pcm16(-1, -2, -3)) {
+		t.Errorf("channel 2 = %v, want %v", got, pcm16(-1, -2, -3))
+	}
+
+	// A downmixed four channel source
+	mix := &sourceWriter{channels: 4, names: []string{"test_mix"}}
+	if err := mix.write(pcm16(4, 8, 12, 16, -4, -4, -4, -4), levels); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if got := read("test_mix"); !bytes.Equal(got, pcm16(10, -4)) {
+		t.Errorf("downmix = %v, want %v", got, pcm16(10, -4))
+	}
+
+	// Each write of a source reports the level of each of its analysis sources
+	sources := make(map[string]int)
+	for len(levels) > 0 {
+		sources[(<-levels).Source]++
+	}
+	if sources["test_ch1"] != 2 || sources["test_ch2"] != 2 || sources["test_mix"] != 1 {
+		t.Errorf("unexpected audio levels per source %v", sources)
+	}
+}
@@ -71,6 +71,19 @@ func ConvertPCMToWAV(pcmData []byte, sampleRate, bitDepth, numChannels int) ([]b
 	return buf.Bytes(), nil
 }
 
+// ExportAudio saves PCM data as an audio clip in the configured export format. WAV and FLAC
+// are encoded natively, other formats require FFmpeg.
+func ExportAudio(pcmData []byte, outputPath string, settings *conf.AudioSettings) error {
+	switch settings.Export.Type {
+	case "wav":
+		return SavePCMDataToWAV(outputPath, pcmData)
+	case "flac":
+		return SavePCMDataToFLAC(outputPath, pcmData)
+	default:
+		return ExportAudioWithFFmpeg(pcmData, outputPath, settings)
+	}
+}
+
 // SavePCMDataToWAV saves the given PCM data as a WAV file at the specified filePath.
 func SavePCMDataToWAV(filePath string, pcmData []byte) error {
 	// Create the directory structure if it doesn't exist
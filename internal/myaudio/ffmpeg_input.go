@@ -15,14 +15,52 @@ import (
 	"github.com/tphakala/birdnet-go/internal/conf"
 )
 
-// ffmpegProcesses keeps track of running FFmpeg processes for each URL
-// This is used to ensure that only one FFmpeg process is running per RTSP source
+// ffmpegProcesses keeps track of running FFmpeg processes for each source name
+// This is used to ensure that only one FFmpeg process is running per stream source
 var ffmpegProcesses sync.Map
 
 // FFmpegConfig holds the configuration for the FFmpeg command
 type FFmpegConfig struct {
-	URL       string
-	Transport string
+	Source      string // audio source name
+	Type        string // rtsp, http or file
+	URL         string // stream URL or file path
+	Transport   string // RTSP transport protocol
+	Channels    int    // number of channels read from the stream
+	ChannelMode string // split or downmix for multichannel streams
+}
+
+// sourceSettings returns the audio source settings of the stream with the channel layout the
+// stream was started with
+func (config *FFmpegConfig) sourceSettings() conf.AudioSourceSettings {
+	source, _ := conf.Setting().FindAudioSource(config.Source)
+	source.Name = config.Source
+	source.Channels = config.Channels
+	source.ChannelMode = config.ChannelMode
+	return source
+}
+
+// isStreamConfigured reports whether the stream source is still enabled in the settings
+func isStreamConfigured(name string) bool {
+	for _, source := range conf.Setting().EnabledAudioSources() {
+		if source.Name == name && source.IsStream() {
+			return true
+		}
+	}
+	return false
+}
+
+// inputArgs returns the FFmpeg input arguments for the source type
+func (config *FFmpegConfig) inputArgs() []string {
+	switch config.Type {
+	case conf.SourceTypeHTTP:
+		// Reconnect dropped HTTP streams within FFmpeg
+		return []string{"-reconnect", "1", "-reconnect_streamed", "1", "-reconnect_delay_max", "30", "-i", config.URL}
+	case conf.SourceTypeFile:
+		// Read files at their native rate to simulate a live source
+		return []string{"-re", "-i", config.URL}
+	default:
+		return []string{"-rtsp_transport", config.Transport, "-i", config.URL}
+	}
 }
 
 // FFmpegProcess represents a running FFmpeg process
@@ -34,19 +72,19 @@ type FFmpegProcess struct {
 	restartTracker *FFmpegRestartTracker
 }
 
-// audioWatchdog is a struct that keeps track of the last time data was received from the RTSP source
+// audioWatchdog is a struct that keeps track of the last time data was received from the stream source
 type audioWatchdog struct {
 	lastDataTime time.Time
 	mu           sync.Mutex
 }
 
-// FFmpegRestartTracker keeps track of restart information for each RTSP source
+// FFmpegRestartTracker keeps track of restart information for each stream source
 type FFmpegRestartTracker struct {
 	restartCount  int
 	lastRestartAt time.Time
 }
 
-// restartTrackers keeps track of restart information for each RTSP source URL
+// restartTrackers keeps track of restart information for each stream source
 var restartTrackers sync.Map
 
 // backoffStrategy implements an exponential backoff for retries
@@ -157,9 +195,9 @@ func (p *FFmpegProcess) getRestartDelay() time.Duration {
 }
 
 // Cleanup cleans up the FFmpeg process and removes it from the map
-func (p *FFmpegProcess) Cleanup(url string) {
+func (p *FFmpegProcess) Cleanup(source string) {
 	if p == nil || p.cmd == nil || p.cmd.Process == nil {
-		ffmpegProcesses.Delete(url)
+		ffmpegProcesses.Delete(source)
 		return
 	}
 
@@ -188,17 +226,17 @@ func (p *FFmpegProcess) Cleanup(url string) {
 			if err := p.cmd.Process.Kill(); err != nil {
 				// Only log if both kill attempts fail and process still exists
 				if !strings.Contains(err.Error(), "process already finished") {
-					log.Printf("⚠️ Failed to kill FFmpeg process for %s: %v", url, err)
+					log.Printf("⚠️ Failed to kill FFmpeg process for %s: %v", source, err)
 				}
 			}
 		}
 	}
 
-	ffmpegProcesses.Delete(url)
+	ffmpegProcesses.Delete(source)
 }
 
 // startWatchdog starts a goroutine that monitors the audio stream for inactivity
-func (p *FFmpegProcess) startWatchdog(ctx context.Context, url string, watchdog *audioWatchdog) <-chan struct{} {
+func (p *FFmpegProcess) startWatchdog(ctx context.Context, source string, watchdog *audioWatchdog) <-chan struct{} {
 	watchdogDone := make(chan struct{})
 	go func() {
 		defer close(watchdogDone)
@@ -211,18 +249,11 @@ func (p *FFmpegProcess) startWatchdog(ctx context.Context, url string, watchdog
 				return
 			case <-ticker.C:
 				// Check if the stream is still configured
-				settings := conf.Setting()
-				streamConfigured := false
-				for _, configuredURL := range settings.Realtime.RTSP.URLs {
-					if configuredURL == url {
-						streamConfigured = true
-						break
-					}
-				}
+				streamConfigured := isStreamConfigured(source)
 
 				// Only check watchdog timeout if the stream is still configured
 				if streamConfigured && watchdog.timeSinceLastData() > 60*time.Second {
-					log.Printf("⚠️ No data received from RTSP source %s for 60 seconds, triggering restart", url)
+					log.Printf("⚠️ No data received from source %s for 60 seconds, triggering restart", source)
 					return
 				} else if !streamConfigured {
 					return
@@ -234,13 +265,18 @@ func (p *FFmpegProcess) startWatchdog(ctx context.Context, url string, watchdog
 }
 
 // processAudio reads audio data from FFmpeg's stdout and writes it to buffers
-func (p *FFmpegProcess) processAudio(ctx context.Context, url string, restartChan chan struct{}, audioLevelChan chan AudioLevelData) error {
+func (p *FFmpegProcess) processAudio(ctx context.Context, config *FFmpegConfig, restartChan chan struct{}, audioLevelChan chan AudioLevelData) error {
 	// Create a buffer to store audio data
 	buf := make([]byte, 32768)
 	watchdog := &audioWatchdog{lastDataTime: time.Now()}
+	source := config.Source
+
+	// Route the audio to the analysis sources of the stream
+	sourceSettings := config.sourceSettings()
+	writer := newSourceWriter(&sourceSettings)
 
 	// Start watchdog goroutine
-	watchdogDone := p.startWatchdog(ctx, url, watchdog)
+	watchdogDone := p.startWatchdog(ctx, source, watchdog)
 
 	// Continuously process audio data
 	for {
@@ -250,24 +286,15 @@ func (p *FFmpegProcess) processAudio(ctx context.Context, url string, restartCha
 			return nil     // Return nil on normal shutdown
 		case <-watchdogDone:
 			// Check if the stream is still configured before triggering restart
-			settings := conf.Setting()
-			streamConfigured := false
-			for _, configuredURL := range settings.Realtime.RTSP.URLs {
-				if configuredURL == url {
-					streamConfigured = true
-					break
-				}
-			}
-
-			if streamConfigured {
+			if isStreamConfigured(source) {
 				// Trigger restart by sending signal to restartChan
 				select {
 				case restartChan <- struct{}{}:
-					log.Printf("🔄 Watchdog triggered restart for RTSP source %s", url)
+					log.Printf("🔄 Watchdog triggered restart for source %s", source)
 				default:
-					log.Printf("❌ Restart channel full, dropping restart request for %s", url)
+					log.Printf("❌ Restart channel full, dropping restart request for %s", source)
 				}
-				return fmt.Errorf("watchdog detected no data for RTSP source %s", url)
+				return fmt.Errorf("watchdog detected no data for source %s", source)
 			} else {
 				return nil
 			}
@@ -284,40 +311,15 @@ func (p *FFmpegProcess) processAudio(ctx context.Context, url string, restartCha
 				return fmt.Errorf("error reading from ffmpeg: %w", err)
 			}
 
-			// Ensure we don't process more data than we've read
 			if n > 0 {
 				watchdog.update() // Update the watchdog timestamp
-				// Write the audio data to the analysis buffer
-				err = WriteToAnalysisBuffer(url, buf[:n])
-				if err != nil {
-					log.Printf("❌ Error writing to analysis buffer for RTSP source %s: %v", url, err)
-					time.Sleep(1 * time.Second)
-					continue
-				}
 
-				// Write the audio data to the capture buffer
-				err = WriteToCaptureBuffer(url, buf[:n])
-				if err != nil {
-					log.Printf("❌ Error writing to capture buffer for RTSP source %s: %v", url, err)
+				// Write the audio data to the analysis and capture buffers
+				if err := writer.write(buf[:n], audioLevelChan); err != nil {
+					log.Printf("❌ %v", err)
 					time.Sleep(1 * time.Second)
 					continue
 				}
-
-				// Calculate audio level
-				audioLevelData := calculateAudioLevel(buf[:n])
-
-				// Send level to channel (non-blocking)
-				select {
-				case audioLevelChan <- audioLevelData:
-					// Data sent successfully
-				default:
-					// Channel is full, clear the channel
-					for len(audioLevelChan) > 0 {
-						<-audioLevelChan
-					}
-					// Try to send the new data
-					audioLevelChan <- audioLevelData
-				}
 			}
 		}
 	}
@@ -334,20 +336,19 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 	ctx, cancel := context.WithCancel(ctx)
 
 	// Get the FFmpeg-compatible values for sample rate, channels, and bit depth
-	ffmpegSampleRate, ffmpegNumChannels, ffmpegFormat := getFFmpegFormat(conf.SampleRate, conf.NumChannels, conf.BitDepth)
+	ffmpegSampleRate, ffmpegNumChannels, ffmpegFormat := getFFmpegFormat(conf.SampleRate, config.Channels, conf.BitDepth)
 
 	// Prepare the FFmpeg command with appropriate arguments
-	cmd := exec.CommandContext(ctx, settings.FfmpegPath,
-		"-rtsp_transport", config.Transport, // Set RTSP transport protocol
-		"-i", config.URL, // Input URL
+	args := append(config.inputArgs(), // Input options and URL
 		"-loglevel", "error", // Set log level to error
 		"-vn",              // Disable video
 		"-f", ffmpegFormat, // Set output format to signed 16-bit little-endian
 		"-ar", ffmpegSampleRate, // Set audio sample rate to 48kHz
-		"-ac", ffmpegNumChannels, // Set number of audio channels to 1 (mono)
+		"-ac", ffmpegNumChannels, // Set number of audio channels, 1 (mono) unless the source is multichannel
 		"-hide_banner", // Hide the banner
 		"pipe:1",       // Output to stdout
 	)
+	cmd := exec.CommandContext(ctx, settings.FfmpegPath, args...)
 
 	// Set up platform-specific process group
 	setupProcessGroup(cmd)
@@ -363,8 +364,8 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 		return nil, fmt.Errorf("error creating ffmpeg pipe: %w", err)
 	}
 
-	// Log the FFmpeg command for debugging purposes
-	log.Println("⬆️  Starting ffmpeg with command:", cmd.String())
+	// Log the FFmpeg command for debugging purposes, without credentials of the URL
+	log.Printf("⬆️  Starting ffmpeg for source %s: %s", config.Source, strings.Replace(cmd.String(), config.URL, conf.SanitizeStreamURL(config.URL), 1))
 
 	// Start the FFmpeg process
 	if err := cmd.Start(); err != nil {
@@ -380,7 +381,7 @@ func startFFmpeg(ctx context.Context, config FFmpegConfig) (*FFmpegProcess, erro
 		if err != nil {
 			// Don't log if process was killed (normal shutdown) or context was cancelled
 			if !strings.Contains(err.Error(), "signal: killed") && !errors.Is(err, context.Canceled) {
-				log.Printf("⚠️ FFmpeg process for RTSP source %s exited with error: %v", config.URL, err)
+				log.Printf("⚠️ FFmpeg process for source %s exited with error: %v", config.Source, err)
 				// Include stderr in the error if available
 				if stderrBuf.String() != "" {
 					log.Printf("⚠️ FFmpeg process stderr:\n%v", stderrBuf.String())
@@ -410,23 +411,14 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 
 	for {
 		// Check if the stream is still configured before starting/restarting
-		settings := conf.Setting()
-		streamConfigured := false
-		for _, url := range settings.Realtime.RTSP.URLs {
-			if url == config.URL {
-				streamConfigured = true
-				break
-			}
-		}
-
-		if !streamConfigured {
+		if !isStreamConfigured(config.Source) {
 			// Remove the process from the map if it exists
-			if process, exists := ffmpegProcesses.Load(config.URL); exists {
+			if process, exists := ffmpegProcesses.Load(config.Source); exists {
 				if p, ok := process.(*FFmpegProcess); ok {
-					p.Cleanup(config.URL)
+					p.Cleanup(config.Source)
 				}
 			}
-			ffmpegProcesses.Delete(config.URL)
+			ffmpegProcesses.Delete(config.Source)
 			return nil
 		}
 
@@ -434,7 +426,7 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 		process, err := startFFmpeg(ctx, config)
 		if err != nil {
 			// Clean up our nil placeholder from the ffmpegProcesses map
-			ffmpegProcesses.Delete(config.URL)
+			ffmpegProcesses.Delete(config.Source)
 
 			// Get the next delay duration and check if we should retry
 			delay, retry := backoff.nextDelay()
@@ -444,7 +436,7 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 			}
 
 			// Log the failure and the next retry attempt
-			log.Printf("⚠️ Failed to start FFmpeg for RTSP source %s: %v. Retrying in %v...", config.URL, err, delay)
+			log.Printf("⚠️ Failed to start FFmpeg for source %s: %v. Retrying in %v...", config.Source, err, delay)
 
 			// Wait for either the context to be cancelled or the delay to pass
 			select {
@@ -461,64 +453,52 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 		backoff.reset()
 
 		// Store the process in the map
-		ffmpegProcesses.Store(config.URL, process)
+		ffmpegProcesses.Store(config.Source, process)
 
 		// Start processing audio and wait for it to finish or for a restart signal
 		processDone := make(chan error, 1)
 		go func() {
-			processDone <- process.processAudio(ctx, config.URL, restartChan, audioLevelChan)
+			processDone <- process.processAudio(ctx, &config, restartChan, audioLevelChan)
 		}()
 
 		select {
 		case <-ctx.Done():
 			// Context cancelled, stop the FFmpeg process
-			log.Printf("🔴 Context cancelled, stopping FFmpeg for RTSP source %s.", config.URL)
-			process.Cleanup(config.URL)
+			log.Printf("🔴 Context cancelled, stopping FFmpeg for source %s.", config.Source)
+			process.Cleanup(config.Source)
 			return ctx.Err()
 
 		case err := <-processDone:
 			// FFmpeg process or audio processing ended
-			process.Cleanup(config.URL)
+			process.Cleanup(config.Source)
 
 			// Check if the stream is still configured before handling the error
-			settings := conf.Setting()
-			streamConfigured := false
-			for _, url := range settings.Realtime.RTSP.URLs {
-				if url == config.URL {
-					streamConfigured = true
-					break
-				}
-			}
-
-			if !streamConfigured {
-				ffmpegProcesses.Delete(config.URL)
+			if !isStreamConfigured(config.Source) {
+				ffmpegProcesses.Delete(config.Source)
 				return nil
 			}
 
 			if err != nil && !errors.Is(err, context.Canceled) {
-				log.Printf("⚠️ FFmpeg process for RTSP source %s ended unexpectedly: %v", config.URL, err)
+				log.Printf("⚠️ FFmpeg process for source %s ended unexpectedly: %v", config.Source, err)
+			}
+
+			// A file source has been read completely, do not analyze it again
+			if err == nil && config.Type == conf.SourceTypeFile {
+				log.Printf("✅ Finished reading file source %s", config.Source)
+				return nil
 			}
 
 		case <-restartChan:
 			// Restart signal received
-			log.Printf("🔄 Restart signal received, restarting FFmpeg for RTSP source %s.", config.URL)
-			process.Cleanup(config.URL)
+			log.Printf("🔄 Restart signal received, restarting FFmpeg for source %s.", config.Source)
+			process.Cleanup(config.Source)
 			backoff.reset()
 		}
 
 		// Check configuration again before waiting for restart
-		settings = conf.Setting()
-		streamConfigured = false
-		for _, url := range settings.Realtime.RTSP.URLs {
-			if url == config.URL {
-				streamConfigured = true
-				break
-			}
-		}
-
-		if !streamConfigured {
-			log.Printf("🛑 Stream %s is no longer configured, stopping lifecycle manager", config.URL)
-			ffmpegProcesses.Delete(config.URL)
+		if !isStreamConfigured(config.Source) {
+			log.Printf("🛑 Stream %s is no longer configured, stopping lifecycle manager", config.Source)
+			ffmpegProcesses.Delete(config.Source)
 			return nil
 		}
 
@@ -533,7 +513,7 @@ func manageFfmpegLifecycle(ctx context.Context, config FFmpegConfig, restartChan
 		case <-time.After(delay):
 			// Continue to next iteration after delay
 		case <-restartChan:
-			log.Printf("🔄 Restart signal received during restart delay, restarting FFmpeg for RTSP source %s immediately.", config.URL)
+			log.Printf("🔄 Restart signal received during restart delay, restarting FFmpeg for source %s immediately.", config.Source)
 			continue
 		}
 	}
@@ -551,24 +531,20 @@ func getExitCode(err error) int {
 	return -1
 }
 
-// CaptureAudioRTSP is the main function for capturing audio from an RTSP stream
-func CaptureAudioRTSP(url, transport string, wg *sync.WaitGroup, quitChan <-chan struct{}, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
+// CaptureAudioStream is the main function for capturing audio from an RTSP, HTTP or file source
+func CaptureAudioStream(config FFmpegConfig, wg *sync.WaitGroup, quitChan <-chan struct{}, restartChan chan struct{}, audioLevelChan chan AudioLevelData) {
 	// Ensure the WaitGroup is decremented when the function exits
 	defer wg.Done()
+	// Mark the stream inactive when it ends so it can be started again
+	defer activeStreams.CompareAndDelete(config.Source, config)
 
 	// Return with error if FFmpeg path is not set
 	if conf.GetFfmpegBinaryName() == "" {
-		log.Printf("❌ FFmpeg is not available, cannot capture audio from RTSP source %s.", url)
+		log.Printf("❌ FFmpeg is not available, cannot capture audio from source %s.", config.Source)
 		log.Printf("⚠️ Please make sure FFmpeg is installed and included in system PATH.")
 		return
 	}
 
-	// Create a configuration for FFmpeg
-	config := FFmpegConfig{
-		URL:       url,
-		Transport: transport,
-	}
-
 	// Create a new context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	// Ensure the cancel function is called when the function exits
@@ -576,30 +552,32 @@ func CaptureAudioRTSP(url, transport string, wg *sync.WaitGroup, quitChan <-chan
 
 	// Start a goroutine to handle the quit signal
 	go func() {
-		// Wait for a signal on the quit channel
-		<-quitChan
-		// Log that a quit signal was received
-		log.Printf("🔴 Quit signal received, stopping FFmpeg for RTSP source %s.", url)
-		// Cancel the context to stop all operations
-		cancel()
+		select {
+		case <-quitChan:
+			// Log that a quit signal was received
+			log.Printf("🔴 Quit signal received, stopping FFmpeg for source %s.", config.Source)
+			// Cancel the context to stop all operations
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
 
 	// Manage the FFmpeg lifecycle
 	err := manageFfmpegLifecycle(ctx, config, restartChan, audioLevelChan)
 	// If an error occurred and it's not due to context cancellation, log it and report to user
 	if err != nil && !errors.Is(err, context.Canceled) {
-		log.Printf("⚠️ FFmpeg lifecycle manager for RTSP source %s exited with error: %v", url, err)
+		log.Printf("⚠️ FFmpeg lifecycle manager for source %s exited with error: %v", config.Source, err)
 	}
 }
 
-// audioWatchdog is a struct that keeps track of the last time data was received from the RTSP source
+// audioWatchdog is a struct that keeps track of the last time data was received from the stream source
 func (w *audioWatchdog) update() {
 	w.mu.Lock()
 	w.lastDataTime = time.Now()
 	w.mu.Unlock()
 }
 
-// timeSinceLastData returns the time since the last data was received from the RTSP source
+// timeSinceLastData returns the time since the last data was received from the stream source
 func (w *audioWatchdog) timeSinceLastData() time.Duration {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -0,0 +1,418 @@
+// flac_encode.go: native FLAC encoder for audio clip export
+package myaudio
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	flacBlockSize         = 4096 // samples per channel in a frame
+	flacMaxPartitionOrder = 8    // maximum Rice partition order
+	flacMaxFixedOrder     = 4    // highest order of the fixed predictors
+	flacRiceParamLimit    = 14   // highest Rice parameter of the 4-bit coding method
+)
+
+// flacSampleRateCodes maps sample rates to their frame header codes, other rates are read
+// from the stream info.
+var flacSampleRateCodes = map[int]uint64{
+	8000: 4, 16000: 5, 22050: 6, 24000: 7, 32000: 8, 44100: 9, 48000: 10, 96000: 11,
+}
+
+// flacSampleSizeCodes maps bit depths to their frame header codes.
+var flacSampleSizeCodes = map[int]uint64{8: 1, 12: 2, 16: 4, 20: 5, 24: 6}
+
+// SavePCMDataToFLAC saves the given PCM data as a FLAC file at the specified filePath.
+func SavePCMDataToFLAC(filePath string, pcmData []byte) error {
+	data, err := EncodePCMToFLAC(pcmData, conf.SampleRate, conf.BitDepth, conf.NumChannels)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	// Write to a temporary file first so that a partial clip is never visible
+	tempFilePath := filePath + tempExt
+	if err := os.WriteFile(tempFilePath, data, 0o644); err != nil {
+		os.Remove(tempFilePath)
+		return fmt.Errorf("failed to write FLAC file: %w", err)
+	}
+	return finalizeOutput(tempFilePath)
+}
+
+// EncodePCMToFLAC encodes interleaved little-endian PCM data as a FLAC stream. Each subframe
+// is stored with the best fitting fixed predictor and Rice coded residuals, or verbatim if
+// the audio does not compress.
+func EncodePCMToFLAC(pcmData []byte, sampleRate, bitDepth, numChannels int) ([]byte, error) {
+	if bitDepth != 16 && bitDepth != 24 {
+		return nil, fmt.Errorf("unsupported bit depth for FLAC encoding: %d", bitDepth)
+	}
+	if numChannels < 1 || numChannels > 8 {
+		return nil, fmt.Errorf("unsupported number of channels for FLAC encoding: %d", numChannels)
+	}
+	if sampleRate <= 0 || sampleRate >= 1<<20 {
+		return nil, fmt.Errorf("unsupported sample rate for FLAC encoding: %d", sampleRate)
+	}
+
+	bytesPerSample := bitDepth / 8
+	frameBytes := bytesPerSample * numChannels
+	totalSamples := len(pcmData) / frameBytes
+	if totalSamples == 0 {
+		return nil, fmt.Errorf("no audio data to encode")
+	}
+	pcmData = pcmData[:totalSamples*frameBytes]
+
+	channels := make([][]int32, numChannels)
+	for ch := range channels {
+		channels[ch] = make([]int32, totalSamples)
+	}
+	for i := 0; i < totalSamples; i++ {
+		for ch := range channels {
+			offset := i*frameBytes + ch*bytesPerSample
+			if bitDepth == 16 {
+				channels[ch][i] = int32(int16(binary.LittleEndian.Uint16(pcmData[offset:])))
+			} else {
+				v := int32(pcmData[offset]) | int32(pcmData[offset+1])<<8 | int32(pcmData[offset+2])<<16
+				channels[ch][i] = v << 8 >> 8 // sign extend
+			}
+		}
+	}
+
+	var frames []byte
+	minFrameSize, maxFrameSize := 0, 0
+	for start, number := 0, uint64(0); start < totalSamples; start, number = start+flacBlockSize, number+1 {
+		end := min(start+flacBlockSize, totalSamples)
+		block := make([][]int32, numChannels)
+		for ch := range channels {
+			block[ch] = channels[ch][start:end]
+		}
+
+		frame := encodeFLACFrame(block, number, sampleRate, bitDepth)
+		if minFrameSize == 0 || len(frame) < minFrameSize {
+			minFrameSize = len(frame)
+		}
+		maxFrameSize = max(maxFrameSize, len(frame))
+		frames = append(frames, frame...)
+	}
+
+	// The last block may be shorter, the stream info only describes the regular block size
+	blockSize := min(flacBlockSize, totalSamples)
+	md5sum := md5.Sum(pcmData) // MD5 of the samples, which for 16 and 24 bit is the PCM data itself
+
+	w := &flacBitWriter{}
+	w.writeBytes([]byte("fLaC"))
+	w.writeBits(1, 1)   // last metadata block
+	w.writeBits(0, 7)   // STREAMINFO
+	w.writeBits(34, 24) // block length
+	w.writeBits(uint64(blockSize), 16)
+	w.writeBits(uint64(blockSize), 16)
+	w.writeBits(uint64(minFrameSize), 24)
+	w.writeBits(uint64(maxFrameSize), 24)
+	w.writeBits(uint64(sampleRate), 20)
+	w.writeBits(uint64(numChannels-1), 3)
+	w.writeBits(uint64(bitDepth-1), 5)
+	w.writeBits(uint64(totalSamples), 36)
+	w.writeBytes(md5sum[:])
+	w.writeBytes(frames)
+
+	return w.bytes(), nil
+}
+
+// encodeFLACFrame encodes one block of samples of all channels as a FLAC frame.
+func encodeFLACFrame(block [][]int32, number uint64, sampleRate, bitDepth int) []byte {
+	blockSize := len(block[0])
+	w := &flacBitWriter{}
+
+	// Frame header, fixed block size stream with independent channels
+	w.writeBits(0x3FFE, 14)
+	w.writeBits(0, 1) // reserved
+	w.writeBits(0, 1) // fixed block size
+	var blockSizeBits uint
+	switch {
+	case blockSize == flacBlockSize:
+		w.writeBits(12, 4) // 256 * 2^(12-8)
+	case blockSize <= 256:
+		w.writeBits(6, 4)
+		blockSizeBits = 8
+	default:
+		w.writeBits(7, 4)
+		blockSizeBits = 16
+	}
+	w.writeBits(flacSampleRateCodes[sampleRate], 4)
+	w.writeBits(uint64(len(block)-1), 4)
+	w.writeBits(flacSampleSizeCodes[bitDepth], 3)
+	w.writeBits(0, 1) // reserved
+	w.writeUTF8(number)
+	if blockSizeBits > 0 {
+		w.writeBits(uint64(blockSize-1), blockSizeBits)
+	}
+	w.writeBits(uint64(flacCRC8(w.bytes())), 8)
+
+	for _, samples := range block {
+		encodeFLACSubframe(w, samples, uint(bitDepth))
+	}
+
+	w.align()
+	w.writeBits(uint64(flacCRC16(w.bytes())), 16)
+	return w.bytes()
+}
+
+// encodeFLACSubframe writes the samples of one channel using the smallest of the constant,
+// fixed predictor and verbatim encodings.
+func encodeFLACSubframe(w *flacBitWriter, samples []int32, bps uint) {
+	constant := true
+	for _, s := range samples[1:] {
+		if s != samples[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		w.writeBits(0, 8) // padding bit, type CONSTANT, no wasted bits
+		w.writeSigned(samples[0], bps)
+		return
+	}
+
+	// Find the fixed predictor with the smallest residual
+	bestOrder, bestCost := -1, uint64(len(samples))*uint64(bps)
+	var bestResidual []int64
+	var bestParams []uint
+	var bestPartitionOrder int
+	residual := make([]int64, len(samples))
+	for i, s := range samples {
+		residual[i] = int64(s)
+	}
+	for order := 0; order <= flacMaxFixedOrder && order < len(samples); order++ {
+		if order > 0 {
+			// Each order is the difference of the residual of the previous order
+			for i := len(samples) - 1; i >= order; i-- {
+				residual[i] -= residual[i-1]
+			}
+		}
+		partitionOrder, params, cost := riceParameters(residual[order:], len(samples), order)
+		cost += uint64(order) * uint64(bps)
+		if cost < bestCost {
+			bestOrder, bestCost = order, cost
+			bestResidual = append(bestResidual[:0], residual[order:]...)
+			bestParams, bestPartitionOrder = params, partitionOrder
+		}
+	}
+
+	if bestOrder < 0 {
+		w.writeBits(1<<1, 8) // padding bit, type VERBATIM, no wasted bits
+		for _, s := range samples {
+			w.writeSigned(s, bps)
+		}
+		return
+	}
+
+	w.writeBits(uint64(0x08|bestOrder)<<1, 8) // padding bit, type FIXED with order, no wasted bits
+	for _, s := range samples[:bestOrder] {
+		w.writeSigned(s, bps)
+	}
+	writeRiceResidual(w, bestResidual, bestParams, bestPartitionOrder, bestOrder)
+}
+
+// riceParameters selects the Rice partition order and the parameter of each partition for a
+// residual, and estimates the number of bits the coded residual needs.
+func riceParameters(residual []int64, blockSize, order int) (partitionOrder int, params []uint, cost uint64) {
+	// Partitions must split the block evenly and the first one must hold more than the
+	// warm-up samples
+	maxOrder := 0
+	for maxOrder < flacMaxPartitionOrder && blockSize%(2<<maxOrder) == 0 && blockSize>>(maxOrder+1) > order {
+		maxOrder++
+	}
+
+	// Sums of the zigzag coded residual of the finest partitions, merged for coarser orders
+	sums := make([]uint64, 1<<maxOrder)
+	partitionSize := blockSize >> maxOrder
+	for i, r := range residual {
+		sums[(i+order)/partitionSize] += zigzag(r)
+	}
+
+	cost = ^uint64(0)
+	for po := maxOrder; po >= 0; po-- {
+		n := blockSize >> po
+		poParams := make([]uint, len(sums))
+		poCost := uint64(6) // coding method and partition order
+		for p, sum := range sums {
+			count := n
+			if p == 0 {
+				count -= order
+			}
+			k := riceParameter(sum, count)
+			poParams[p] = k
+			poCost += 5 + uint64(count)*uint64(k+1) + sum>>k
+		}
+		if poCost < cost {
+			partitionOrder, params, cost = po, poParams, poCost
+		}
+
+		// Merge neighbouring partitions for the next coarser order
+		for p := 0; p < len(sums)/2; p++ {
+			sums[p] = sums[2*p] + sums[2*p+1]
+		}
+		sums = sums[:len(sums)/2]
+	}
+	return partitionOrder, params, cost
+}
+
+// riceParameter returns the Rice parameter for count values whose zigzag codes sum to sum.
+func riceParameter(sum uint64, count int) uint {
+	if count <= 0 {
+		return 0
+	}
+	k := uint(0)
+	for k < 30 && uint64(count)<<(k+1) < sum {
+		k++
+	}
+	return k
+}
+
+// writeRiceResidual writes a partitioned Rice coded residual of a block whose first order
+// samples are warm-up samples.
+func writeRiceResidual(w *flacBitWriter, residual []int64, params []uint, partitionOrder, order int) {
+	paramBits := uint(4)
+	for _, k := range params {
+		if k > flacRiceParamLimit {
+			paramBits = 5
+			break
+		}
+	}
+	w.writeBits(uint64(paramBits-4), 2) // coding method, RICE or RICE2
+	w.writeBits(uint64(partitionOrder), 4)
+
+	partitionSize := (len(residual) + order) >> partitionOrder
+	i := 0
+	for p, k := range params {
+		n := partitionSize
+		if p == 0 {
+			n -= order
+		}
+		w.writeBits(uint64(k), paramBits)
+		for _, r := range residual[i : i+n] {
+			u := zigzag(r)
+			w.writeUnary(u >> k)
+			w.writeBits(u&(1<<k-1), k)
+		}
+		i += n
+	}
+}
+
+// zigzag maps signed values to unsigned ones, 0, -1, 1, -2 ... to 0, 1, 2, 3 ...
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// flacBitWriter writes values of arbitrary bit length, most significant bit first.
+type flacBitWriter struct {
+	buf   []byte
+	acc   uint64 // pending bits, right aligned
+	nbits uint   // number of pending bits, always less than 8 between writes
+}
+
+// writeBits writes the n lowest bits of v, n must be at most 56.
+func (w *flacBitWriter) writeBits(v uint64, n uint) {
+	if n == 0 {
+		return
+	}
+	w.acc = w.acc<<n | v&(1<<n-1)
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.nbits -= 8
+		w.buf = append(w.buf, byte(w.acc>>w.nbits))
+	}
+	w.acc &= 1<<w.nbits - 1
+}
+
+// writeSigned writes v as a two's complement value of n bits.
+func (w *flacBitWriter) writeSigned(v int32, n uint) {
+	w.writeBits(uint64(int64(v)), n)
+}
+
+// writeUnary writes q zero bits followed by a one bit.
+func (w *flacBitWriter) writeUnary(q uint64) {
+	for ; q >= 32; q -= 32 {
+		w.writeBits(0, 32)
+	}
+	w.writeBits(1, uint(q)+1)
+}
+
+// writeUTF8 writes v in the extended UTF-8 coding used for FLAC frame numbers.
+func (w *flacBitWriter) writeUTF8(v uint64) {
+	if v < 0x80 {
+		w.writeBits(v, 8)
+		return
+	}
+	// Number of continuation bytes, each holding 6 bits
+	n := uint(1)
+	for v >= 1<<(6*n+6-n) {
+		n++
+	}
+	w.writeBits(0xFF00>>(n+1)&0xFF|v>>(6*n), 8)
+	for i := n; i > 0; i-- {
+		w.writeBits(0x80|(v>>(6*(i-1)))&0x3F, 8)
+	}
+}
+
+// writeBytes writes whole bytes.
+func (w *flacBitWriter) writeBytes(data []byte) {
+	if w.nbits == 0 {
+		w.buf = append(w.buf, data...)
+		return
+	}
+	for _, b := range data {
+		w.writeBits(uint64(b), 8)
+	}
+}
+
+// align pads the output with zero bits to the next byte boundary.
+func (w *flacBitWriter) align() {
+	if w.nbits > 0 {
+		w.writeBits(0, 8-w.nbits)
+	}
+}
+
+// bytes returns the complete bytes written so far.
+func (w *flacBitWriter) bytes() []byte {
+	return w.buf
+}
+
+// flacCRC8 returns the CRC-8 of a frame header, polynomial x^8 + x^2 + x + 1.
+func flacCRC8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// flacCRC16 returns the CRC-16 of a frame, polynomial x^16 + x^15 + x^2 + 1.
+func flacCRC16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
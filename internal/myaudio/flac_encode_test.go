@@ -0,0 +1,83 @@
+package myaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/tphakala/flac"
+)
+
+func TestEncodePCMToFLACRoundTrip(t *testing.T) {
+	// A tone, silence and noise exercise the fixed predictor, constant and verbatim subframes,
+	// the sample count leaves a short last block
+	const numSamples = 3*flacBlockSize + 1000
+	rng := rand.New(rand.NewSource(1))
+	pcm := make([]byte, 0, numSamples*2)
+	for i := 0; i < numSamples; i++ {
+		var sample int16
+		switch {
+		case i < flacBlockSize:
+			sample = int16(12000*math.Sin(2*math.Pi*3000*float64(i)/48000) + rng.NormFloat64()*50)
+		case i < 2*flacBlockSize:
+			sample = 0
+		default:
+			sample = int16(rng.Intn(65536) - 32768)
+		}
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(sample))
+	}
+
+	encoded, err := EncodePCMToFLAC(pcm, 48000, 16, 1)
+	if err != nil {
+		t.Fatalf("EncodePCMToFLAC failed: %v", err)
+	}
+	if len(encoded) >= len(pcm) {
+		t.Errorf("expected FLAC to be smaller than PCM, got %d >= %d bytes", len(encoded), len(pcm))
+	}
+
+	decoder, err := flac.NewDecoder(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("failed to decode stream info: %v", err)
+	}
+	if decoder.SampleRate != 48000 || decoder.NChannels != 1 || decoder.BitsPerSample != 16 || decoder.TotalSamples != numSamples {
+		t.Errorf("unexpected stream info: %+v", decoder.StreamInfo)
+	}
+
+	var decoded []byte
+	for {
+		frame, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to decode frame: %v", err)
+		}
+		decoded = append(decoded, frame...)
+	}
+	if !bytes.Equal(decoded, pcm) {
+		t.Error("decoded audio differs from the encoded PCM data")
+	}
+}
+
+func TestFLACBitWriterUTF8(t *testing.T) {
+	tests := []struct {
+		value uint64
+		want  []byte
+	}{
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0xC2, 0x80}},
+		{0x7FF, []byte{0xDF, 0xBF}},
+		{0x800, []byte{0xE0, 0xA0, 0x80}},
+		{0x10000, []byte{0xF0, 0x90, 0x80, 0x80}},
+	}
+	for _, tt := range tests {
+		w := &flacBitWriter{}
+		w.writeUTF8(tt.value)
+		if !bytes.Equal(w.bytes(), tt.want) {
+			t.Errorf("writeUTF8(%#x) = %x, want %x", tt.value, w.bytes(), tt.want)
+		}
+	}
+}
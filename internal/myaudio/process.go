@@ -86,7 +86,8 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 		// Results enqueued successfully
 	default:
 		log.Println("❌ Results queue is full!")
-		// Queue is full
+		// Queue is full, count the dropped results for telemetry
+		queue.RecordDroppedResults()
 	}
 	return nil
 }
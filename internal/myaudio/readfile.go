@@ -73,6 +73,34 @@ func ReadAudioFileBuffered(settings *conf.Settings, callback AudioChunkCallback)
 	}
 }
 
+// ReadAudioFile reads a complete WAV or FLAC file into float32 samples at conf.SampleRate.
+func ReadAudioFile(filePath string) ([]float32, error) {
+	info, err := GetAudioInfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read consecutive chunks without overlap
+	settings := &conf.Settings{}
+	settings.Input.Path = filePath
+	var samples []float32
+	if err := ReadAudioFileBuffered(settings, func(chunk []float32) error {
+		samples = append(samples, chunk...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	// Drop the padding of the last chunk
+	if info.SampleRate > 0 {
+		total := int(int64(info.TotalSamples) * conf.SampleRate / int64(info.SampleRate))
+		if total < len(samples) {
+			samples = samples[:total]
+		}
+	}
+	return samples, nil
+}
+
 // getAudioDivisor returns the appropriate divisor for converting samples based on bit depth
 func getAudioDivisor(bitDepth int) (float32, error) {
 	switch bitDepth {
@@ -0,0 +1,310 @@
+// spectrogram.go: native STFT spectrogram renderer
+package myaudio
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// SpectrogramOptions controls how a spectrogram is rendered.
+type SpectrogramOptions struct {
+	Width        int     // image width in pixels
+	Height       int     // image height in pixels
+	FFTSize      int     // FFT window size in samples, a power of two
+	MinFreq      int     // lowest frequency shown in Hz
+	MaxFreq      int     // highest frequency shown in Hz, 0 for the Nyquist frequency
+	ColorMap     string  // inferno, magma, viridis or grayscale
+	DynamicRange float64 // range in dB below the loudest bin that is shown
+	Format       string  // output image format, png or webp
+
+	Annotation *SpectrogramAnnotation // optional overlay, nil for none
+}
+
+// SpectrogramAnnotation marks the expected frequency band of a species and the prediction
+// windows of a detection on a spectrogram.
+type SpectrogramAnnotation struct {
+	BandMinFreq int                 // lower edge of the band in Hz
+	BandMaxFreq int                 // upper edge of the band in Hz, 0 for no band
+	Windows     []SpectrogramWindow // prediction windows
+}
+
+// SpectrogramWindow is a time span in seconds from the start of the audio.
+type SpectrogramWindow struct {
+	Start float64
+	End   float64
+}
+
+// Colours of the annotation overlay, chosen to stand out against all colour maps
+var (
+	spectrogramBandColor   = color.RGBA{0, 255, 255, 255}
+	spectrogramWindowColor = color.RGBA{255, 255, 255, 255}
+)
+
+// spectrogramColorMaps defines the colour maps by evenly spaced control points.
+var spectrogramColorMaps = map[string][]color.RGBA{
+	"inferno": {
+		{0, 0, 4, 255}, {40, 11, 84, 255}, {101, 21, 110, 255}, {159, 42, 99, 255},
+		{212, 72, 66, 255}, {245, 125, 21, 255}, {250, 193, 39, 255}, {252, 255, 164, 255},
+	},
+	"magma": {
+		{0, 0, 4, 255}, {28, 16, 68, 255}, {79, 18, 123, 255}, {129, 37, 129, 255},
+		{181, 54, 122, 255}, {229, 80, 100, 255}, {251, 135, 97, 255}, {254, 194, 135, 255},
+		{252, 253, 191, 255},
+	},
+	"viridis": {
+		{68, 1, 84, 255}, {72, 40, 120, 255}, {62, 74, 137, 255}, {49, 104, 142, 255},
+		{38, 130, 142, 255}, {31, 158, 137, 255}, {53, 183, 121, 255}, {109, 205, 89, 255},
+		{180, 222, 44, 255}, {253, 231, 37, 255},
+	},
+	"grayscale": {
+		{0, 0, 0, 255}, {255, 255, 255, 255},
+	},
+}
+
+// IsSpectrogramColorMap reports whether name is a supported spectrogram colour map.
+func IsSpectrogramColorMap(name string) bool {
+	_, ok := spectrogramColorMaps[name]
+	return ok
+}
+
+// Validate checks the options, MaxFreq is limited to the Nyquist frequency of sampleRate.
+func (o *SpectrogramOptions) Validate(sampleRate int) error {
+	if o.Width < 1 || o.Width > vp8lMaxDimension || o.Height < 1 || o.Height > vp8lMaxDimension {
+		return fmt.Errorf("invalid spectrogram size %dx%d", o.Width, o.Height)
+	}
+	if o.FFTSize < 64 || o.FFTSize > 16384 || o.FFTSize&(o.FFTSize-1) != 0 {
+		return fmt.Errorf("spectrogram FFT size must be a power of two between 64 and 16384, got %d", o.FFTSize)
+	}
+	if o.MaxFreq <= 0 || o.MaxFreq > sampleRate/2 {
+		o.MaxFreq = sampleRate / 2
+	}
+	if o.MinFreq < 0 || o.MinFreq >= o.MaxFreq {
+		return fmt.Errorf("invalid spectrogram frequency range %d-%d Hz", o.MinFreq, o.MaxFreq)
+	}
+	if !IsSpectrogramColorMap(o.ColorMap) {
+		return fmt.Errorf("unknown spectrogram colour map: %s", o.ColorMap)
+	}
+	if o.DynamicRange <= 0 {
+		return fmt.Errorf("spectrogram dynamic range must be positive")
+	}
+	if o.Format != "png" && o.Format != "webp" {
+		return fmt.Errorf("unsupported spectrogram format: %s", o.Format)
+	}
+	return nil
+}
+
+// CreateSpectrogram renders the spectrogram of a WAV or FLAC file and saves it to outputPath.
+func CreateSpectrogram(audioPath, outputPath string, opts SpectrogramOptions) error {
+	samples, err := ReadAudioFile(audioPath)
+	if err != nil {
+		return err
+	}
+
+	img, err := RenderSpectrogram(samples, conf.SampleRate, opts)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeSpectrogram(&buf, img, opts.Format); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create spectrogram directory: %w", err)
+	}
+	tempFilePath := outputPath + tempExt
+	if err := os.WriteFile(tempFilePath, buf.Bytes(), 0o644); err != nil {
+		os.Remove(tempFilePath)
+		return fmt.Errorf("failed to write spectrogram: %w", err)
+	}
+	return finalizeOutput(tempFilePath)
+}
+
+// EncodeSpectrogram writes a spectrogram image as png or webp.
+func EncodeSpectrogram(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "webp":
+		return EncodeWebP(w, img)
+	default:
+		return fmt.Errorf("unsupported spectrogram format: %s", format)
+	}
+}
+
+// RenderSpectrogram renders the short-time Fourier transform of mono samples. Each image
+// column shows the loudest Hann windowed frames of its time slice, each row the loudest FFT
+// bin of its frequency band, on a dB scale relative to the loudest bin of the image.
+func RenderSpectrogram(samples []float32, sampleRate int, opts SpectrogramOptions) (*image.RGBA, error) {
+	if err := opts.Validate(sampleRate); err != nil {
+		return nil, err
+	}
+
+	n := opts.FFTSize
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+
+	// Frames overlap by half a window, columns cover at least one frame
+	columnSamples := float64(len(samples)) / float64(opts.Width)
+	framesPerColumn := max(1, int(math.Ceil(columnSamples/float64(n/2))))
+
+	// Frequency bins of each image row, from the highest frequency at the top
+	binHz := float64(sampleRate) / float64(n)
+	freqPerRow := float64(opts.MaxFreq-opts.MinFreq) / float64(opts.Height)
+	rowBins := make([][2]int, opts.Height)
+	for y := range rowBins {
+		high := float64(opts.MaxFreq) - freqPerRow*float64(y)
+		low := high - freqPerRow
+		lo := int(math.Round(low / binHz))
+		hi := max(lo+1, int(math.Round(high/binHz)))
+		rowBins[y] = [2]int{min(lo, n/2), min(hi, n/2+1)}
+	}
+
+	levels := make([]float64, opts.Width*opts.Height)
+	power := make([]float64, n/2+1)
+	frame := make([]complex128, n)
+	peak := math.Inf(-1)
+	for x := 0; x < opts.Width; x++ {
+		for i := range power {
+			power[i] = 0
+		}
+		for f := 0; f < framesPerColumn; f++ {
+			center := int(columnSamples*(float64(x)+(float64(f)+0.5)/float64(framesPerColumn))) - n/2
+			for i := range frame {
+				var s float64
+				if j := center + i; j >= 0 && j < len(samples) {
+					s = float64(samples[j])
+				}
+				frame[i] = complex(s*window[i], 0)
+			}
+			fft(frame)
+			for i := range power {
+				power[i] = math.Max(power[i], real(frame[i])*real(frame[i])+imag(frame[i])*imag(frame[i]))
+			}
+		}
+
+		for y, bins := range rowBins {
+			var p float64
+			for _, v := range power[bins[0]:bins[1]] {
+				p = math.Max(p, v)
+			}
+			db := 10 * math.Log10(p+1e-12)
+			levels[y*opts.Width+x] = db
+			peak = math.Max(peak, db)
+		}
+	}
+
+	palette := spectrogramPalette(opts.ColorMap)
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	floor := peak - opts.DynamicRange
+	for i, db := range levels {
+		v := math.Min(1, math.Max(0, (db-floor)/opts.DynamicRange))
+		img.SetRGBA(i%opts.Width, i/opts.Width, palette[int(v*255)])
+	}
+
+	if opts.Annotation != nil {
+		duration := float64(len(samples)) / float64(sampleRate)
+		drawSpectrogramAnnotation(img, opts, duration)
+	}
+	return img, nil
+}
+
+// drawSpectrogramAnnotation draws the band edges as dashed lines across the image and each
+// prediction window as a bar along the top edge. Overlapping windows alternate between two
+// lanes so that their boundaries stay visible.
+func drawSpectrogramAnnotation(img *image.RGBA, opts SpectrogramOptions, duration float64) {
+	a := opts.Annotation
+
+	if a.BandMaxFreq > a.BandMinFreq {
+		freqRow := func(freq int) int {
+			return int(math.Round(float64(opts.MaxFreq-freq) / float64(opts.MaxFreq-opts.MinFreq) * float64(opts.Height)))
+		}
+		for _, freq := range []int{a.BandMinFreq, a.BandMaxFreq} {
+			if freq < opts.MinFreq || freq > opts.MaxFreq {
+				continue
+			}
+			y := min(freqRow(freq), opts.Height-1)
+			for x := 0; x < opts.Width; x++ {
+				if x/4%2 == 0 {
+					img.SetRGBA(x, y, spectrogramBandColor)
+				}
+			}
+		}
+	}
+
+	if duration <= 0 {
+		return
+	}
+	const barHeight, laneHeight = 3, 5
+	for i, w := range a.Windows {
+		x0 := max(0, int(math.Round(w.Start/duration*float64(opts.Width))))
+		x1 := min(opts.Width-1, int(math.Round(w.End/duration*float64(opts.Width)))-1)
+		top := 1 + i%2*laneHeight
+		for y := top; y < min(top+barHeight, opts.Height); y++ {
+			for x := x0; x <= x1; x++ {
+				img.SetRGBA(x, y, spectrogramWindowColor)
+			}
+		}
+	}
+}
+
+// spectrogramPalette interpolates a colour map to 256 colours.
+func spectrogramPalette(name string) [256]color.RGBA {
+	points := spectrogramColorMaps[name]
+	var palette [256]color.RGBA
+	for i := range palette {
+		pos := float64(i) / 255 * float64(len(points)-1)
+		j := min(int(pos), len(points)-2)
+		t := pos - float64(j)
+		a, b := points[j], points[j+1]
+		palette[i] = color.RGBA{
+			R: uint8(math.Round(float64(a.R) + t*(float64(b.R)-float64(a.R)))),
+			G: uint8(math.Round(float64(a.G) + t*(float64(b.G)-float64(a.G)))),
+			B: uint8(math.Round(float64(a.B) + t*(float64(b.B)-float64(a.B)))),
+			A: 255,
+		}
+	}
+	return palette
+}
+
+// fft computes the discrete Fourier transform of x in place, len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package myaudio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func testSpectrogramOptions() SpectrogramOptions {
+	return SpectrogramOptions{
+		Width:        200,
+		Height:       100,
+		FFTSize:      1024,
+		MinFreq:      0,
+		MaxFreq:      12000,
+		ColorMap:     "grayscale",
+		DynamicRange: 80,
+		Format:       "png",
+	}
+}
+
+func TestRenderSpectrogramTone(t *testing.T) {
+	// A 6 kHz tone lies in the middle row of a 0-12 kHz spectrogram
+	const sampleRate = 48000
+	samples := make([]float32, 3*sampleRate)
+	for i := range samples {
+		samples[i] = float32(0.5 * math.Sin(2*math.Pi*6000*float64(i)/sampleRate))
+	}
+
+	img, err := RenderSpectrogram(samples, sampleRate, testSpectrogramOptions())
+	if err != nil {
+		t.Fatalf("RenderSpectrogram failed: %v", err)
+	}
+	if img.Bounds().Dx() != 200 || img.Bounds().Dy() != 100 {
+		t.Fatalf("unexpected image size %v", img.Bounds())
+	}
+
+	brightest, brightestRow := uint8(0), -1
+	for y := 0; y < 100; y++ {
+		if v := img.RGBAAt(100, y).R; v > brightest {
+			brightest, brightestRow = v, y
+		}
+	}
+	if brightestRow < 48 || brightestRow > 51 {
+		t.Errorf("expected tone near row 50, brightest row is %d", brightestRow)
+	}
+	if v := img.RGBAAt(100, 10).R; v > brightest/2 {
+		t.Errorf("expected dark background above the tone, got %d", v)
+	}
+}
+
+func TestRenderSpectrogramAnnotation(t *testing.T) {
+	// Silence renders in a single colour of the map, distinct from the annotation colours
+	const sampleRate = 48000
+	samples := make([]float32, 3*sampleRate)
+	opts := testSpectrogramOptions()
+	opts.ColorMap = "inferno"
+	opts.Annotation = &SpectrogramAnnotation{
+		BandMinFreq: 3000,
+		BandMaxFreq: 6000,
+		Windows:     []SpectrogramWindow{{Start: 0, End: 1.5}, {Start: 1.5, End: 3}},
+	}
+
+	img, err := RenderSpectrogram(samples, sampleRate, opts)
+	if err != nil {
+		t.Fatalf("RenderSpectrogram failed: %v", err)
+	}
+
+	// Band edges at 6 kHz and 3 kHz are rows 50 and 75 of a 0-12 kHz image
+	for _, y := range []int{50, 75} {
+		if img.RGBAAt(0, y) != spectrogramBandColor {
+			t.Errorf("expected band edge at row %d", y)
+		}
+	}
+	if img.RGBAAt(0, 60) == spectrogramBandColor {
+		t.Error("unexpected band colour inside the band")
+	}
+
+	// The first window covers the left half in the first lane, the second the right half
+	// in the second lane
+	if img.RGBAAt(50, 1) != spectrogramWindowColor || img.RGBAAt(150, 1) == spectrogramWindowColor {
+		t.Error("first window drawn in the wrong place")
+	}
+	if img.RGBAAt(150, 6) != spectrogramWindowColor || img.RGBAAt(50, 6) == spectrogramWindowColor {
+		t.Error("second window drawn in the wrong place")
+	}
+}
+
+func TestSpectrogramOptionsValidate(t *testing.T) {
+	opts := testSpectrogramOptions()
+	opts.MaxFreq = 0
+	if err := opts.Validate(48000); err != nil || opts.MaxFreq != 24000 {
+		t.Errorf("expected max frequency to default to Nyquist, got %d (%v)", opts.MaxFreq, err)
+	}
+
+	invalid := []func(o *SpectrogramOptions){
+		func(o *SpectrogramOptions) { o.FFTSize = 1000 },
+		func(o *SpectrogramOptions) { o.MinFreq = 12000 },
+		func(o *SpectrogramOptions) { o.ColorMap = "rainbow" },
+		func(o *SpectrogramOptions) { o.Format = "gif" },
+		func(o *SpectrogramOptions) { o.Width = 0 },
+	}
+	for i, modify := range invalid {
+		opts := testSpectrogramOptions()
+		modify(&opts)
+		if err := opts.Validate(48000); err == nil {
+			t.Errorf("case %d: expected invalid options to be rejected", i)
+		}
+	}
+}
+
+func TestEncodeSpectrogram(t *testing.T) {
+	samples := make([]float32, 48000)
+	for i := range samples {
+		samples[i] = float32(math.Sin(float64(i) / 3))
+	}
+	opts := testSpectrogramOptions()
+	opts.ColorMap = "inferno"
+	img, err := RenderSpectrogram(samples, 48000, opts)
+	if err != nil {
+		t.Fatalf("RenderSpectrogram failed: %v", err)
+	}
+
+	var pngData bytes.Buffer
+	if err := EncodeSpectrogram(&pngData, img, "png"); err != nil {
+		t.Fatalf("PNG encoding failed: %v", err)
+	}
+	if _, err := png.Decode(&pngData); err != nil {
+		t.Errorf("invalid PNG: %v", err)
+	}
+
+	var webpData bytes.Buffer
+	if err := EncodeSpectrogram(&webpData, img, "webp"); err != nil {
+		t.Fatalf("WebP encoding failed: %v", err)
+	}
+	data := webpData.Bytes()
+	if len(data) < 25 || string(data[0:4]) != "RIFF" || string(data[8:16]) != "WEBPVP8L" || data[20] != vp8lSignature {
+		t.Fatalf("invalid WebP header: %x", data[:min(len(data), 25)])
+	}
+	if size := binary.LittleEndian.Uint32(data[4:8]); int(size) != len(data)-8 {
+		t.Errorf("RIFF size %d does not match file size %d", size, len(data))
+	}
+	// Image size is stored as 14 bit values after the signature
+	header := binary.LittleEndian.Uint32(data[21:25])
+	if width, height := header&0x3fff+1, header>>14&0x3fff+1; width != 200 || height != 100 {
+		t.Errorf("WebP size %dx%d, want 200x100", width, height)
+	}
+}
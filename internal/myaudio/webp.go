@@ -0,0 +1,304 @@
+// webp.go: lossless WebP encoder for spectrogram images
+package myaudio
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+const (
+	vp8lSignature     = 0x2f
+	vp8lMaxDimension  = 1 << 14
+	vp8lMaxCodeLength = 15 // maximum length of the pixel prefix codes
+	vp8lMaxCLCLength  = 7  // maximum length of the code length prefix code
+	vp8lGreenSymbols  = 256 + 24
+	vp8lDistSymbols   = 40
+)
+
+// vp8lCodeLengthOrder is the order in which the code length code lengths are stored.
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP writes an image as a lossless WebP file. Pixels are stored as prefix coded
+// literals without transforms, which suits images with few distinct colour values like
+// spectrograms.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return fmt.Errorf("invalid WebP image size %dx%d", width, height)
+	}
+
+	// Collect the pixels and the symbol counts of each channel
+	pixels := make([]color.NRGBA, 0, width*height)
+	var green, red, blue, alpha [256]int
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pixels = append(pixels, p)
+			green[p.G]++
+			red[p.R]++
+			blue[p.B]++
+			alpha[p.A]++
+			opaque = opaque && p.A == 0xff
+		}
+	}
+
+	bw := &vp8lBitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if opaque {
+		bw.writeBits(0, 1) // alpha is not used
+	} else {
+		bw.writeBits(1, 1)
+	}
+	bw.writeBits(0, 3) // version
+	bw.writeBits(0, 1) // no transforms
+	bw.writeBits(0, 1) // no colour cache
+	bw.writeBits(0, 1) // no meta prefix codes
+
+	greenCounts := make([]int, vp8lGreenSymbols)
+	copy(greenCounts, green[:])
+	greenCode := writePrefixCode(bw, greenCounts)
+	redCode := writePrefixCode(bw, red[:])
+	blueCode := writePrefixCode(bw, blue[:])
+	alphaCode := writePrefixCode(bw, alpha[:])
+	writePrefixCode(bw, make([]int, vp8lDistSymbols)) // distances are not used
+
+	for _, p := range pixels {
+		greenCode.write(bw, int(p.G))
+		redCode.write(bw, int(p.R))
+		blueCode.write(bw, int(p.B))
+		alphaCode.write(bw, int(p.A))
+	}
+	data := bw.flush()
+
+	// RIFF container with a single VP8L chunk, chunks are padded to an even size
+	padded := len(data) + len(data)%2
+	header := make([]byte, 0, 20)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(4+8+padded))
+	header = append(header, "WEBPVP8L"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if len(data) < padded {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// prefixCode is a canonical prefix code of an alphabet.
+type prefixCode struct {
+	lengths []int
+	codes   []uint32 // codes bit reversed for the LSB first bit stream
+	single  bool     // only one symbol is used, it is coded with zero bits
+}
+
+// newPrefixCode builds the canonical code for the given code lengths.
+func newPrefixCode(lengths []int) *prefixCode {
+	c := &prefixCode{lengths: lengths, codes: make([]uint32, len(lengths))}
+
+	used := 0
+	var lengthCounts [vp8lMaxCodeLength + 1]int
+	for _, l := range lengths {
+		if l > 0 {
+			lengthCounts[l]++
+			used++
+		}
+	}
+	c.single = used == 1
+
+	var nextCode [vp8lMaxCodeLength + 2]uint32
+	for l := 1; l <= vp8lMaxCodeLength; l++ {
+		nextCode[l+1] = (nextCode[l] + uint32(lengthCounts[l])) << 1
+	}
+	for symbol, l := range lengths {
+		if l > 0 {
+			c.codes[symbol] = bits.Reverse32(nextCode[l]) >> (32 - l)
+			nextCode[l]++
+		}
+	}
+	return c
+}
+
+// write writes the code of a symbol.
+func (c *prefixCode) write(bw *vp8lBitWriter, symbol int) {
+	if !c.single {
+		bw.writeBits(c.codes[symbol], c.lengths[symbol])
+	}
+}
+
+// writePrefixCode writes the prefix code for the given symbol counts and returns it. Alphabets
+// with at most two used symbols below 256 are stored as simple codes.
+func writePrefixCode(bw *vp8lBitWriter, counts []int) *prefixCode {
+	var used []int
+	for symbol, count := range counts {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) == 0 {
+		used = append(used, 0)
+	}
+
+	if len(used) <= 2 && used[len(used)-1] < 256 {
+		lengths := make([]int, len(counts))
+		bw.writeBits(1, 1) // simple code
+		bw.writeBits(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(used[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.writeBits(uint32(used[1]), 8)
+		}
+		for _, symbol := range used {
+			lengths[symbol] = 1
+		}
+		return newPrefixCode(lengths)
+	}
+
+	lengths := huffmanLengths(counts, vp8lMaxCodeLength)
+	code := newPrefixCode(lengths)
+
+	// The code lengths are stored with a prefix code of their own, using the literal
+	// lengths 0 to 15 only
+	clCounts := make([]int, len(vp8lCodeLengthOrder))
+	for _, l := range lengths {
+		clCounts[l]++
+	}
+	clLengths := huffmanLengths(clCounts, vp8lMaxCLCLength)
+	clCode := newPrefixCode(clLengths)
+
+	numCodes := 4
+	for i, symbol := range vp8lCodeLengthOrder {
+		if clLengths[symbol] > 0 {
+			numCodes = max(numCodes, i+1)
+		}
+	}
+	bw.writeBits(0, 1) // normal code
+	bw.writeBits(uint32(numCodes-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:numCodes] {
+		bw.writeBits(uint32(clLengths[symbol]), 3)
+	}
+	bw.writeBits(0, 1) // code lengths of all symbols follow
+	for _, l := range lengths {
+		clCode.write(bw, l)
+	}
+	return code
+}
+
+// huffmanNode is a node of the tree built by huffmanLengths.
+type huffmanNode struct {
+	count       int
+	symbol      int // leaf symbol, -1 for inner nodes
+	left, right *huffmanNode
+}
+
+// huffmanHeap orders nodes by count.
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int            { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool  { return h[i].count < h[j].count }
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// huffmanLengths returns Huffman code lengths for the symbol counts, limited to maxLength.
+// Counts are flattened until the tree fits the limit. A single used symbol gets length 1.
+func huffmanLengths(counts []int, maxLength int) []int {
+	counts = append([]int(nil), counts...)
+	lengths := make([]int, len(counts))
+
+	for {
+		h := &huffmanHeap{}
+		for symbol, count := range counts {
+			if count > 0 {
+				*h = append(*h, &huffmanNode{count: count, symbol: symbol})
+			}
+		}
+		switch h.Len() {
+		case 0:
+			return lengths
+		case 1:
+			lengths[(*h)[0].symbol] = 1
+			return lengths
+		}
+
+		// Keep equal counts in symbol order for a deterministic tree
+		sort.SliceStable(*h, func(i, j int) bool { return (*h)[i].count < (*h)[j].count })
+		heap.Init(h)
+		for h.Len() > 1 {
+			a := heap.Pop(h).(*huffmanNode)
+			b := heap.Pop(h).(*huffmanNode)
+			heap.Push(h, &huffmanNode{count: a.count + b.count, symbol: -1, left: a, right: b})
+		}
+
+		longest := 0
+		var walk func(n *huffmanNode, depth int)
+		walk = func(n *huffmanNode, depth int) {
+			if n.symbol >= 0 {
+				lengths[n.symbol] = depth
+				longest = max(longest, depth)
+				return
+			}
+			walk(n.left, depth+1)
+			walk(n.right, depth+1)
+		}
+		walk((*h)[0], 0)
+
+		if longest <= maxLength {
+			return lengths
+		}
+		for symbol, count := range counts {
+			if count > 0 {
+				counts[symbol] = count>>1 | 1
+			}
+		}
+	}
+}
+
+// vp8lBitWriter writes values least significant bit first as used by VP8L.
+type vp8lBitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits int
+}
+
+// writeBits writes the n lowest bits of v, n must be at most 32.
+func (w *vp8lBitWriter) writeBits(v uint32, n int) {
+	w.acc |= uint64(v&(1<<n-1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// flush pads the last byte with zero bits and returns the written data.
+func (w *vp8lBitWriter) flush() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,151 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EmailNotifier sends messages by SMTP. With the mailto scheme the connection is upgraded with
+// STARTTLS when the server supports it, with mailtos it uses implicit TLS.
+type EmailNotifier struct {
+	name        string
+	addr        string // host:port of the SMTP server
+	host        string
+	implicitTLS bool
+	username    string
+	password    string
+	from        string
+	to          []string
+}
+
+func newEmailNotifier(name string, u *url.URL) (*EmailNotifier, error) {
+	implicitTLS := strings.EqualFold(u.Scheme, "mailtos")
+
+	port := u.Port()
+	if port == "" {
+		port = "587"
+		if implicitTLS {
+			port = "465"
+		}
+	}
+
+	e := &EmailNotifier{
+		name:        name,
+		addr:        net.JoinHostPort(u.Hostname(), port),
+		host:        u.Hostname(),
+		implicitTLS: implicitTLS,
+		from:        u.Query().Get("from"),
+	}
+	if u.User != nil {
+		e.username = u.User.Username()
+		e.password, _ = u.User.Password()
+	}
+	for _, addr := range strings.Split(u.Query().Get("to"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			e.to = append(e.to, addr)
+		}
+	}
+
+	if e.from == "" {
+		e.from = e.username
+	}
+	if len(e.to) == 0 {
+		e.to = []string{e.from}
+	}
+	if e.from == "" || !strings.Contains(e.from, "@") {
+		return nil, fmt.Errorf("email target %s needs a sender address, set the from parameter", name)
+	}
+	return e, nil
+}
+
+// Name returns the target name.
+func (e *EmailNotifier) Name() string {
+	return e.name
+}
+
+// Send delivers the message to all recipients.
+func (e *EmailNotifier) Send(ctx context.Context, msg *Message) error {
+	dialer := &net.Dialer{Timeout: httpTimeout}
+	var conn net.Conn
+	var err error
+	if e.implicitTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: e.host}}).DialContext(ctx, "tcp", e.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", e.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to SMTP server %s: %w", e.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error starting SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if err := e.deliver(client, msg); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	return client.Quit()
+}
+
+// deliver runs the SMTP transaction on an open session.
+func (e *EmailNotifier) deliver(client *smtp.Client, msg *Message) error {
+	if !e.implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: e.host}); err != nil {
+				return err
+			}
+		}
+	}
+	if e.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.from); err != nil {
+		return err
+	}
+	for _, rcpt := range e.to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.compose(msg)); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// compose builds the RFC 5322 message.
+func (e *EmailNotifier) compose(msg *Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
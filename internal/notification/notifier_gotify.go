@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// GotifyNotifier sends messages to a Gotify server.
+type GotifyNotifier struct {
+	name     string
+	endpoint string // message endpoint URL
+	token    string // application token
+	client   *http.Client
+}
+
+// gotifyMessage is the Gotify message API request body.
+type gotifyMessage struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+func newGotifyNotifier(name string, u *url.URL) (*GotifyNotifier, error) {
+	p := strings.Trim(u.Path, "/")
+	if p == "" {
+		return nil, fmt.Errorf("gotify target %s URL has no application token", name)
+	}
+	// The token is the last path element, anything before it is the server base path
+	base, token := path.Split(p)
+
+	return &GotifyNotifier{
+		name:     name,
+		endpoint: (&url.URL{Scheme: httpScheme(u.Scheme), Host: u.Host, Path: "/" + base + "message"}).String(),
+		token:    token,
+		client:   &http.Client{Timeout: httpTimeout},
+	}, nil
+}
+
+// Name returns the target name.
+func (g *GotifyNotifier) Name() string {
+	return g.name
+}
+
+// Send posts the message to Gotify. Gotify priorities range from 0 to 10,
+// so the 1 to 5 message priority is scaled to match.
+func (g *GotifyNotifier) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(gotifyMessage{
+		Title:    msg.Title,
+		Message:  msg.Body,
+		Priority: msg.Priority * 2,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding gotify message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating gotify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.token)
+
+	if err := doRequest(g.client, req); err != nil {
+		return fmt.Errorf("error sending gotify message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NtfyNotifier publishes messages to an ntfy topic.
+type NtfyNotifier struct {
+	name     string
+	endpoint string // topic URL
+	token    string // access token, sent as a bearer token
+	username string
+	password string
+	client   *http.Client
+}
+
+func newNtfyNotifier(name string, u *url.URL) (*NtfyNotifier, error) {
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" {
+		return nil, fmt.Errorf("ntfy target %s URL has no topic", name)
+	}
+
+	n := &NtfyNotifier{
+		name:     name,
+		endpoint: (&url.URL{Scheme: httpScheme(u.Scheme), Host: u.Host, Path: "/" + topic}).String(),
+		token:    u.Query().Get("token"),
+		client:   &http.Client{Timeout: httpTimeout},
+	}
+	if u.User != nil {
+		n.username = u.User.Username()
+		n.password, _ = u.User.Password()
+	}
+	return n, nil
+}
+
+// Name returns the target name.
+func (n *NtfyNotifier) Name() string {
+	return n.name
+}
+
+// Send publishes the message to the topic.
+func (n *NtfyNotifier) Send(ctx context.Context, msg *Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.endpoint, strings.NewReader(msg.Body))
+	if err != nil {
+		return fmt.Errorf("error creating ntfy request: %w", err)
+	}
+	req.Header.Set("Title", msg.Title)
+	req.Header.Set("Tags", "bird")
+	if msg.Priority > 0 {
+		req.Header.Set("Priority", strconv.Itoa(msg.Priority))
+	}
+	switch {
+	case n.token != "":
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	case n.username != "":
+		req.SetBasicAuth(n.username, n.password)
+	}
+
+	if err := doRequest(n.client, req); err != nil {
+		return fmt.Errorf("error publishing to ntfy: %w", err)
+	}
+	return nil
+}
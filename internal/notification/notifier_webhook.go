@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// WebhookNotifier posts messages as JSON to an HTTP endpoint.
+type WebhookNotifier struct {
+	name     string
+	endpoint string
+	client   *http.Client
+}
+
+// WebhookPayload is the JSON body posted to webhooks.
+type WebhookPayload struct {
+	Title     string         `json:"title"`
+	Message   string         `json:"message"`
+	Priority  int            `json:"priority,omitempty"`
+	Detection datastore.Note `json:"detection"`
+}
+
+func newWebhookNotifier(name string, u *url.URL) *WebhookNotifier {
+	endpoint := *u
+	endpoint.RawQuery = withoutQueryParams(u.Query(), "priority").Encode()
+
+	return &WebhookNotifier{
+		name:     name,
+		endpoint: endpoint.String(),
+		client:   &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Name returns the target name.
+func (w *WebhookNotifier) Name() string {
+	return w.name
+}
+
+// Send posts the message and the detection to the webhook.
+func (w *WebhookNotifier) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(WebhookPayload{
+		Title:     msg.Title,
+		Message:   msg.Body,
+		Priority:  msg.Priority,
+		Detection: msg.Note,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := doRequest(w.client, req); err != nil {
+		return fmt.Errorf("error calling webhook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+// ui.go: notifications shown to the users of the web UI
+package notification
+
+// Notification is a message shown to the users of the web UI. Components that run without
+// the web server send them on a channel that the web server delivers to its clients.
+type Notification struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
@@ -35,10 +35,9 @@ func New(settings *conf.Settings, beginTime, endTime time.Time, species string,
 	scientificName, commonName, speciesCode := ParseSpeciesString(species)
 
 	// detectionTime is time now minus 3 seconds to account for the delay in the detection
-	now := time.Now()
-	date := now.Format("2006-01-02")
-	detectionTime := now.Add(-2 * time.Second)
-	time := detectionTime.Format("15:04:05")
+	detectionTime := time.Now().Add(-2 * time.Second).Truncate(time.Second)
+	date := detectionTime.Format("2006-01-02")
+	clock := detectionTime.Format("15:04:05")
 
 	var audioSource string
 	if settings.Input.Path != "" {
@@ -50,21 +49,36 @@ func New(settings *conf.Settings, beginTime, endTime time.Time, species string,
 	// Round confidence to two decimal places
 	roundedConfidence := math.Round(confidence*100) / 100
 
+	// Use the location and threshold of the audio source when it overrides them
+	latitude, longitude, threshold := settings.BirdNET.Latitude, settings.BirdNET.Longitude, settings.GetThreshold()
+	sourceSettings, channel, exists := settings.FindAnalysisSource(source)
+	if exists {
+		if sourceSettings.Latitude != 0 || sourceSettings.Longitude != 0 {
+			latitude, longitude = sourceSettings.Latitude, sourceSettings.Longitude
+		}
+		if sourceSettings.Threshold > 0 {
+			threshold = sourceSettings.Threshold
+		}
+	}
+
 	// Return a new Note struct populated with the provided parameters as well as the current date and time.
 	return datastore.Note{
 		SourceNode:     settings.Main.Name,           // From the provided configuration settings.
 		Date:           date,                         // Use ISO 8601 date format.
-		Time:           time,                         // Use 24-hour time format.
+		Time:           clock,                        // Use 24-hour time format.
+		Timestamp:      detectionTime.UTC(),          // Detection time in UTC.
+		TimeZone:       settings.TimeZone,            // IANA time zone of this node.
 		Source:         audioSource,                  // From the provided configuration settings.
+		Channel:        channel,                      // Channel of a split multichannel source, 0 otherwise.
 		BeginTime:      beginTime,                    // Start time of the observation.
 		EndTime:        endTime,                      // End time of the observation.
 		SpeciesCode:    speciesCode,                  // Parsed species code.
 		ScientificName: scientificName,               // Parsed scientific name of the species.
 		CommonName:     commonName,                   // Parsed common name of the species.
 		Confidence:     roundedConfidence,            // Confidence score of the observation.
-		Latitude:       settings.BirdNET.Latitude,    // Geographic latitude where the observation was made.
-		Longitude:      settings.BirdNET.Longitude,   // Geographic longitude where the observation was made.
-		Threshold:      settings.BirdNET.Threshold,   // Threshold setting from configuration.
+		Latitude:       latitude,                     // Geographic latitude where the observation was made.
+		Longitude:      longitude,                    // Geographic longitude where the observation was made.
+		Threshold:      threshold,                    // Threshold setting from configuration.
 		Sensitivity:    settings.BirdNET.Sensitivity, // Sensitivity setting from configuration.
 		ClipName:       clipName,                     // Name of the audio clip.
 		ProcessingTime: elapsedTime,                  // Time taken to process the observation.
@@ -106,7 +120,7 @@ func WriteNotesTable(settings *conf.Settings, notes []datastore.Note, filename s
 	var err error
 
 	for i := range notes {
-		if notes[i].Confidence <= settings.BirdNET.Threshold {
+		if notes[i].Confidence <= settings.GetThreshold() {
 			continue // Skip the current iteration as the note doesn't meet the threshold
 		}
 
@@ -168,7 +182,7 @@ func WriteNotesCsv(settings *conf.Settings, notes []datastore.Note, filename str
 	var err error
 
 	for i := range notes {
-		if notes[i].Confidence <= settings.BirdNET.Threshold {
+		if notes[i].Confidence <= settings.GetThreshold() {
 			continue // Skip the current iteration as the note doesn't meet the threshold
 		}
 
@@ -146,3 +146,49 @@ func (sc *SunCalc) GetSunsetTime(date time.Time) (time.Time, error) {
 	}
 	return sunEventTimes.Sunset, nil
 }
+
+// GetSunTime returns the time of a time of day on the date of t, in the location of t
+func (sc *SunCalc) GetSunTime(st conf.SunTime, t time.Time) (time.Time, error) {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if st.Event == "" {
+		return date.Add(st.Offset), nil
+	}
+
+	sunEventTimes, err := sc.GetSunEventTimes(date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get sun event times: %w", err)
+	}
+
+	var eventTime time.Time
+	switch st.Event {
+	case conf.SunEventCivilDawn:
+		eventTime = sunEventTimes.CivilDawn
+	case conf.SunEventSunrise:
+		eventTime = sunEventTimes.Sunrise
+	case conf.SunEventSunset:
+		eventTime = sunEventTimes.Sunset
+	case conf.SunEventCivilDusk:
+		eventTime = sunEventTimes.CivilDusk
+	default:
+		return time.Time{}, fmt.Errorf("unknown sun event: %s", st.Event)
+	}
+	return eventTime.Add(st.Offset), nil
+}
+
+// InPeriod reports whether t is within the period of its day from start to end. A period
+// ending before it starts spans midnight, e.g. from sunset to sunrise.
+func (sc *SunCalc) InPeriod(t time.Time, start, end conf.SunTime) (bool, error) {
+	from, err := sc.GetSunTime(start, t)
+	if err != nil {
+		return false, err
+	}
+	to, err := sc.GetSunTime(end, t)
+	if err != nil {
+		return false, err
+	}
+
+	if from.Before(to) {
+		return !t.Before(from) && t.Before(to), nil
+	}
+	return !t.Before(from) || t.Before(to), nil
+}
@@ -3,6 +3,8 @@ package suncalc
 import (
 	"testing"
 	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
 )
 
 func TestNewSunCalc(t *testing.T) {
@@ -121,3 +123,38 @@ func TestCacheConsistency(t *testing.T) {
 		t.Error("Cached sunrise time doesn't match calculated time")
 	}
 }
+
+func TestInPeriod(t *testing.T) {
+	// Helsinki at the March equinox, sunrise is about 06:15 and sunset about 18:25 local time
+	sc := NewSunCalc(60.1699, 24.9384)
+	eet := time.FixedZone("EET", 2*60*60)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 20, hour, minute, 0, 0, eet)
+	}
+	sunrise, sunset := conf.SunTime{Event: conf.SunEventSunrise}, conf.SunTime{Event: conf.SunEventSunset}
+	tenPM, sixAM := conf.SunTime{Offset: 22 * time.Hour}, conf.SunTime{Offset: 6 * time.Hour}
+
+	tests := []struct {
+		name       string
+		t          time.Time
+		start, end conf.SunTime
+		want       bool
+	}{
+		{"noon during the day", at(12, 0), sunrise, sunset, true},
+		{"noon during the night", at(12, 0), sunset, sunrise, false},
+		{"before sunrise during the night", at(5, 30), sunset, sunrise, true},
+		{"sunrise plus an hour", at(6, 45), conf.SunTime{Event: conf.SunEventSunrise, Offset: time.Hour}, sunset, false},
+		{"late evening in a clock period", at(23, 0), tenPM, sixAM, true},
+		{"early morning in a clock period", at(3, 0), tenPM, sixAM, true},
+		{"afternoon outside a clock period", at(15, 0), tenPM, sixAM, false},
+	}
+	for _, tt := range tests {
+		got, err := sc.InPeriod(tt.t, tt.start, tt.end)
+		if err != nil {
+			t.Fatalf("%s: InPeriod failed: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: InPeriod() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -18,6 +18,7 @@ type Metrics struct {
 	MQTT          *metrics.MQTTMetrics
 	BirdNET       *metrics.BirdNETMetrics
 	ImageProvider *metrics.ImageProviderMetrics
+	Queue         *metrics.QueueMetrics
 }
 
 // NewMetrics creates a new instance of Metrics, initializing all metric collectors.
@@ -40,11 +41,17 @@ func NewMetrics() (*Metrics, error) {
 		return nil, fmt.Errorf("failed to create ImageProvider metrics: %w", err)
 	}
 
+	queueMetrics, err := metrics.NewQueueMetrics(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue metrics: %w", err)
+	}
+
 	m := &Metrics{
 		registry:      registry,
 		MQTT:          mqttMetrics,
 		BirdNET:       birdnetMetrics,
 		ImageProvider: imageProviderMetrics,
+		Queue:         queueMetrics,
 	}
 
 	return m, nil
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// QueueMetrics contains Prometheus metrics of the detection results and action queues.
+type QueueMetrics struct {
+	DepthGauge          *prometheus.GaugeVec
+	DeadLetterGauge     prometheus.Gauge
+	DroppedCounter      *prometheus.CounterVec
+	RetryCounter        *prometheus.CounterVec
+	DeadLetteredCounter *prometheus.CounterVec
+	registry            *prometheus.Registry
+}
+
+// NewQueueMetrics creates a new instance of QueueMetrics.
+// It requires a Prometheus registry to register the metrics.
+// It returns an error if metric registration fails.
+func NewQueueMetrics(registry *prometheus.Registry) (*QueueMetrics, error) {
+	m := &QueueMetrics{registry: registry}
+	m.initMetrics()
+	if err := registry.Register(m); err != nil {
+		return nil, fmt.Errorf("failed to register queue metrics: %w", err)
+	}
+	return m, nil
+}
+
+// initMetrics initializes all metrics for QueueMetrics.
+func (m *QueueMetrics) initMetrics() {
+	m.DepthGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "birdnet_queue_depth",
+			Help: "Number of items waiting in a queue, partitioned by queue: results, actions or persistent.",
+		},
+		[]string{"queue"},
+	)
+	m.DeadLetterGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "birdnet_queue_dead_letters",
+			Help: "Number of actions in the dead letter queue that are no longer retried.",
+		},
+	)
+	m.DroppedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "birdnet_queue_dropped_total",
+			Help: "Total number of items dropped because a queue was full, partitioned by queue.",
+		},
+		[]string{"queue"},
+	)
+	m.RetryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "birdnet_action_retries_total",
+			Help: "Total number of failed action attempts scheduled for retry, partitioned by action.",
+		},
+		[]string{"action"},
+	)
+	m.DeadLetteredCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "birdnet_action_dead_lettered_total",
+			Help: "Total number of actions moved to the dead letter queue, partitioned by action.",
+		},
+		[]string{"action"},
+	)
+}
+
+// SetQueueDepth sets the number of items waiting in a queue.
+func (m *QueueMetrics) SetQueueDepth(queue string, depth float64) {
+	m.DepthGauge.WithLabelValues(queue).Set(depth)
+}
+
+// SetDeadLetters sets the number of actions in the dead letter queue.
+func (m *QueueMetrics) SetDeadLetters(count float64) {
+	m.DeadLetterGauge.Set(count)
+}
+
+// AddDropped adds to the number of items dropped from a queue.
+func (m *QueueMetrics) AddDropped(queue string, count float64) {
+	m.DroppedCounter.WithLabelValues(queue).Add(count)
+}
+
+// IncrementRetries counts a failed action attempt that will be retried.
+func (m *QueueMetrics) IncrementRetries(action string) {
+	m.RetryCounter.WithLabelValues(action).Inc()
+}
+
+// IncrementDeadLettered counts an action moved to the dead letter queue.
+func (m *QueueMetrics) IncrementDeadLettered(action string) {
+	m.DeadLetteredCounter.WithLabelValues(action).Inc()
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *QueueMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.DepthGauge.Describe(ch)
+	ch <- m.DeadLetterGauge.Desc()
+	m.DroppedCounter.Describe(ch)
+	m.RetryCounter.Describe(ch)
+	m.DeadLetteredCounter.Describe(ch)
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *QueueMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.DepthGauge.Collect(ch)
+	ch <- m.DeadLetterGauge
+	m.DroppedCounter.Collect(ch)
+	m.RetryCounter.Collect(ch)
+	m.DeadLetteredCounter.Collect(ch)
+}